@@ -0,0 +1,467 @@
+package azure
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/0x4f53/dossier"
+)
+
+// ========================== Structs ==========================
+
+type AzurePerson struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Date  string `json:"date"`
+}
+
+type AzureCommit struct {
+	CommitID  string      `json:"commitId"`
+	Comment   string      `json:"comment"`
+	Author    AzurePerson `json:"author"`
+	Committer AzurePerson `json:"committer"`
+	RemoteURL string      `json:"remoteUrl"`
+}
+
+type AzureCommitPage struct {
+	Count int           `json:"count"`
+	Value []AzureCommit `json:"value"`
+}
+
+type Repo struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	WebURL        string `json:"webUrl"`
+	DefaultBranch string `json:"defaultBranch"` // "refs/heads/main"
+	IsDisabled    bool   `json:"isDisabled"`
+	IsFork        bool   `json:"isFork"`
+}
+
+type RepoPage struct {
+	Count int    `json:"count"`
+	Value []Repo `json:"value"`
+}
+
+type Project struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type ProjectPage struct {
+	Count int       `json:"count"`
+	Value []Project `json:"value"`
+}
+
+// ========================== Constants ==========================
+
+const providerName = "azure"
+
+// ========================== Scanner ==========================
+
+// AzureScanner holds everything one scan needs, so several can run in a process
+// and tests can supply their own Doer and Reporter
+type AzureScanner struct {
+	*dossier.Engine
+	Token      string
+	APIVersion string // the api-version requested
+}
+
+var _ dossier.Scanner = (*AzureScanner)(nil)
+
+func NewScanner(token string, cfg *dossier.Config, blacklist []*regexp.Regexp) *AzureScanner {
+	s := &AzureScanner{Engine: dossier.NewEngine(providerName, cfg, blacklist), Token: token, APIVersion: "7.1"}
+	s.Reporter = dossier.TextReporter{}
+	s.Authorize = s.authorize
+	return s
+}
+
+// ========================== HTTP Helpers ==========================
+
+func (s *AzureScanner) authorize(req *http.Request) {
+	if s.Token != "" {
+		req.SetBasicAuth("", s.Token) // PATs go in the password with an empty username
+	}
+}
+
+// ========================== Commit Processing ==========================
+
+// commits converts API commits for ProcessCommits. Committers are only
+// listed with --committer-too.
+func (s *AzureScanner) commits(commits []AzureCommit, repoName string) []dossier.Commit {
+	out := make([]dossier.Commit, len(commits))
+	for i, c := range commits {
+		commitTime, _ := time.Parse(time.RFC3339, c.Author.Date)
+		out[i] = dossier.Commit{
+			ID:       c.CommitID,
+			Repo:     repoName,
+			Location: c.RemoteURL,
+			Time:     commitTime,
+			Date:     c.Author.Date,
+			Author:   dossier.Person{Name: c.Author.Name, Email: c.Author.Email},
+			Message:  c.Comment,
+			Text:     fmt.Sprintf("%s %s %s", c.Comment, c.Author.Name, repoName),
+		}
+		if s.Options.CommitterToo {
+			out[i].Committer = dossier.Person{Name: c.Committer.Name, Email: c.Committer.Email}
+		}
+	}
+	return out
+}
+
+// ========================== Repos and Commits ==========================
+
+// apiURL builds a project-scoped REST URL; target is "organization/project"
+func (s *AzureScanner) apiURL(target, path string, query url.Values) string {
+	org, project, _ := strings.Cut(target, "/")
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("api-version", s.APIVersion)
+	return fmt.Sprintf("https://dev.azure.com/%s/%s/_apis/git/%s?%s",
+		url.PathEscape(org), url.PathEscape(project), path, query.Encode())
+}
+
+// GetOrgProjects lists the projects of an organization visible to the token
+func (s *AzureScanner) GetOrgProjects(ctx context.Context, org string) ([]Project, error) {
+	u := fmt.Sprintf("https://dev.azure.com/%s/_apis/projects?%s", url.PathEscape(org), url.Values{"api-version": {s.APIVersion}}.Encode())
+	body, status, _, err := s.Get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if status != 200 {
+		return nil, fmt.Errorf("Azure DevOps API error %d\n%s", status, string(body))
+	}
+	var page ProjectPage
+	if err := dossier.DecodeJSON(u, status, body, &page); err != nil {
+		return nil, err
+	}
+	sort.Slice(page.Value, func(i, j int) bool { return page.Value[i].Name < page.Value[j].Name })
+	return page.Value, nil
+}
+
+// GetProjectRepos lists a project's repos. The repositories API has no sort
+// options; order by name so --repo-limit picks the same repos every run.
+func (s *AzureScanner) GetProjectRepos(ctx context.Context, target string) ([]Repo, error) {
+	u := s.apiURL(target, "repositories", nil)
+	body, status, _, err := s.Get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if status != 200 {
+		return nil, fmt.Errorf("Azure DevOps API error %d\n%s", status, string(body))
+	}
+	var page RepoPage
+	if err := dossier.DecodeJSON(u, status, body, &page); err != nil {
+		return nil, err
+	}
+	sort.Slice(page.Value, func(i, j int) bool { return page.Value[i].Name < page.Value[j].Name })
+	return page.Value, nil
+}
+
+func (s *AzureScanner) commitsURL(target, repoID string, top, skip int, since, until time.Time) string {
+	q := url.Values{}
+	q.Set("searchCriteria.$top", fmt.Sprint(top))
+	q.Set("searchCriteria.$skip", fmt.Sprint(skip))
+	if !since.IsZero() {
+		q.Set("searchCriteria.fromDate", since.Format(time.RFC3339))
+	}
+	if !until.IsZero() {
+		q.Set("searchCriteria.toDate", until.Format(time.RFC3339))
+	}
+	return s.apiURL(target, "repositories/"+url.PathEscape(repoID)+"/commits", q)
+}
+
+// activeSinceRepo checks for at least one commit on or after t, since
+// repositories carry no last-push timestamp.
+func (s *AzureScanner) activeSinceRepo(ctx context.Context, target string, repo Repo, t time.Time) bool {
+	u := s.commitsURL(target, repo.ID, 1, 0, t, time.Time{})
+	body, status, _, err := s.Get(ctx, u)
+	if err != nil || status != 200 {
+		return true // scan it and let the commit walk report the error
+	}
+	var page AzureCommitPage
+	if err := dossier.DecodeJSON(u, status, body, &page); err != nil {
+		return true
+	}
+	return len(page.Value) > 0
+}
+
+// lastActivity returns the date of a repo's newest commit, or the zero time
+// when it has none or the lookup fails
+func (s *AzureScanner) lastActivity(ctx context.Context, target string, repo Repo) time.Time {
+	u := s.commitsURL(target, repo.ID, 1, 0, time.Time{}, time.Time{})
+	body, status, _, err := s.Get(ctx, u)
+	if err != nil || status != 200 {
+		return time.Time{}
+	}
+	var page AzureCommitPage
+	if err := dossier.DecodeJSON(u, status, body, &page); err != nil || len(page.Value) == 0 {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339, page.Value[0].Committer.Date)
+	return t
+}
+
+func (s *AzureScanner) ScanRepoCommits(ctx context.Context, target string, repo Repo, ascending bool) {
+	var allCommits []AzureCommit
+
+	for n := 1; ; n++ {
+		if ctx.Err() != nil {
+			return
+		}
+		if s.PageLimitReached(n, "commits of "+repo.Name) {
+			break
+		}
+		u := s.commitsURL(target, repo.ID, 100, len(allCommits), s.Options.Since, s.Options.Until)
+		body, status, _, err := s.Get(ctx, u)
+		if err != nil {
+			s.RepoFailed(repo.Name, len(allCommits), err)
+			break
+		}
+		if status != 200 {
+			s.RepoFailed(repo.Name, len(allCommits), fmt.Errorf("Azure DevOps API error %d: %s", status, dossier.Truncate(strings.TrimSpace(string(body)), 200)))
+			break
+		}
+
+		var page AzureCommitPage
+		if err := dossier.DecodeJSON(u, status, body, &page); err != nil {
+			s.RepoFailed(repo.Name, len(allCommits), err)
+			break
+		}
+		allCommits = append(allCommits, page.Value...)
+		if len(page.Value) < 100 {
+			break
+		}
+	}
+
+	// The API lists newest first; reverse for oldest first
+	if ascending {
+		for i, j := 0, len(allCommits)-1; i < j; i, j = i+1, j-1 {
+			allCommits[i], allCommits[j] = allCommits[j], allCommits[i]
+		}
+	}
+
+	s.ProcessCommits(s.commits(allCommits, repo.Name))
+}
+
+// ========================== Metadata Files ==========================
+
+// ScanRepoMetadata reads well-known contributor files from the default branch
+func (s *AzureScanner) ScanRepoMetadata(ctx context.Context, target string, repo Repo) {
+	if repo.DefaultBranch == "" {
+		return // empty repository
+	}
+	branch := strings.TrimPrefix(repo.DefaultBranch, "refs/heads/")
+	for _, candidates := range dossier.MetadataFiles {
+		for _, path := range candidates {
+			q := url.Values{}
+			q.Set("path", "/"+path)
+			q.Set("includeContent", "true")
+			q.Set("versionDescriptor.version", branch)
+			u := s.apiURL(target, "repositories/"+url.PathEscape(repo.ID)+"/items", q)
+			body, status, _, err := s.Get(ctx, u)
+			if err != nil || status != 200 {
+				continue // usually a 404: no such file
+			}
+			var item struct {
+				Content string `json:"content"`
+			}
+			if err := dossier.DecodeJSON(u, status, body, &item); err != nil {
+				continue
+			}
+			location := fmt.Sprintf("%s?path=/%s&version=GB%s", repo.WebURL, path, branch)
+			s.ReportMetadata(path, item.Content, location)
+			break
+		}
+	}
+}
+
+// ========================== Profile Mode ==========================
+
+// ScanProfile exists for parity with the other providers: Azure DevOps
+// scans a project, not a user, so there is no public profile to read.
+func (s *AzureScanner) ScanProfile(ctx context.Context, target string) error {
+	dossier.Log.Infof("Azure DevOps has no public user profiles, nothing to report for %s\n", target)
+	return nil
+}
+
+// ========================== Scanning ==========================
+
+// ScanUser scans a project ("org/project") and returns the findings reported along the way
+func (s *AzureScanner) ScanUser(ctx context.Context, target string) ([]dossier.Finding, error) {
+	return s.Collect(func() error { return s.scanUser(ctx, target) })
+}
+
+// ScanOrg is ScanUser for an organization
+func (s *AzureScanner) ScanOrg(ctx context.Context, org string) ([]dossier.Finding, error) {
+	return s.Collect(func() error { return s.scanOrg(ctx, org) })
+}
+
+// ScanRepo is ScanUser for a single repo ("org/project/repo")
+func (s *AzureScanner) ScanRepo(ctx context.Context, ref string) ([]dossier.Finding, error) {
+	return s.Collect(func() error { return s.scanRepo(ctx, ref) })
+}
+
+func (s *AzureScanner) scanUser(ctx context.Context, target string) error {
+	if s.Options.ProfileOnly {
+		return s.ScanProfile(ctx, target)
+	}
+
+	dossier.Log.Infof("Scanning Azure DevOps commits for project: %s\n\n", target)
+
+	repos, err := s.GetProjectRepos(ctx, target)
+	if err != nil {
+		return fmt.Errorf("fetching repos: %w", err)
+	}
+	if s.Options.OrderByActivity {
+		// Repositories carry no push timestamp; ask for each one's newest commit
+		active := map[string]time.Time{}
+		for _, r := range repos {
+			active[r.ID] = s.lastActivity(ctx, target, r)
+		}
+		sort.SliceStable(repos, func(i, j int) bool { return active[repos[i].ID].After(active[repos[j].ID]) })
+	}
+	return s.scanRepos(ctx, target, repos)
+}
+
+// scanOrg scans every project in an organization
+func (s *AzureScanner) scanOrg(ctx context.Context, org string) error {
+	projects, err := s.GetOrgProjects(ctx, org)
+	if err != nil {
+		return fmt.Errorf("fetching projects: %w", err)
+	}
+	for _, p := range projects {
+		if err := s.scanUser(ctx, org+"/"+p.Name); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			dossier.Log.Errorf("Error scanning %s/%s: %v\n", org, p.Name, err)
+		}
+	}
+	return nil
+}
+
+// scanRepo scans a single repository, given as "org/project/repo"
+func (s *AzureScanner) scanRepo(ctx context.Context, ref string) error {
+	org, rest, _ := strings.Cut(ref, "/")
+	project, name, ok := strings.Cut(rest, "/")
+	if !ok {
+		return fmt.Errorf("invalid repo %q (want org/project/repo)", ref)
+	}
+	target := org + "/" + project
+	u := s.apiURL(target, "repositories/"+url.PathEscape(name), nil)
+	body, status, _, err := s.Get(ctx, u)
+	if err != nil {
+		return err
+	}
+	if status != 200 {
+		return fmt.Errorf("Azure DevOps API error %d\n%s", status, string(body))
+	}
+	var r Repo
+	if err := dossier.DecodeJSON(u, status, body, &r); err != nil {
+		return err
+	}
+	return s.scanRepos(ctx, target, []Repo{r})
+}
+
+// scanRepos scans the given repos of a project, skipping forks, disabled
+// and inactive ones, up to --repo-limit
+func (s *AzureScanner) scanRepos(ctx context.Context, target string, repos []Repo) error {
+	scanned := 0
+	for i, r := range repos {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.Progress.SetRepo(i+1, len(repos))
+		if r.IsFork {
+			continue // skip forks
+		}
+		if r.IsDisabled {
+			dossier.Log.Infof("Skipping %s: repository is disabled\n", r.Name)
+			continue
+		}
+		if !s.Options.ActiveSince.IsZero() && !s.activeSinceRepo(ctx, target, r, s.Options.ActiveSince) {
+			dossier.Log.Infof("Skipping %s: no activity since %s\n", r.Name, s.Options.ActiveSince.Format("2006-01-02"))
+			continue
+		}
+		if s.Options.RepoLimit > 0 && scanned >= s.Options.RepoLimit {
+			dossier.Log.Warnf("Reached --repo-limit of %d repos\n", s.Options.RepoLimit)
+			break
+		}
+		scanned++
+		s.Stats.ReposScanned++
+		s.WithRepoBuffer(fmt.Sprintf("Scanning repo: %s\n", r.Name), func() {
+			if s.Options.MatchRepos {
+				s.ReportRepoMatches(r.Name, "", nil, r.WebURL)
+			}
+			s.ScanRepoCommits(ctx, target, r, s.Options.OldestFirst)
+			if s.Options.ScanMetadata {
+				s.ScanRepoMetadata(ctx, target, r)
+			}
+		})
+	}
+	return nil
+}
+
+// ========================== Main ==========================
+
+// Main runs the Azure DevOps command line tool
+func Main() {
+	s := NewScanner("", nil, nil)
+	cmd := dossier.NewCommand(s.Engine)
+	flag.IntVar(&s.Options.RepoLimit, "repo-limit", 0, "only scan the first N repos (0 = all), in name order")
+	flag.StringVar(&s.APIVersion, "api-version", s.APIVersion, "Azure DevOps REST api-version to request")
+	flag.BoolVar(&s.Options.CommitterToo, "committer-too", false, "with --author-email-only, include committer emails as well")
+	repoFlag := flag.String("repo", "", "scan only this repository (<organization>/<project>/<repo>) instead of the whole project")
+	cmd.Parse()
+	if s.Options.CommitterToo && !s.Options.EmailOnly {
+		dossier.Log.Errorln("--committer-too requires --author-email-only")
+		os.Exit(1)
+	}
+	if flag.NArg() < 1 && *repoFlag == "" {
+		dossier.Log.Errorln("Usage: go run ./cmd/azure [flags] <organization>/<project>")
+		dossier.Log.Errorln("       go run ./cmd/azure --repo <organization>/<project>/<repo> [flags]")
+		dossier.Log.Errorln("       go run ./cmd/azure schema    (print the signatures.yaml JSON Schema)")
+		os.Exit(1)
+	}
+	target := flag.Arg(0)
+	scan := s.scanUser
+	if *repoFlag != "" {
+		if flag.NArg() > 0 {
+			dossier.Log.Errorln("--repo cannot be combined with a target")
+			os.Exit(1)
+		}
+		if parts := strings.Split(*repoFlag, "/"); len(parts) != 3 || slices.Contains(parts, "") {
+			dossier.Log.Errorf("Invalid --repo %q (want <organization>/<project>/<repo>)\n", *repoFlag)
+			os.Exit(1)
+		}
+		target = *repoFlag
+		scan = s.scanRepo
+	} else if org, project, ok := strings.Cut(target, "/"); !ok || org == "" || project == "" {
+		dossier.Log.Errorf("Invalid target %q (want <organization>/<project>)\n", target)
+		os.Exit(1)
+	}
+
+	tokens, err := dossier.LoadTokens(".env")
+	if err != nil {
+		dossier.Log.Errorln("Error reading .env:", err)
+		os.Exit(1)
+	}
+	s.Token = tokens.AzureDevOps
+	if s.Token != "" {
+		dossier.Log.Infoln("🔑 Found Azure DevOps token in .env or the environment!")
+	} else {
+		dossier.Log.Warnln("⚠️  No Azure DevOps personal access token found in env, only public projects are visible")
+	}
+
+	cmd.Run(target, scan)
+}
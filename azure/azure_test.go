@@ -0,0 +1,188 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/0x4f53/dossier"
+)
+
+// doerFunc lets a function stand in for the HTTP client
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
+
+func respond(req *http.Request, status int, body string) (*http.Response, error) {
+	return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+}
+
+// sampleCommits is a trimmed GET .../repositories/{id}/commits response
+const sampleCommits = `{
+  "count": 1,
+  "value": [{
+    "commitId": "be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4",
+    "author": {"name": "Alice", "email": "alice@acme.io", "date": "2024-05-01T10:00:00Z"},
+    "committer": {"name": "Build Agent", "email": "agent@acme.io", "date": "2024-05-01T10:05:00Z"},
+    "comment": "Fix parser\n\nSigned-off-by: Bob <bob@acme.io>",
+    "changeCounts": {"Add": 0, "Edit": 1, "Delete": 0},
+    "url": "https://dev.azure.com/acme/_apis/git/repositories/r1/commits/be67f88",
+    "remoteUrl": "https://dev.azure.com/acme/tools/_git/cli/commit/be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4"
+  }]
+}`
+
+func TestScanRepoDecodesCommits(t *testing.T) {
+	cfg, err := dossier.DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewScanner("", cfg, nil)
+	s.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+		switch req.URL.Path {
+		case "/acme/tools/_apis/git/repositories/cli":
+			return respond(req, 200, `{"id":"r1","name":"cli","webUrl":"https://dev.azure.com/acme/tools/_git/cli"}`)
+		case "/acme/tools/_apis/git/repositories/r1/commits":
+			return respond(req, 200, sampleCommits)
+		}
+		t.Errorf("unexpected request %s", req.URL)
+		return respond(req, 404, `{}`)
+	})
+	s.Reporter = nil
+
+	findings, err := s.ScanRepo(context.Background(), "acme/tools/cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	emails := map[string]dossier.Finding{}
+	for _, f := range findings {
+		if f.Type == "email" {
+			emails[f.Email] = f
+		}
+	}
+	alice, ok := emails["alice@acme.io"]
+	if !ok {
+		t.Fatalf("no finding for the author in %+v", findings)
+	}
+	if alice.Name != "Alice" || alice.Repo != "cli" || alice.Date != "2024-05-01 10:00:00 UTC" ||
+		alice.Location != "https://dev.azure.com/acme/tools/_git/cli/commit/be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4" {
+		t.Errorf("author finding = %+v", alice)
+	}
+	if bob := emails["bob@acme.io"]; bob.Signature != "Signed-off-by" {
+		t.Errorf("trailer finding = %+v, want Signed-off-by", bob)
+	}
+	if _, ok := emails["agent@acme.io"]; ok {
+		t.Error("committer reported without --committer-too")
+	}
+	if s.Stats.CommitsProcessed != 1 {
+		t.Errorf("processed %d commits, want 1", s.Stats.CommitsProcessed)
+	}
+}
+
+func TestOrderAcrossPages(t *testing.T) {
+	// 101 commits, newest first: a full page of 100, then one more
+	page := func(from, to int) string {
+		var commits []string
+		for n := from; n >= to; n-- {
+			commits = append(commits, fmt.Sprintf(`{"commitId":"c%d","author":{"name":"C%d","email":"c%d@acme.io","date":"2024-05-01T10:00:00Z"},"comment":"Change %d"}`, n, n, n, n))
+		}
+		return `{"count":` + fmt.Sprint(len(commits)) + `,"value":[` + strings.Join(commits, ",") + `]}`
+	}
+	pages := map[string]string{"0": page(101, 2), "100": page(1, 1)}
+	cfg, err := dossier.DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, oldestFirst := range []bool{false, true} {
+		s := NewScanner("", cfg, nil)
+		c := &dossier.Collector{}
+		s.Reporter = c
+		s.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+			if body, ok := pages[req.URL.Query().Get("searchCriteria.$skip")]; ok {
+				return respond(req, 200, body)
+			}
+			t.Errorf("unexpected request %s", req.URL)
+			return respond(req, 404, `{}`)
+		})
+		s.ScanRepoCommits(context.Background(), "acme/tools", Repo{ID: "r1", Name: "cli"}, oldestFirst)
+
+		var order []string
+		seen := map[string]bool{}
+		for _, f := range c.Findings {
+			if f.Type == "email" && f.Signature == "" {
+				if seen[f.Email] {
+					t.Errorf("oldest first %v: %s reported twice", oldestFirst, f.Email)
+				}
+				seen[f.Email] = true
+				order = append(order, f.Email)
+			}
+		}
+		var want []string
+		for n := 101; n >= 1; n-- {
+			want = append(want, fmt.Sprintf("c%d@acme.io", n))
+		}
+		if oldestFirst {
+			slices.Reverse(want)
+		}
+		if !slices.Equal(order, want) {
+			t.Errorf("oldest first %v: got %d commits starting %v, want %v...", oldestFirst, len(order), order[:min(3, len(order))], want[:3])
+		}
+		if s.Stats.CommitsProcessed != 101 {
+			t.Errorf("oldest first %v: processed %d commits, want 101", oldestFirst, s.Stats.CommitsProcessed)
+		}
+	}
+}
+
+func TestOrderByActivity(t *testing.T) {
+	// Newest commit per repo; "empty" has none and so sorts last
+	latest := map[string]string{"r1": "2023-01-10T09:00:00Z", "r2": "2024-06-01T12:00:00Z", "r3": "2024-02-14T08:30:00Z"}
+	repos := `{"count":4,"value":[
+		{"id":"r1","name":"api"},
+		{"id":"r2","name":"cli"},
+		{"id":"r3","name":"docs"},
+		{"id":"r4","name":"empty"}]}`
+	cfg, err := dossier.DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		byActivity bool
+		want       []string
+	}{
+		{false, []string{"r1", "r2", "r3", "r4"}},
+		{true, []string{"r2", "r3", "r1", "r4"}},
+	} {
+		s := NewScanner("", cfg, nil)
+		s.Reporter = nil
+		s.Options.OrderByActivity = tt.byActivity
+		var walked []string
+		s.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path == "/acme/tools/_apis/git/repositories" {
+				return respond(req, 200, repos)
+			}
+			id, ok := strings.CutPrefix(req.URL.Path, "/acme/tools/_apis/git/repositories/")
+			id, ok2 := strings.CutSuffix(id, "/commits")
+			if !ok || !ok2 {
+				t.Errorf("unexpected request %s", req.URL)
+				return respond(req, 404, `{}`)
+			}
+			if req.URL.Query().Get("searchCriteria.$top") != "1" {
+				walked = append(walked, id)
+			}
+			date, ok := latest[id]
+			if !ok {
+				return respond(req, 200, `{"count":0,"value":[]}`)
+			}
+			return respond(req, 200, `{"count":1,"value":[{"commitId":"c-`+id+`","author":{"name":"Dev","email":"dev@acme.io","date":"`+date+`"},"committer":{"name":"Dev","email":"dev@acme.io","date":"`+date+`"},"comment":"Change"}]}`)
+		})
+		if _, err := s.ScanUser(context.Background(), "acme/tools"); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(walked, tt.want) {
+			t.Errorf("order by activity %v: scanned %v, want %v", tt.byActivity, walked, tt.want)
+		}
+	}
+}
@@ -0,0 +1,412 @@
+package bitbucket
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/0x4f53/dossier"
+)
+
+// ========================== Structs ==========================
+
+type BitbucketCommit struct {
+	Hash    string `json:"hash"`
+	Date    string `json:"date"`
+	Message string `json:"message"`
+	Author  struct {
+		Raw string `json:"raw"` // e.g. "John Doe <john@example.com>"
+	} `json:"author"`
+	Links struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+type BitbucketCommitPage struct {
+	Values []BitbucketCommit `json:"values"`
+	Next   string            `json:"next"`
+}
+
+type Repo struct {
+	Slug        string    `json:"slug"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	UpdatedOn   time.Time `json:"updated_on"`
+	MainBranch  struct {
+		Name string `json:"name"`
+	} `json:"mainbranch"`
+	Links struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+type RepoPage struct {
+	Values []Repo `json:"values"`
+	Next   string `json:"next"`
+}
+
+// ========================== Constants ==========================
+
+const providerName = "bitbucket"
+
+// maxPerPage is the largest page size Bitbucket accepts
+const maxPerPage = 100
+
+// ========================== Scanner ==========================
+
+// BitbucketScanner holds everything one scan needs, so several can run in a process
+// and tests can supply their own Doer and Reporter
+type BitbucketScanner struct {
+	*dossier.Engine
+	User  string // with Token as an app password, sent as basic auth
+	Token string
+}
+
+var _ dossier.Scanner = (*BitbucketScanner)(nil)
+
+func NewScanner(token string, cfg *dossier.Config, blacklist []*regexp.Regexp) *BitbucketScanner {
+	s := &BitbucketScanner{Engine: dossier.NewEngine(providerName, cfg, blacklist), Token: token}
+	s.Reporter = dossier.TextReporter{}
+	s.Authorize = s.authorize
+	return s
+}
+
+// pageSize clamps --per-page to what the API allows
+func (s *BitbucketScanner) pageSize() int {
+	return min(max(s.Options.PerPage, 1), maxPerPage)
+}
+
+// ========================== HTTP Helpers ==========================
+
+func (s *BitbucketScanner) authorize(req *http.Request) {
+	if s.User != "" {
+		req.SetBasicAuth(s.User, s.Token) // app password
+	} else if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token) // repository or workspace access token
+	}
+}
+
+// apiError describes a failed API call, with a hint when the credentials
+// themselves were refused
+func (s *BitbucketScanner) apiError(status int, body []byte) error {
+	if status == 401 && s.User != "" {
+		return fmt.Errorf("Bitbucket API error 401: app password rejected, check BITBUCKET_USERNAME (your username, not your email) and that the password has Repositories: Read\n%s", string(body))
+	}
+	if status == 401 && s.Token != "" {
+		return fmt.Errorf("Bitbucket API error 401: access token rejected or expired\n%s", string(body))
+	}
+	return fmt.Errorf("Bitbucket API error %d\n%s", status, string(body))
+}
+
+// ========================== Commit Processing ==========================
+
+// commits converts API commits for ProcessCommits. Bitbucket only has the
+// raw author line, "John Doe <email>".
+func (s *BitbucketScanner) commits(commits []BitbucketCommit, repoName string) []dossier.Commit {
+	out := make([]dossier.Commit, len(commits))
+	for i, c := range commits {
+		name, email := parseRawAuthor(c.Author.Raw)
+		commitTime, _ := time.Parse(time.RFC3339, c.Date)
+		out[i] = dossier.Commit{
+			ID:       c.Hash,
+			Repo:     repoName,
+			Location: c.Links.HTML.Href,
+			Time:     commitTime,
+			Date:     c.Date,
+			Author:   dossier.Person{Name: name, Email: email},
+			Message:  c.Message,
+			Text:     fmt.Sprintf("%s %s %s", c.Message, c.Author.Raw, repoName),
+		}
+	}
+	return out
+}
+
+func parseRawAuthor(raw string) (string, string) {
+	if strings.Contains(raw, "<") && strings.Contains(raw, ">") {
+		parts := strings.SplitN(raw, "<", 2)
+		name := strings.TrimSpace(parts[0])
+		email := strings.TrimSuffix(parts[1], ">")
+		return name, email
+	}
+	return raw, ""
+}
+
+// ========================== Repos and Commits ==========================
+
+// Bitbucket has no stars and doesn't track pushes separately from updates
+var repoSortFields = map[string]string{
+	"updated": "-updated_on",
+	"created": "-created_on",
+	"pushed":  "-updated_on",
+}
+
+func (s *BitbucketScanner) repoSortQuery() string {
+	if field, ok := repoSortFields[s.Options.RepoSort]; ok {
+		return "&sort=" + field
+	}
+	return ""
+}
+
+func (s *BitbucketScanner) GetUserRepos(ctx context.Context, username string) ([]Repo, error) {
+	url := fmt.Sprintf("https://api.bitbucket.org/2.0/repositories/%s?pagelen=%d%s", username, s.pageSize(), s.repoSortQuery())
+	var repos []Repo
+
+	for n := 1; url != ""; n++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if s.PageLimitReached(n, "repos of "+username) {
+			break
+		}
+		body, status, _, err := s.Get(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		if status != 200 {
+			return nil, s.apiError(status, body)
+		}
+
+		var page RepoPage
+		if err := dossier.DecodeJSON(url, status, body, &page); err != nil {
+			return nil, err
+		}
+		repos = append(repos, page.Values...)
+		url = page.Next
+	}
+	return repos, nil
+}
+
+func (s *BitbucketScanner) ScanRepoCommits(ctx context.Context, username, repoSlug, repoName string, ascending bool) {
+	url := fmt.Sprintf("https://api.bitbucket.org/2.0/repositories/%s/%s/commits?pagelen=%d", username, repoSlug, s.pageSize())
+	var allCommits []BitbucketCommit
+
+	for n := 1; url != ""; n++ {
+		if ctx.Err() != nil {
+			return
+		}
+		if s.PageLimitReached(n, "commits of "+repoName) {
+			break
+		}
+		body, status, _, err := s.Get(ctx, url)
+		if err != nil {
+			s.RepoFailed(repoName, len(allCommits), err)
+			break
+		}
+		if status != 200 {
+			s.RepoFailed(repoName, len(allCommits), s.apiError(status, body))
+			break
+		}
+
+		var page BitbucketCommitPage
+		if err := dossier.DecodeJSON(url, status, body, &page); err != nil {
+			s.RepoFailed(repoName, len(allCommits), err)
+			break
+		}
+
+		allCommits = append(allCommits, page.Values...)
+		url = page.Next
+	}
+
+	// Reverse if ascending
+	if ascending {
+		for i, j := 0, len(allCommits)-1; i < j; i, j = i+1, j-1 {
+			allCommits[i], allCommits[j] = allCommits[j], allCommits[i]
+		}
+	}
+
+	s.ProcessCommits(s.commits(allCommits, repoName))
+}
+
+// ========================== Metadata Files ==========================
+
+// ScanRepoMetadata reads well-known contributor files from the main branch
+func (s *BitbucketScanner) ScanRepoMetadata(ctx context.Context, username string, repo Repo) {
+	branch := repo.MainBranch.Name
+	if branch == "" {
+		return // empty repository
+	}
+	for _, candidates := range dossier.MetadataFiles {
+		for _, path := range candidates {
+			u := fmt.Sprintf("https://api.bitbucket.org/2.0/repositories/%s/%s/src/%s/%s", username, repo.Slug, url.PathEscape(branch), path)
+			body, status, _, err := s.Get(ctx, u)
+			if err != nil || status != 200 {
+				continue // usually a 404: no such file
+			}
+			location := fmt.Sprintf("%s/src/%s/%s", repo.Links.HTML.Href, branch, path)
+			s.ReportMetadata(path, string(body), location)
+			break
+		}
+	}
+}
+
+// ========================== Profile Mode ==========================
+
+// ScanProfile exists for parity with the other providers: Bitbucket's API
+// never exposes a user's email address, so there is nothing to report.
+func (s *BitbucketScanner) ScanProfile(ctx context.Context, username string) error {
+	dossier.Log.Infof("Bitbucket profiles don't expose email addresses, nothing to report for %s\n", username)
+	return nil
+}
+
+// ========================== Scanning ==========================
+
+// ScanUser scans a user and returns the findings reported along the way
+func (s *BitbucketScanner) ScanUser(ctx context.Context, username string) ([]dossier.Finding, error) {
+	return s.Collect(func() error { return s.scanUser(ctx, username) })
+}
+
+// ScanOrg is ScanUser for a workspace
+func (s *BitbucketScanner) ScanOrg(ctx context.Context, workspace string) ([]dossier.Finding, error) {
+	return s.Collect(func() error { return s.scanOrg(ctx, workspace) })
+}
+
+// ScanRepo is ScanUser for a single repo ("workspace/slug")
+func (s *BitbucketScanner) ScanRepo(ctx context.Context, fullName string) ([]dossier.Finding, error) {
+	return s.Collect(func() error { return s.scanRepo(ctx, fullName) })
+}
+
+func (s *BitbucketScanner) scanUser(ctx context.Context, username string) error {
+	if s.Options.ProfileOnly {
+		dossier.Log.Infof("Fetching profile emails for user: %s\n\n", username)
+		return s.ScanProfile(ctx, username)
+	}
+
+	dossier.Log.Infof("Scanning Bitbucket commits for user: %s\n\n", username)
+
+	repos, err := s.GetUserRepos(ctx, username)
+	if err != nil {
+		return fmt.Errorf("fetching repos: %w", err)
+	}
+	return s.scanRepos(ctx, username, repos)
+}
+
+// scanOrg scans every repo in a workspace; Bitbucket has no separate notion
+// of an organization, so this is ScanUser without the profile-only mode
+func (s *BitbucketScanner) scanOrg(ctx context.Context, workspace string) error {
+	dossier.Log.Infof("Scanning Bitbucket commits for workspace: %s\n\n", workspace)
+	repos, err := s.GetUserRepos(ctx, workspace)
+	if err != nil {
+		return fmt.Errorf("fetching repos: %w", err)
+	}
+	return s.scanRepos(ctx, workspace, repos)
+}
+
+// scanRepo scans a single repo, given as "workspace/slug"
+func (s *BitbucketScanner) scanRepo(ctx context.Context, fullName string) error {
+	workspace, slug, ok := strings.Cut(fullName, "/")
+	if !ok {
+		return fmt.Errorf("invalid repo %q (want workspace/slug)", fullName)
+	}
+	url := fmt.Sprintf("https://api.bitbucket.org/2.0/repositories/%s/%s", workspace, slug)
+	body, status, _, err := s.Get(ctx, url)
+	if err != nil {
+		return err
+	}
+	if status != 200 {
+		return s.apiError(status, body)
+	}
+	var r Repo
+	if err := dossier.DecodeJSON(url, status, body, &r); err != nil {
+		return err
+	}
+	return s.scanRepos(ctx, workspace, []Repo{r})
+}
+
+// scanRepos scans the given repos of a workspace, skipping inactive ones, up
+// to --repo-limit
+func (s *BitbucketScanner) scanRepos(ctx context.Context, username string, repos []Repo) error {
+	scanned := 0
+	for i, r := range repos {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.Progress.SetRepo(i+1, len(repos))
+		if !s.Options.ActiveSince.IsZero() && r.UpdatedOn.Before(s.Options.ActiveSince) {
+			dossier.Log.Infof("Skipping %s: no activity since %s\n", r.Name, s.Options.ActiveSince.Format("2006-01-02"))
+			continue
+		}
+		if s.Options.RepoLimit > 0 && scanned >= s.Options.RepoLimit {
+			dossier.Log.Warnf("Reached --repo-limit of %d repos\n", s.Options.RepoLimit)
+			break
+		}
+		scanned++
+		s.Stats.ReposScanned++
+		s.WithRepoBuffer(fmt.Sprintf("Scanning repo: %s\n", r.Name), func() {
+			if s.Options.MatchRepos {
+				s.ReportRepoMatches(r.Name, r.Description, nil, r.Links.HTML.Href)
+			}
+			s.ScanRepoCommits(ctx, username, r.Slug, r.Name, s.Options.OldestFirst)
+			if s.Options.ScanMetadata {
+				s.ScanRepoMetadata(ctx, username, r)
+			}
+		})
+	}
+	return nil
+}
+
+// ========================== Main ==========================
+
+// Main runs the Bitbucket command line tool
+func Main() {
+	s := NewScanner("", nil, nil)
+	cmd := dossier.NewCommand(s.Engine)
+	flag.IntVar(&s.Options.PerPage, "per-page", s.Options.PerPage, fmt.Sprintf("items requested per page of a listing (1-%d)", maxPerPage))
+	flag.IntVar(&s.Options.RepoLimit, "repo-limit", 0, "only scan the first N repos (0 = all), in --repo-sort order")
+	flag.StringVar(&s.Options.RepoSort, "repo-sort", "", "order repos by updated, created or pushed before scanning")
+	repoFlag := flag.String("repo", "", "scan only this repository (workspace/slug) instead of all of a user's repos")
+	cmd.Parse()
+	if _, ok := repoSortFields[s.Options.RepoSort]; s.Options.RepoSort != "" && !ok {
+		dossier.Log.Errorf("Invalid --repo-sort %q (Bitbucket supports updated, created or pushed)\n", s.Options.RepoSort)
+		os.Exit(1)
+	}
+	if n := s.pageSize(); n != s.Options.PerPage {
+		dossier.Log.Warnf("⚠️  --per-page %d is outside 1-%d, using %d\n", s.Options.PerPage, maxPerPage, n)
+	}
+	if flag.NArg() < 1 && *repoFlag == "" {
+		dossier.Log.Errorln("Usage: go run ./cmd/bitbucket [flags] <bitbucket-username>")
+		dossier.Log.Errorln("       go run ./cmd/bitbucket --repo workspace/slug [flags]")
+		dossier.Log.Errorln("       go run ./cmd/bitbucket schema    (print the signatures.yaml JSON Schema)")
+		os.Exit(1)
+	}
+	username := flag.Arg(0)
+	scan := s.scanUser
+	if *repoFlag != "" {
+		if flag.NArg() > 0 {
+			dossier.Log.Errorln("--repo cannot be combined with a username")
+			os.Exit(1)
+		}
+		username = *repoFlag
+		scan = s.scanRepo
+	}
+
+	tokens, err := dossier.LoadTokens(".env")
+	if err != nil {
+		dossier.Log.Errorln("Error reading .env:", err)
+		os.Exit(1)
+	}
+	if tokens.BitbucketAppPassword != "" {
+		if tokens.BitbucketUsername == "" {
+			dossier.Log.Errorln("BITBUCKET_APP_PASSWORD needs BITBUCKET_USERNAME as well")
+			os.Exit(1)
+		}
+		s.User, s.Token = tokens.BitbucketUsername, tokens.BitbucketAppPassword
+		dossier.Log.Infoln("🔑 Found Bitbucket app password in .env or the environment!")
+	} else if tokens.BitbucketAccessToken != "" {
+		s.Token = tokens.BitbucketAccessToken
+		dossier.Log.Infoln("🔑 Found Bitbucket access token in .env or the environment!")
+	} else {
+		dossier.Log.Warnln("⚠️  No Bitbucket credentials found in env, running unauthenticated (with rate limits, public repos only)")
+	}
+
+	cmd.Run(username, scan)
+}
@@ -0,0 +1,84 @@
+package bitbucket
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/0x4f53/dossier"
+)
+
+// doerFunc lets a function stand in for the HTTP client
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
+
+func respond(req *http.Request, status int, body string) (*http.Response, error) {
+	return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+}
+
+// authorOrder lists the author email findings in the order they were reported
+func authorOrder(findings []dossier.Finding) string {
+	var emails []string
+	for _, f := range findings {
+		if f.Type == "email" && f.Signature == "" {
+			emails = append(emails, f.Email)
+		}
+	}
+	return strings.Join(emails, " ")
+}
+
+func TestOrderAcrossPages(t *testing.T) {
+	commit := func(n string) string {
+		return `{"hash":"c` + n + `","date":"2024-05-0` + n + `T10:00:00+00:00","message":"Change ` + n + `","author":{"raw":"C` + n + ` <c` + n + `@acme.io>"}}`
+	}
+	const next = "https://api.bitbucket.org/2.0/repositories/alice/tool/commits?pagelen=2&page=2"
+	pages := map[string]string{
+		"":  `{"values":[` + commit("3") + "," + commit("2") + `],"next":"` + next + `"}`,
+		"2": `{"values":[` + commit("1") + `]}`,
+	}
+	cfg, err := dossier.DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		oldestFirst bool
+		want        string
+	}{
+		{false, "c3@acme.io c2@acme.io c1@acme.io"},
+		{true, "c1@acme.io c2@acme.io c3@acme.io"},
+	} {
+		s := NewScanner("", cfg, nil)
+		c := &dossier.Collector{}
+		s.Reporter = c
+		s.Options.PerPage = 2
+		s.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+			if body, ok := pages[req.URL.Query().Get("page")]; ok {
+				return respond(req, 200, body)
+			}
+			t.Errorf("unexpected request %s", req.URL)
+			return respond(req, 404, `{}`)
+		})
+		s.ScanRepoCommits(context.Background(), "alice", "tool", "tool", tt.oldestFirst)
+		if got := authorOrder(c.Findings); got != tt.want {
+			t.Errorf("oldest first %v: order %s, want %s", tt.oldestFirst, got, tt.want)
+		}
+		if s.Stats.CommitsProcessed != 3 {
+			t.Errorf("oldest first %v: processed %d commits, want 3", tt.oldestFirst, s.Stats.CommitsProcessed)
+		}
+	}
+}
+
+func TestPageSizeIsClamped(t *testing.T) {
+	for _, tt := range []struct{ perPage, want int }{
+		{-5, 1}, {0, 1}, {1, 1}, {50, 50}, {100, 100}, {101, 100}, {1000, 100},
+	} {
+		s := NewScanner("", nil, nil)
+		s.Options.PerPage = tt.perPage
+		if got := s.pageSize(); got != tt.want {
+			t.Errorf("--per-page %d: page size %d, want %d", tt.perPage, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package dossier
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// ========================== Repo Buffer ==========================
+
+// Finding types that count as a leak for --only-with-secrets
+var secretFindingTypes = map[string]bool{
+	"google_credential":   true,
+	"saas_credential":     true,
+	"registry_credential": true,
+	"encoded_secret":      true,
+}
+
+// RepoBuffer holds back one repo's findings until it is known whether the
+// repo contained a secret. Past Max findings (--max-buffered-findings; 0 =
+// no cap) they spill to a temp file as JSON lines, so a huge repo can't
+// exhaust memory. The cap covers only this buffer: the json, sarif and
+// xlsx reporters and DedupReporter keep everything until the scan ends.
+type RepoBuffer struct {
+	Max int
+
+	findings []Finding
+	spill    *os.File
+	secrets  bool
+}
+
+func (b *RepoBuffer) Report(f Finding) {
+	if secretFindingTypes[f.Type] {
+		b.secrets = true
+	}
+	if b.Max <= 0 || len(b.findings) < b.Max {
+		b.findings = append(b.findings, f)
+		return
+	}
+	if b.spill == nil {
+		file, err := os.CreateTemp("", "dossier-spill-*.jsonl")
+		if err != nil {
+			Log.Warnln("Warning: cannot spill findings to disk, keeping them in memory:", err)
+			b.findings = append(b.findings, f)
+			return
+		}
+		b.spill = file
+	}
+	line, _ := json.Marshal(spilled{f, f.secretHash})
+	b.spill.Write(append(line, '\n'))
+}
+
+// spilled is a finding as written to the spill file, carrying the secret
+// hash Key needs, which Finding's own JSON leaves out
+type spilled struct {
+	Finding
+	SecretHash string `json:"secret_hash,omitempty"`
+}
+
+// Replay sends the buffered findings to r in order, then discards them
+func (b *RepoBuffer) Replay(r Reporter) {
+	for _, f := range b.findings {
+		r.Report(f)
+	}
+	if b.spill != nil {
+		if _, err := b.spill.Seek(0, io.SeekStart); err == nil {
+			dec := json.NewDecoder(b.spill)
+			for {
+				var s spilled
+				if err := dec.Decode(&s); err != nil {
+					break
+				}
+				s.Finding.secretHash = s.SecretHash
+				r.Report(s.Finding)
+			}
+		}
+	}
+	b.Discard()
+}
+
+// HasSecrets reports whether any finding buffered so far is a secret
+func (b *RepoBuffer) HasSecrets() bool {
+	return b.secrets
+}
+
+func (b *RepoBuffer) Discard() {
+	b.findings = nil
+	if b.spill != nil {
+		b.spill.Close()
+		os.Remove(b.spill.Name())
+		b.spill = nil
+	}
+}
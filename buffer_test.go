@@ -0,0 +1,68 @@
+package dossier
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepoBufferSpillsPastMax(t *testing.T) {
+	b := &RepoBuffer{Max: 2}
+	for i := 0; i < 5; i++ {
+		b.Report(Finding{Type: "email", Email: fmt.Sprintf("dev%d@acme.io", i)})
+	}
+	b.Report(Finding{Type: "saas_credential", Signature: "stripe_secret_key"}.WithSecret(strings.Repeat("9xQ", 8)))
+
+	if len(b.findings) != 2 {
+		t.Errorf("%d findings in memory, want the cap of 2", len(b.findings))
+	}
+	if b.spill == nil {
+		t.Fatal("nothing spilled to disk")
+	}
+	spillPath := b.spill.Name()
+	if filepath.Dir(spillPath) != filepath.Clean(os.TempDir()) {
+		t.Errorf("spill file %s not in the temp dir", spillPath)
+	}
+	if !b.HasSecrets() {
+		t.Error("secret that spilled to disk not counted")
+	}
+
+	c := &Collector{}
+	b.Replay(c)
+	if len(c.Findings) != 6 {
+		t.Fatalf("replayed %d findings, want 6", len(c.Findings))
+	}
+	for i := 0; i < 5; i++ {
+		if want := fmt.Sprintf("dev%d@acme.io", i); c.Findings[i].Email != want {
+			t.Errorf("finding %d = %s, want %s in report order", i, c.Findings[i].Email, want)
+		}
+	}
+	want := Finding{Type: "saas_credential", Signature: "stripe_secret_key"}.WithSecret(strings.Repeat("9xQ", 8))
+	if got := c.Findings[5]; got.Key() != want.Key() || got.Secret != want.Secret {
+		t.Errorf("spilled secret came back as %+v", got)
+	}
+	if _, err := os.Stat(spillPath); !os.IsNotExist(err) {
+		t.Errorf("spill file left behind after replay: %v", err)
+	}
+}
+
+func TestRepoBufferDiscardRemovesSpillFile(t *testing.T) {
+	b := &RepoBuffer{Max: 1}
+	b.Report(Finding{Type: "email", Email: "alice@acme.io"})
+	b.Report(Finding{Type: "email", Email: "bob@acme.io"})
+	if b.spill == nil {
+		t.Fatal("nothing spilled to disk")
+	}
+	spillPath := b.spill.Name()
+	b.Discard()
+	if _, err := os.Stat(spillPath); !os.IsNotExist(err) {
+		t.Errorf("spill file left behind after discard: %v", err)
+	}
+	c := &Collector{}
+	b.Replay(c)
+	if len(c.Findings) != 0 {
+		t.Errorf("discarded buffer replayed %v", c.Findings)
+	}
+}
@@ -0,0 +1,209 @@
+package dossier
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ========================== Disk Cache ==========================
+
+// DiskEntry is one API response kept on disk, with the validators the
+// server sent so a stale entry can be revalidated instead of re-fetched
+type DiskEntry struct {
+	URL          string    `json:"url"`
+	Status       int       `json:"status"`
+	Body         []byte    `json:"body"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Stored       time.Time `json:"stored"`
+}
+
+// DiskCache keeps API responses across runs, one file per key under Dir;
+// Fetch's keys include the credentials, so runs with other tokens miss.
+// Entries younger than TTL are used as they are; older ones are only sent
+// as conditional requests. Responses may come from private repos, so the
+// files are readable by the owner only. A nil *DiskCache caches nothing.
+type DiskCache struct {
+	Dir string
+	TTL time.Duration
+}
+
+func OpenDiskCache(dir string, ttl time.Duration) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &DiskCache{Dir: dir, TTL: ttl}, nil
+}
+
+func (c *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get returns the entry stored under key, if any, and whether it is still
+// within the TTL. Unreadable entries count as missing.
+func (c *DiskCache) Get(key string) (*DiskEntry, bool) {
+	if c == nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var e DiskEntry
+	if json.Unmarshal(data, &e) != nil || e.URL != key {
+		return nil, false
+	}
+	return &e, time.Since(e.Stored) < c.TTL
+}
+
+// Put stores a response under key with the validators in h. Storing a
+// stale entry again after a 304 restarts its TTL.
+func (c *DiskCache) Put(key string, body []byte, status int, h http.Header) {
+	if c == nil {
+		return
+	}
+	e := DiskEntry{URL: key, Status: status, Body: body, ETag: h.Get("ETag"), LastModified: h.Get("Last-Modified"), Stored: time.Now()}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	// Write and rename, so a run stopped mid-write leaves no torn entry
+	tmp, err := os.CreateTemp(c.Dir, ".tmp-*")
+	if err != nil {
+		Log.Warnln("⚠️  Could not write to the cache:", err)
+		return
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		Log.Warnln("⚠️  Could not write to the cache:", err)
+	}
+}
+
+// Header holds the validators, for callers that expect response headers
+func (e *DiskEntry) Header() http.Header {
+	h := http.Header{}
+	if e.ETag != "" {
+		h.Set("ETag", e.ETag)
+	}
+	if e.LastModified != "" {
+		h.Set("Last-Modified", e.LastModified)
+	}
+	return h
+}
+
+// Validate makes req conditional on e still being current, so an unchanged
+// resource comes back as a bodiless 304
+func (e *DiskEntry) Validate(req *http.Request) {
+	if e == nil {
+		return
+	}
+	if e.ETag != "" {
+		req.Header.Set("If-None-Match", e.ETag)
+	}
+	if e.LastModified != "" {
+		req.Header.Set("If-Modified-Since", e.LastModified)
+	}
+}
+
+// ========================== Response Cache ==========================
+
+// ResponseCache keeps the most recent successful responses by key (the URL
+// of a GET), so a page requested twice in one run is fetched once. Stale
+// entries stay around for their ETags, to be revalidated rather than
+// refetched. Bounded by entry count and total body size; a nil cache
+// (--no-response-cache) never hits. Each Engine has its own, so responses
+// fetched with one token are never served to another scanner.
+type ResponseCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int
+	bytes      int
+	order      *list.List // front is most recently used
+	entries    map[string]*list.Element
+}
+
+type cachedResponse struct {
+	key    string
+	body   []byte
+	status int
+	header http.Header
+	stale  bool
+}
+
+// validator turns a stale entry into the conditional request that checks it
+func (r *cachedResponse) validator() *DiskEntry {
+	if r == nil || r.header.Get("ETag") == "" && r.header.Get("Last-Modified") == "" {
+		return nil
+	}
+	return &DiskEntry{URL: r.key, Status: r.status, Body: r.body, ETag: r.header.Get("ETag"), LastModified: r.header.Get("Last-Modified")}
+}
+
+func NewResponseCache(maxEntries, maxBytes int) *ResponseCache {
+	return &ResponseCache{maxEntries: maxEntries, maxBytes: maxBytes, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// Get returns the entry for key, if any, and whether it is fresh enough to
+// use without asking the API
+func (c *ResponseCache) Get(key string) (*cachedResponse, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	r := e.Value.(*cachedResponse)
+	return r, !r.stale
+}
+
+func (c *ResponseCache) Put(key string, body []byte, status int, header http.Header) {
+	if c == nil || len(body) > c.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.bytes -= len(e.Value.(*cachedResponse).body)
+		c.order.Remove(e)
+	}
+	c.entries[key] = c.order.PushFront(&cachedResponse{key: key, body: body, status: status, header: header})
+	c.bytes += len(body)
+	for c.order.Len() > c.maxEntries || c.bytes > c.maxBytes {
+		oldest := c.order.Back()
+		r := oldest.Value.(*cachedResponse)
+		c.order.Remove(oldest)
+		delete(c.entries, r.key)
+		c.bytes -= len(r.body)
+	}
+}
+
+// Expire marks every entry stale, so each --watch cycle sees fresh data.
+// Pages that did not change come back as 304s, which cost no rate limit.
+func (c *ResponseCache) Expire() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for e := c.order.Front(); e != nil; e = e.Next() {
+		e.Value.(*cachedResponse).stale = true
+	}
+}
@@ -0,0 +1,204 @@
+package dossier
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// doerFunc lets a function stand in for the HTTP client
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
+
+func respond(req *http.Request, status int, header http.Header, body string) (*http.Response, error) {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+}
+
+func TestRepeatedURLServedFromResponseCache(t *testing.T) {
+	var sent []string
+	e := NewEngine("github", nil, nil)
+	e.Options.Retry.MaxAttempts = 1
+	e.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req.URL.Path+" "+req.Header.Get("If-None-Match"))
+		switch req.URL.Path {
+		case "/missing":
+			return respond(req, 404, nil, `{"message":"Not Found"}`)
+		case "/repos":
+			if req.Header.Get("If-None-Match") == `"v1"` {
+				return respond(req, 304, nil, "")
+			}
+		}
+		return respond(req, 200, http.Header{"Etag": {`"v1"`}}, `["`+req.URL.Path+`"]`)
+	})
+	get := func(path string) string {
+		body, _, _, err := e.Get(context.Background(), "https://api.github.com"+path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body)
+	}
+
+	for i := 0; i < 3; i++ {
+		if got := get("/repos"); got != `["/repos"]` {
+			t.Fatalf("body = %s", got)
+		}
+	}
+	get("/other")
+	get("/missing")
+	get("/missing")
+	if got, want := strings.Join(sent, ","), "/repos ,/other ,/missing ,/missing "; got != want {
+		t.Errorf("requests sent = %q, want %q: a repeated 200 once, errors every time", got, want)
+	}
+	if e.Stats.ResponsesReused != 2 {
+		t.Errorf("responses reused = %d, want 2", e.Stats.ResponsesReused)
+	}
+
+	// Expired entries are revalidated with their ETag, and a 304 serves the copy
+	sent = nil
+	e.Responses.Expire()
+	if got := get("/repos"); got != `["/repos"]` {
+		t.Errorf("body after 304 = %s", got)
+	}
+	if got := strings.Join(sent, ","); got != `/repos "v1"` || e.Stats.NotModified != 1 {
+		t.Errorf("requests sent = %q, not modified = %d; want one conditional request", got, e.Stats.NotModified)
+	}
+
+	// --no-response-cache
+	sent = nil
+	e.Responses = nil
+	get("/repos")
+	get("/repos")
+	if len(sent) != 2 {
+		t.Errorf("without a cache sent %d requests, want 2", len(sent))
+	}
+}
+
+func TestResponseCacheEviction(t *testing.T) {
+	c := NewResponseCache(2, 10)
+	c.Put("a", []byte("aaaa"), 200, nil)
+	c.Put("b", []byte("bbbb"), 200, nil)
+	c.Get("a") // now most recently used
+	c.Put("c", []byte("cc"), 200, nil)
+	if _, ok := c.Get("b"); ok {
+		t.Error("least recently used entry kept past the entry limit")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("%s evicted", key)
+		}
+	}
+
+	c.Put("d", []byte("dddddddd"), 200, nil) // 4+2+8 bytes: a, the oldest, has to go
+	if _, ok := c.Get("a"); ok {
+		t.Error("least recently used entry kept past the byte limit")
+	}
+	if c.bytes != 10 || c.order.Len() != 2 {
+		t.Errorf("cache holds %d bytes in %d entries, want c and d in 10 bytes", c.bytes, c.order.Len())
+	}
+
+	c.Put("huge", make([]byte, 11), 200, nil)
+	if _, ok := c.Get("huge"); ok {
+		t.Error("cached a body larger than the whole cache")
+	}
+}
+
+func TestDiskCachePutGet(t *testing.T) {
+	c, err := OpenDiskCache(filepath.Join(t.TempDir(), "cache"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := c.Get("https://api.github.com/users/alice"); e != nil {
+		t.Fatalf("empty cache returned %+v", e)
+	}
+	c.Put("https://api.github.com/users/alice", []byte(`{"login":"alice"}`), 200, http.Header{"Etag": {`"v1"`}, "Last-Modified": {"Wed, 01 May 2024 10:00:00 GMT"}})
+
+	e, fresh := c.Get("https://api.github.com/users/alice")
+	if e == nil || !fresh {
+		t.Fatalf("entry = %+v, fresh %v; want a fresh entry", e, fresh)
+	}
+	if string(e.Body) != `{"login":"alice"}` || e.Status != 200 || e.ETag != `"v1"` || e.LastModified != "Wed, 01 May 2024 10:00:00 GMT" {
+		t.Errorf("entry = %+v", e)
+	}
+	files, err := os.ReadDir(c.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || strings.HasPrefix(files[0].Name(), ".tmp-") {
+		t.Fatalf("cache dir holds %v, want one entry and no temp files", files)
+	}
+	if info, err := files[0].Info(); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("entry mode = %v (%v), want 0600", info.Mode().Perm(), err)
+	}
+
+	// A key that hashes to a file holding another URL, or a torn file, is a miss
+	os.WriteFile(c.path("https://api.github.com/users/bob"), []byte(`{"url":"https://api.github.com/users/alice"}`), 0o600)
+	os.WriteFile(c.path("https://api.github.com/users/carol"), []byte(`{"url":`), 0o600)
+	for _, key := range []string{"https://api.github.com/users/bob", "https://api.github.com/users/carol"} {
+		if e, _ := c.Get(key); e != nil {
+			t.Errorf("%s: got %+v, want a miss", key, e)
+		}
+	}
+
+	c.TTL = 0
+	if e, fresh := c.Get("https://api.github.com/users/alice"); e == nil || fresh {
+		t.Errorf("past the TTL: entry %v, fresh %v; want a stale entry", e != nil, fresh)
+	}
+}
+
+func TestDiskCacheAcrossRuns(t *testing.T) {
+	dir := t.TempDir()
+	var sent []string
+	// run is one invocation: a new Engine with its own memory cache
+	run := func(token string, ttl time.Duration) string {
+		e := NewEngine("github", nil, nil)
+		e.Options.Retry.MaxAttempts = 1
+		e.Disk = &DiskCache{Dir: dir, TTL: ttl}
+		if token != "" {
+			e.Authorize = func(req *http.Request) { req.Header.Set("Authorization", "token "+token) }
+		}
+		e.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+			sent = append(sent, req.Header.Get("Authorization")+" "+req.Header.Get("If-None-Match"))
+			if req.Header.Get("If-None-Match") == `"v1"` {
+				return respond(req, 304, nil, "")
+			}
+			return respond(req, 200, http.Header{"Etag": {`"v1"`}}, `{"for":"`+token+`"}`)
+		})
+		body, _, _, err := e.Get(context.Background(), "https://api.github.com/user/repos")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body)
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		ttl      time.Duration
+		wantSent string // "" when served from disk
+		wantBody string
+	}{
+		{"first run", "aaa", time.Hour, `token aaa `, `{"for":"aaa"}`},
+		{"same token within the TTL", "aaa", time.Hour, "", `{"for":"aaa"}`},
+		{"same token past the TTL", "aaa", 0, `token aaa "v1"`, `{"for":"aaa"}`},
+		{"another token", "bbb", time.Hour, `token bbb `, `{"for":"bbb"}`},
+		{"no token", "", time.Hour, ` `, `{"for":""}`},
+	}
+	for _, tt := range tests {
+		sent = nil
+		if body := run(tt.token, tt.ttl); body != tt.wantBody {
+			t.Errorf("%s: body = %s, want %s", tt.name, body, tt.wantBody)
+		}
+		if got := strings.Join(sent, ","); got != tt.wantSent {
+			t.Errorf("%s: sent %q, want %q", tt.name, got, tt.wantSent)
+		}
+	}
+}
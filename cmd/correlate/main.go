@@ -0,0 +1,7 @@
+package main
+
+import "github.com/0x4f53/dossier/correlate"
+
+func main() {
+	correlate.Main()
+}
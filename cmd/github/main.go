@@ -0,0 +1,7 @@
+package main
+
+import "github.com/0x4f53/dossier/github"
+
+func main() {
+	github.Main()
+}
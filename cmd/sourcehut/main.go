@@ -0,0 +1,7 @@
+package main
+
+import "github.com/0x4f53/dossier/sourcehut"
+
+func main() {
+	sourcehut.Main()
+}
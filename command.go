@@ -0,0 +1,469 @@
+package dossier
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ========================== Command Line ==========================
+
+const seenStoreFile = "seen_findings.txt"
+
+// Command is the part of the command line every provider shares: output,
+// filters, signature files, dedup, caching and --watch. A provider
+// registers its own flags between NewCommand and Parse, checks them and
+// its token after Parse, then hands Run the scan.
+type Command struct {
+	Engine *Engine
+
+	watch, useSyslog, noResponseCache, blacklistIgnoreCase      bool
+	dedupAcrossRuns, resetDedup, dedup, dedupByName, verifyMX   bool
+	verbose, quiet, showProgress                                bool
+	interval, cacheTTL                                          time.Duration
+	syslogAddr, manifest, format, output, esIndex               string
+	activeSince, since, until, order, redirectHosts, cacheDir   string
+	signaturesFile, signaturesDir, whitelistFile, blacklistFile string
+	redactConfig                                                string
+	seed                                                        int64
+	excludeEmails, tzOffsets                                    stringList
+	closers                                                     []io.Closer
+}
+
+// NewCommand registers the shared flags on flag.CommandLine, bound to e
+func NewCommand(e *Engine) *Command {
+	c := &Command{Engine: e}
+	o := &e.Options
+	flag.BoolVar(&c.watch, "watch", false, "keep re-running the scan, printing only findings not seen in earlier cycles (not with --format json, sarif or xlsx)")
+	flag.DurationVar(&c.interval, "interval", 15*time.Minute, "time to wait between --watch cycles")
+	flag.BoolVar(&c.useSyslog, "syslog", false, "send findings to syslog as JSON instead of printing them (not with --format or --output)")
+	flag.StringVar(&c.syslogAddr, "syslog-addr", "", "remote syslog collector (host:port, UDP); implies --syslog")
+	flag.Var(&c.excludeEmails, "exclude-email", "regex of emails to skip, on top of blacklist.txt (repeatable)")
+	flag.BoolVar(&c.noResponseCache, "no-response-cache", false, "don't reuse responses for URLs already fetched in this run")
+	flag.IntVar(&o.MaxPages, "max-pages", o.MaxPages, "safety cap on pages fetched per listing (0 = no cap)")
+	flag.BoolVar(&o.DetectLanguage, "detect-language", false, "guess the natural language of commit messages per identity")
+	flag.StringVar(&c.manifest, "manifest", "", "write a JSON manifest of parameters and coverage to this file")
+	flag.Float64Var(&o.NameSimilarity, "name-similarity", 0, "suggest identities whose names are at least this similar (0-1, Jaro-Winkler; 0 = off)")
+	flag.StringVar(&c.format, "format", "text", "output format: text, table, kv, xlsx, csv, sarif, es-bulk, json, jsonl, jsonl-gz, ndjson or ndjson-findings-and-identities")
+	flag.StringVar(&c.output, "output", "", "write --format json/jsonl/jsonl-gz/ndjson/ndjson-findings-and-identities/csv/sarif output to this file instead of stdout (required for xlsx)")
+	flag.StringVar(&c.esIndex, "es-index", "dossier", "Elasticsearch index name for --format es-bulk")
+	flag.StringVar(&c.activeSince, "active-since", "", "skip repos with no pushes since this date (YYYY-MM-DD or RFC 3339)")
+	flag.StringVar(&c.since, "since", "", "only scan commits authored on or after this date (YYYY-MM-DD or RFC 3339)")
+	flag.StringVar(&c.until, "until", "", "only scan commits authored up to the end of this date (YYYY-MM-DD), or before this RFC 3339 time")
+	flag.IntVar(&o.Retry.MaxAttempts, "retry-max", o.Retry.MaxAttempts, "attempts per request on 5xx responses and transient network errors (timeouts, dropped connections)")
+	flag.DurationVar(&o.Retry.BaseDelay, "retry-base-delay", o.Retry.BaseDelay, "initial retry delay, doubled on each further attempt")
+	flag.DurationVar(&o.RequestTimeout, "request-timeout", o.RequestTimeout, "give up on a single request after this long, including reading the response (0 = no limit)")
+	flag.BoolVar(&o.ProfileOnly, "include-email-from-profile-only", false, "only fetch profile emails (and GPG key emails on GitHub), skipping all commit history")
+	flag.BoolVar(&o.SkipNoReply, "skip-noreply", false, "drop platform noreply addresses (users.noreply.github.com and the like), noreply@ mailboxes and bots")
+	flag.BoolVar(&o.SkipBinaryLike, "skip-binary-like", false, "skip signature matching on commit messages that look like pasted binary or minified blobs")
+	flag.BoolVar(&o.EmailOnly, "author-email-only", false, "print only distinct author emails, one per line, skipping all other findings")
+	flag.DurationVar(&o.FlushInterval, "flush-interval", 0, "also flush buffered output (table, jsonl-gz) this often during a scan, e.g. 30s; table prints only new or changed rows")
+	flag.BoolVar(&o.ScanMetadata, "scan-metadata", false, "also read CODEOWNERS, AUTHORS, MAINTAINERS and .mailmap in each repo for emails and usernames")
+	flag.StringVar(&o.EmailFormat, "email-format", o.EmailFormat, "how text output renders emails: plain, mailto or angle")
+	flag.BoolVar(&o.OnlyWithSecrets, "only-with-secrets", false, "only print repos (and their findings) that contain at least one secret")
+	flag.Float64Var(&o.SampleRate, "sample-rate", o.SampleRate, "process only this random fraction of fetched commits (0-1], for quick profiling")
+	flag.Int64Var(&c.seed, "seed", 0, "random seed for --sample-rate, for reproducible samples (0 = time-based)")
+	flag.IntVar(&o.MaxBufferedFindings, "max-buffered-findings", o.MaxBufferedFindings, "findings held in memory per repo by --only-with-secrets before spilling to a temp file (0 = no cap); --format json, sarif and xlsx and --dedup still hold the whole run in memory")
+	flag.DurationVar(&o.DateSkew, "date-skew", o.DateSkew, "flag commits dated further than this into the future as suspicious")
+	flag.BoolVar(&o.CoauthorOnly, "include-coauthor-only", false, "report only identities from Co-authored-by, Signed-off-by and similar trailers, skipping commit authors")
+	flag.StringVar(&c.signaturesFile, "signatures", "", "signature file (default $DOSSIER_SIGNATURES, else signatures.yaml in the working directory, then in ~/.config/dossier, then the built-in set)")
+	flag.StringVar(&c.signaturesDir, "signatures-dir", "", "load and merge every *.yaml signature pack in this directory instead of signatures.yaml")
+	flag.StringVar(&c.whitelistFile, "whitelist", "", "only report emails matching one of this file's regexes (one per line); the blacklist still applies after it")
+	flag.BoolVar(&c.blacklistIgnoreCase, "blacklist-ignore-case", false, "match blacklist and --exclude-email patterns case-insensitively, so gmail\\.com also drops Bob@Gmail.com")
+	flag.StringVar(&c.blacklistFile, "blacklist", "", "email blacklist file (default $DOSSIER_BLACKLIST, else blacklist.txt in the working directory, then in ~/.config/dossier, then the built-in list)")
+	flag.StringVar(&c.redactConfig, "redact-config", "", "YAML file of extra regexes whose matches are masked as **** in every finding")
+	flag.Var(&c.tzOffsets, "tz-offset", "only report commits made at this UTC offset, e.g. +05:30 (repeatable)")
+	flag.BoolVar(&c.dedupAcrossRuns, "dedup-across-runs", false, "skip findings already reported by earlier runs, remembered in "+seenStoreFile)
+	flag.BoolVar(&c.resetDedup, "reset-dedup", false, "forget the findings remembered by --dedup-across-runs before scanning")
+	flag.StringVar(&o.Explain, "explain", "", "log to stderr why this email was or wasn't reported at each filter")
+	flag.BoolVar(&o.MatchRepos, "match-repos", false, "also run the repo_names, os and utility signatures over repo names, descriptions and topics")
+	flag.BoolVar(&o.NormalizeNames, "normalize-names", false, "clean display names (quotes, \"via\" suffixes, spacing, all-caps or all-lowercase) before reporting and grouping")
+	flag.BoolVar(&o.OrderByActivity, "order-by-activity", false, "scan the most recently active repos first, so caps and deadlines keep the freshest data")
+	flag.BoolVar(&c.dedup, "dedup", false, "print each email once per run, with how often it was seen (emails are held until the scan ends)")
+	flag.BoolVar(&c.dedupByName, "dedup-by-name", false, "with --dedup, treat the same email under different names as separate identities")
+	flag.BoolVar(&c.verifyMX, "verify-mx", false, "drop emails whose domain has no MX records (one DNS lookup per domain)")
+	flag.StringVar(&c.order, "order", "newest", "commit order within each scan: newest or oldest first")
+	flag.StringVar(&c.redirectHosts, "trusted-redirect-hosts", "", "comma-separated extra hosts API redirects may go to (credentials are never forwarded)")
+	flag.BoolVar(&c.verbose, "verbose", false, "also log every API request to stderr")
+	flag.BoolVar(&c.quiet, "quiet", false, "only log warnings and errors to stderr")
+	flag.BoolVar(&c.showProgress, "progress", true, "show the repo count and commits processed on stderr while scanning, if it is a terminal")
+	flag.StringVar(&c.cacheDir, "cache-dir", "", "keep API responses in this directory between runs (it may hold private repo data)")
+	flag.DurationVar(&c.cacheTTL, "cache-ttl", time.Hour, "how long --cache-dir responses are used without asking the API; older ones are revalidated")
+	return c
+}
+
+// Parse parses the command line and sets up the engine from the shared
+// flags, exiting on invalid ones. "schema" prints the signatures.yaml JSON
+// Schema and exits.
+func (c *Command) Parse() {
+	e := c.Engine
+	flag.Parse()
+	e.Identities.NormalizeNames = e.Options.NormalizeNames
+	if err := Log.SetVerbosity(c.verbose, c.quiet); err != nil {
+		fatal("Error:", err)
+	}
+	if flag.NArg() == 1 && flag.Arg(0) == "schema" {
+		schema, _ := json.MarshalIndent(ConfigSchema(), "", "  ")
+		fmt.Println(string(schema))
+		os.Exit(0)
+	}
+	if c.activeSince != "" {
+		t, err := parseDate(c.activeSince)
+		if err != nil {
+			fatalf("Invalid --active-since %q: %v\n", c.activeSince, err)
+		}
+		e.Options.ActiveSince = t
+	}
+	if c.since != "" {
+		t, err := parseDate(c.since)
+		if err != nil {
+			fatalf("Invalid --since %q: %v\n", c.since, err)
+		}
+		e.Options.Since = t
+	}
+	if c.until != "" {
+		t, err := parseDate(c.until)
+		if err != nil {
+			fatalf("Invalid --until %q: %v\n", c.until, err)
+		}
+		if len(c.until) == len("2006-01-02") {
+			t = t.AddDate(0, 0, 1) // a bare date includes that whole day
+		}
+		if !t.After(e.Options.Since) {
+			fatal("--until must be later than --since")
+		}
+		e.Options.Until = t
+	}
+	if (c.useSyslog || c.syslogAddr != "") && (c.format != "text" || c.output != "") {
+		fatal("--syslog cannot be combined with --format or --output")
+	}
+	c.setReporter()
+	if c.output != "" && !strings.HasPrefix(c.format, "json") && !strings.HasPrefix(c.format, "ndjson") && c.format != "xlsx" && c.format != "csv" && c.format != "sarif" {
+		fatal("--output is only supported with --format json, jsonl, jsonl-gz, ndjson, ndjson-findings-and-identities, csv, sarif or xlsx")
+	}
+	if e.Options.EmailOnly {
+		if c.format != "text" || c.useSyslog || c.syslogAddr != "" {
+			fatal("--author-email-only cannot be combined with --format or --syslog")
+		}
+		e.Reporter = NewEmailListReporter(os.Stdout, e.Options.EmailFormat)
+	}
+	if e.Options.SampleRate <= 0 || e.Options.SampleRate > 1 {
+		fatalf("Invalid --sample-rate %v (want a fraction in (0, 1])\n", e.Options.SampleRate)
+	}
+	if c.seed == 0 {
+		c.seed = time.Now().UnixNano()
+	}
+	e.Sampler = rand.New(rand.NewSource(c.seed))
+	switch e.Options.EmailFormat {
+	case "plain", "mailto", "angle":
+	default:
+		fatalf("Invalid --email-format %q (want plain, mailto or angle)\n", e.Options.EmailFormat)
+	}
+	for _, o := range c.tzOffsets {
+		norm, err := ParseOffset(o)
+		if err != nil {
+			fatal("Error:", err)
+		}
+		if e.Options.TZOffsets == nil {
+			e.Options.TZOffsets = map[string]bool{}
+		}
+		e.Options.TZOffsets[norm] = true
+	}
+	if c.noResponseCache {
+		e.Responses = nil
+	}
+	if e.Options.OrderByActivity {
+		if e.Options.RepoSort != "" && e.Options.RepoSort != "pushed" {
+			fatalf("--order-by-activity conflicts with --repo-sort %s\n", e.Options.RepoSort)
+		}
+		e.Options.RepoSort = "pushed"
+	}
+	switch c.order {
+	case "newest":
+	case "oldest":
+		e.Options.OldestFirst = true
+	default:
+		fatalf("Invalid --order %q (want newest or oldest)\n", c.order)
+	}
+	for _, h := range strings.Split(c.redirectHosts, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			e.TrustedRedirectHosts[strings.ToLower(h)] = true
+		}
+	}
+	if c.dedupByName && !c.dedup {
+		fatal("--dedup-by-name requires --dedup")
+	}
+	if c.watch {
+		if c.interval <= 0 {
+			fatal("--interval must be positive")
+		}
+		switch c.format {
+		case "json", "sarif", "xlsx":
+			fatalf("--format %s only writes its file when the program exits; use a streaming format with --watch\n", c.format)
+		}
+	}
+	if c.dedup && e.Options.FlushInterval > 0 {
+		fatal("--flush-interval cannot be combined with --dedup, whose counts are only final when the scan ends")
+	}
+	c.load()
+
+	if c.useSyslog || c.syslogAddr != "" {
+		r, err := NewSyslogReporter(c.syslogAddr)
+		if err != nil {
+			Log.Warnln("⚠️  Could not connect to syslog, printing findings instead:", err)
+		} else {
+			e.Reporter = r
+		}
+	}
+	if c.dedup {
+		if c.watch && e.Seen == nil {
+			e.Seen = map[string]bool{}
+		}
+		e.Dedup(c.dedupByName)
+	}
+	if c.showProgress && !c.quiet && IsTerminal(os.Stderr) {
+		e.Progress = NewProgressReporter(e.Reporter)
+		e.Reporter = e.Progress
+	}
+}
+
+// setReporter picks the reporter for --format
+func (c *Command) setReporter() {
+	e := c.Engine
+	opened := func(r Reporter, err error) Reporter {
+		if err != nil {
+			fatal("Error opening output:", err)
+		}
+		return r
+	}
+	switch c.format {
+	case "text":
+		e.Reporter = TextReporter{EmailFormat: e.Options.EmailFormat}
+	case "table":
+		e.Reporter = &TableReporter{W: os.Stdout, Identities: e.Identities}
+	case "kv":
+		e.Reporter = KVReporter{W: os.Stdout}
+	case "xlsx":
+		if c.output == "" {
+			fatal("--format xlsx requires --output")
+		}
+		e.Reporter = NewXLSXReporter(c.output, e.Identities)
+	case "csv":
+		e.Reporter = opened(NewCSVReporter(c.output))
+	case "sarif":
+		e.Reporter = opened(NewSARIFReporter(c.output, Version))
+	case "es-bulk":
+		e.Reporter = NewESBulkReporter(os.Stdout, c.esIndex)
+	case "ndjson-findings-and-identities":
+		r, err := NewJSONLReporter(c.output, false)
+		e.Reporter = opened(FindingsAndIdentitiesReporter{JSONLReporter: r, Identities: e.Identities}, err)
+	case "json":
+		e.Reporter = opened(NewJSONReporter(c.output))
+	case "ndjson":
+		w := io.Writer(os.Stdout)
+		if c.output != "" {
+			file, err := os.Create(c.output)
+			if err != nil {
+				fatal("Error opening output:", err)
+			}
+			c.closers = append(c.closers, file)
+			w = file
+		}
+		e.Reporter = NewNDJSONReporter(w)
+	case "jsonl", "jsonl-gz":
+		if c.format == "jsonl-gz" && c.output == "" {
+			fatal("--format jsonl-gz requires --output")
+		}
+		e.Reporter = opened(NewJSONLReporter(c.output, c.format == "jsonl-gz"))
+	default:
+		fatalf("Invalid --format %q (want text, table, kv, xlsx, csv, sarif, es-bulk, json, jsonl, jsonl-gz, ndjson or ndjson-findings-and-identities)\n", c.format)
+	}
+}
+
+// load reads the signature, redaction, blacklist and whitelist files and
+// opens the dedup store and the disk cache
+func (c *Command) load() {
+	e := c.Engine
+	if c.signaturesDir != "" && c.signaturesFile != "" {
+		fatal("--signatures and --signatures-dir are mutually exclusive")
+	}
+	var err error
+	if c.signaturesDir != "" {
+		e.Config, err = LoadPatternDir(c.signaturesDir)
+	} else if path := ConfigPath(c.signaturesFile, "DOSSIER_SIGNATURES", "signatures.yaml"); path != "" {
+		e.Config, err = LoadPatterns(path)
+	} else {
+		e.Config, err = DefaultPatterns()
+	}
+	if err != nil {
+		fatal("Error reading YAML:", err)
+	}
+
+	if c.redactConfig != "" {
+		if e.Redactions, err = LoadRedactions(c.redactConfig); err != nil {
+			fatal("Error reading redact config:", err)
+		}
+	}
+
+	if c.resetDedup {
+		if err := os.Remove(seenStoreFile); err != nil && !os.IsNotExist(err) {
+			fatal("Error resetting dedup store:", err)
+		}
+	}
+	if c.dedupAcrossRuns {
+		seen, store, err := LoadSeenStore(seenStoreFile)
+		if err != nil {
+			fatal("Error reading dedup store:", err)
+		}
+		c.closers = append(c.closers, store)
+		e.Seen, e.SeenStore = seen, store
+	}
+
+	var blacklist []*regexp.Regexp
+	if path := ConfigPath(c.blacklistFile, "DOSSIER_BLACKLIST", "blacklist.txt"); path != "" {
+		blacklist, err = LoadBlacklist(path)
+	} else {
+		blacklist, err = DefaultBlacklist()
+	}
+	if err != nil {
+		fatal("Error reading blacklist:", err)
+	}
+	for _, expr := range c.excludeEmails {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			fatalf("Invalid --exclude-email pattern %q: %v\n", expr, err)
+		}
+		blacklist = append(blacklist, re)
+	}
+	if c.blacklistIgnoreCase {
+		blacklist = IgnoreCase(blacklist)
+	}
+	e.Blacklist = NewBlacklist(blacklist)
+	if c.whitelistFile != "" {
+		whitelist, err := LoadWhitelist(c.whitelistFile)
+		if err != nil {
+			fatal("Error reading whitelist:", err)
+		}
+		if len(whitelist) == 0 {
+			fatalf("Whitelist %s has no patterns, nothing would be reported\n", c.whitelistFile)
+		}
+		e.Whitelist = NewBlacklist(whitelist)
+	}
+	if c.verifyMX {
+		e.MX = NewMXChecker()
+	}
+	if c.cacheDir != "" {
+		if e.Disk, err = OpenDiskCache(c.cacheDir, c.cacheTTL); err != nil {
+			fatal("Error opening cache:", err)
+		}
+	}
+}
+
+// Run scans target once, or every --interval with --watch until Ctrl-C,
+// printing the summary and writing the --manifest after each scan
+func (c *Command) Run(target string, scan func(ctx context.Context, target string) error) {
+	e := c.Engine
+	defer func() {
+		for _, cl := range c.closers {
+			cl.Close()
+		}
+	}()
+
+	// Stop cleanly on Ctrl-C, between repos or while waiting in watch mode
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	defer e.CloseReporter()
+
+	if c.watch {
+		c.watchLoop(ctx, target, scan)
+		return
+	}
+	started := time.Now()
+	err := scan(ctx, target)
+	c.writeManifest(target, started, err)
+	if err != nil {
+		Log.Errorln("Error:", err)
+		e.CloseReporter()
+		os.Exit(1)
+	}
+	e.FlushReporter()
+	e.SummarizeReporter()
+	e.PrintSummary()
+}
+
+// watchLoop runs a scan every --interval until ctx is cancelled, flushing
+// after each so every cycle's new findings are written straight away
+func (c *Command) watchLoop(ctx context.Context, target string, scan func(ctx context.Context, target string) error) {
+	e := c.Engine
+	if e.Seen == nil && !c.dedup { // with --dedup a SeenFilter already tracks them
+		e.Seen = map[string]bool{}
+	}
+	for cycle := 1; ; cycle++ {
+		Log.Infof("=== Watch cycle %d at %s ===\n\n", cycle, time.Now().Format("2006-01-02 15:04:05 MST"))
+		e.Reset()
+		started := time.Now()
+		err := scan(ctx, target)
+		c.writeManifest(target, started, err)
+		if err != nil && ctx.Err() == nil {
+			Log.Errorln("Error:", err)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		e.FlushReporter()
+		e.SummarizeReporter()
+		e.PrintSummary()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.interval):
+		}
+	}
+}
+
+func (c *Command) writeManifest(target string, started time.Time, scanErr error) {
+	if c.manifest == "" {
+		return
+	}
+	e := c.Engine
+	if err := WriteManifest(c.manifest, e.Provider, target, e.Config, e.Stats, started, scanErr); err != nil {
+		Log.Errorln("Error writing manifest:", err)
+	}
+}
+
+// fatal and fatalf log a command line error and exit
+func fatal(args ...any) {
+	Log.Errorln(args...)
+	os.Exit(1)
+}
+
+func fatalf(format string, args ...any) {
+	Log.Errorf(format, args...)
+	os.Exit(1)
+}
+
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// stringList collects a repeatable flag
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ", ") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
@@ -0,0 +1,79 @@
+package dossier
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchLoopReportsEachFindingOnce(t *testing.T) {
+	w := Log.W
+	Log.W = io.Discard
+	defer func() { Log.W = w }()
+
+	var out strings.Builder
+	e := NewEngine("github", nil, nil)
+	e.Reporter = NewNDJSONReporter(&out)
+	c := &Command{Engine: e, watch: true, interval: time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	alice := Finding{Type: "email", Email: "alice@acme.io", Location: "https://example.com/c/1"}
+	bob := Finding{Type: "email", Email: "bob@acme.io", Location: "https://example.com/c/2"}
+	cycles := 0
+	c.watchLoop(ctx, "acme", func(ctx context.Context, target string) error {
+		cycles++
+		switch cycles {
+		case 1:
+			e.Report(alice)
+		case 2:
+			e.Report(alice)
+			e.Report(bob)
+		default:
+			cancel()
+		}
+		return ctx.Err()
+	})
+
+	if cycles != 3 {
+		t.Fatalf("ran %d cycles, want 3", cycles)
+	}
+	got := out.String()
+	if n := strings.Count(got, "alice@acme.io"); n != 1 {
+		t.Errorf("alice reported %d times, want 1:\n%s", n, got)
+	}
+	if n := strings.Count(got, "bob@acme.io"); n != 1 {
+		t.Errorf("bob reported %d times, want 1:\n%s", n, got)
+	}
+	if n := strings.Count(got, `"type":"summary"`); n != 2 {
+		t.Errorf("got %d summaries, want one per finished cycle:\n%s", n, got)
+	}
+	if i, j := strings.Index(got, "alice@acme.io"), strings.Index(got, `"type":"summary"`); i > j {
+		t.Errorf("first cycle's finding was not written before its summary:\n%s", got)
+	}
+}
+
+func TestExcludeEmailExtendsTheBlacklist(t *testing.T) {
+	c := &Command{
+		Engine:        NewEngine("github", nil, nil),
+		blacklistFile: writeFile(t, "blacklist.txt", "^root@\n"),
+		excludeEmails: stringList{`^ci@`, `@contractor\.io$`},
+	}
+	c.load()
+	for addr, want := range map[string]bool{
+		"alice@acme.io":        true,
+		"root@acme.io":         false, // from the file
+		"ci@acme.io":           false,
+		"bob@contractor.io":    false,
+		"bob@subcontractor.io": true,
+	} {
+		if got := c.Engine.ShouldReport(addr); got != want {
+			t.Errorf("ShouldReport(%s) = %v, want %v", addr, got, want)
+		}
+	}
+	if s := c.Engine.Summary(); s.EmailsBlacklisted != 3 {
+		t.Errorf("blacklisted %d, want 3", s.EmailsBlacklisted)
+	}
+}
@@ -0,0 +1,168 @@
+package dossier
+
+import (
+	"strings"
+	"time"
+)
+
+// ========================== Commits ==========================
+
+// Person is a commit author or committer. Login and URL are the linked
+// platform account, where the API reports one.
+type Person struct {
+	Name  string
+	Email string
+	Login string
+	URL   string
+}
+
+// Commit is what ProcessCommits needs from a provider's commit, whatever
+// its API calls the fields
+type Commit struct {
+	ID       string
+	Repo     string
+	Location string
+	Time     time.Time
+	Date     string // as the API reported it, if Time couldn't be parsed
+
+	Author Person
+	// Committer is reported alongside the author when its Email is set;
+	// providers leave it empty when they don't want committers listed
+	Committer Person
+
+	Message string
+	// Text is what the os and utility signatures run over, when it should
+	// hold more than the message (e.g. the author line or the whole commit)
+	Text string
+	// Signed commits count towards their identities' signatures;
+	// SigningKey is empty when the key can't be attributed
+	Signed     bool
+	SigningKey string
+}
+
+// ========================== Commit Processing ==========================
+
+// ProcessCommits reports the identities, signatures and secrets in a batch
+// of commits
+func (e *Engine) ProcessCommits(commits []Commit) {
+	e.count(func(s *ScanStats) { s.CommitsProcessed += len(commits) })
+	e.Progress.SetCommits(e.Stats.CommitsProcessed)
+	accounts := map[string]bool{}
+	for _, c := range commits {
+		if !e.SampleCommit() {
+			continue
+		}
+		commitDate := c.Date
+		if !c.Time.IsZero() {
+			commitDate = c.Time.Format("2006-01-02 15:04:05 MST")
+		}
+		if !e.MatchesOffset(c.Time) || !e.InDateRange(c.Time) {
+			continue
+		}
+		e.Spans.Add(c.Repo, c.ID, c.Time)
+		finding := func(f Finding) Finding {
+			f.Date, f.Repo = commitDate, c.Repo
+			if f.Location == "" {
+				f.Location = c.Location
+			}
+			return f
+		}
+		email, name := c.Author.Email, c.Author.Name
+
+		// Co-authors, sign-offs and other trailer identities
+		for _, t := range ParseTrailers(c.Message) {
+			if !strings.EqualFold(t.Email, email) && e.ShouldReport(t.Email) {
+				e.Identities.Add(t.Email, t.Name, c.Repo, c.Time)
+				e.Report(finding(Finding{Type: "email", Signature: t.Key, Email: t.Email, Name: t.Name}))
+			}
+		}
+		if e.Options.CoauthorOnly {
+			continue
+		}
+
+		// Other addresses mentioned in the message body
+		for _, addr := range MessageEmails(c.Message, email) {
+			if e.ShouldReport(addr) {
+				e.Report(finding(Finding{Type: "email", Signature: "commit message", Email: addr}))
+			}
+		}
+
+		// Emails (with names)
+		for i, who := range []Person{c.Author, c.Committer} {
+			if i == 1 && (who.Email == "" || e.Options.EmailOnly && !e.Options.CommitterToo) {
+				continue
+			}
+			reportable := e.ShouldReport(who.Email)
+			if reportable {
+				if i == 0 || !strings.EqualFold(who.Email, email) { // count each commit once
+					e.Identities.Add(who.Email, who.Name, c.Repo, c.Time)
+					if c.Signed {
+						e.Identities.AddSignature(who.Email, c.SigningKey)
+					}
+				}
+				e.Report(finding(Finding{Type: "email", Email: who.Email, Name: who.Name}))
+				for _, m := range SearchPatterns(EmailDomain(who.Email), e.Config.EmailDomains) {
+					e.Report(finding(Finding{Type: "domain_match", Signature: m, Email: who.Email, Name: who.Name}))
+				}
+			}
+
+			// The linked account is reported even for blacklisted (e.g. noreply)
+			// emails, since it is often the only real identifier on the commit
+			if who.Login != "" && !accounts[who.Login] {
+				accounts[who.Login] = true
+				addr := ""
+				if reportable {
+					addr = who.Email
+				}
+				e.Report(finding(Finding{Type: "account", Value: who.Login, Email: addr, Name: who.Name, Location: who.URL}))
+			}
+		}
+
+		if e.Options.EmailOnly {
+			continue
+		}
+
+		if reason := e.SuspiciousDate(c.Time); reason != "" {
+			e.Report(finding(Finding{Type: "suspicious_date", Signature: reason, Email: email, Name: name}))
+		}
+
+		if e.Options.SkipBinaryLike && LooksBinary(c.Message) {
+			e.count(func(s *ScanStats) { s.BinaryLikeSkipped++ })
+			continue
+		}
+
+		text := c.Text
+		if text == "" {
+			text = c.Message
+		}
+		for _, m := range SearchPatterns(text, e.Config.OperatingSystems) {
+			e.Report(finding(Finding{Type: "os", Signature: m, Email: email, Name: name}))
+		}
+		for _, m := range SearchPatterns(text, e.Config.Utilities) {
+			e.Report(finding(Finding{Type: "utility", Signature: m, Email: email, Name: name}))
+		}
+
+		for _, m := range FindSecrets(c.Message, GoogleCredentialPatterns) {
+			e.Report(finding(Finding{Type: "google_credential", Signature: m.ID, Email: email, Name: name}.WithSecret(m.Value)).At(c.Message, m.Offset))
+		}
+		for _, m := range FindSecrets(c.Message, SaaSCredentialPatterns) {
+			e.Report(finding(Finding{Type: "saas_credential", Signature: m.ID, Email: email, Name: name}.WithSecret(m.Value)).At(c.Message, m.Offset))
+		}
+		for _, r := range FindRegistryCredentials(c.Message) {
+			e.Report(finding(Finding{Type: "registry_credential", Signature: r.Kind, Value: r.Registry, Email: email, Name: name}.WithSecret(r.Value)).At(c.Message, r.Offset))
+		}
+
+		// Credentials hidden in base64/hex blobs
+		for _, m := range DecodeAndRescan(c.Message, EncodedSecretPatterns) {
+			e.Report(finding(Finding{Type: "encoded_secret", Signature: m.ID, Encoding: m.Encoding, Email: email, Name: name}.WithSecret(m.Value)).At(c.Message, m.Offset))
+		}
+
+		for _, a := range FindCryptoAddresses(c.Message) {
+			e.Report(finding(Finding{Type: "crypto_address", Signature: a.Coin, Value: a.Address, Email: email, Name: name}).At(c.Message, a.Offset))
+		}
+
+		if e.Options.DetectLanguage {
+			e.Identities.AddLanguage(email, DetectLanguage(c.Message))
+		}
+	}
+}
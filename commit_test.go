@@ -0,0 +1,164 @@
+package dossier
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// process runs commits through a fresh engine built by setup and returns
+// what it reported
+func process(t *testing.T, setup func(e *Engine), commits ...Commit) []Finding {
+	t.Helper()
+	cfg, err := DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine("github", cfg, nil)
+	c := &Collector{}
+	e.Reporter = c
+	if setup != nil {
+		setup(e)
+	}
+	e.ProcessCommits(commits)
+	return c.Findings
+}
+
+func byType(findings []Finding, typ string) []Finding {
+	var out []Finding
+	for _, f := range findings {
+		if f.Type == typ {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+var commitTime = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+func TestProcessCommitsDomainMatch(t *testing.T) {
+	findings := process(t, func(e *Engine) {
+		e.Config.EmailDomains = []Pattern{{ID: "Acme Corp", Regex: `(^|\.)acme\.io$`}}
+	},
+		Commit{ID: "1", Location: "https://example.com/c/1", Time: commitTime, Author: Person{Name: "Alice", Email: "alice@eu.acme.io"}},
+		Commit{ID: "2", Location: "https://example.com/c/2", Time: commitTime, Author: Person{Name: "Mallory", Email: "mallory@notacme.io"}},
+	)
+	matches := byType(findings, "domain_match")
+	if len(matches) != 1 {
+		t.Fatalf("domain matches = %+v, want one", matches)
+	}
+	m := matches[0]
+	if m.Signature != "Acme Corp" || m.Email != "alice@eu.acme.io" || m.Name != "Alice" || m.Location != "https://example.com/c/1" {
+		t.Errorf("domain match = %+v", m)
+	}
+}
+
+func TestAuthorEmailOnlyPrintsOnlyAddresses(t *testing.T) {
+	var out strings.Builder
+	process(t, func(e *Engine) {
+		e.Options.EmailOnly = true
+		e.Reporter = NewEmailListReporter(&out, "plain")
+	},
+		Commit{ID: "1", Time: commitTime,
+			Author:    Person{Name: "Alice", Email: "alice@acme.io", Login: "alice"},
+			Committer: Person{Name: "CI", Email: "ci@acme.io"},
+			Message:   "Fix build on ubuntu with gpg\n\nkey " + fakeGoogleKey + "\n\nCo-authored-by: Bob <bob@acme.io>"},
+		Commit{ID: "2", Time: commitTime, Author: Person{Name: "Alice", Email: "Alice@acme.io"}, Message: "Again"},
+	)
+	if got, want := out.String(), "bob@acme.io\nalice@acme.io\n"; got != want {
+		t.Errorf("output = %q, want only the distinct author and trailer emails %q", got, want)
+	}
+}
+
+func TestSampleRateWithFixedSeed(t *testing.T) {
+	commits := make([]Commit, 5000)
+	for i := range commits {
+		commits[i] = Commit{ID: fmt.Sprint(i), Time: commitTime, Author: Person{Name: "Dev", Email: fmt.Sprintf("dev%d@acme.io", i)}}
+	}
+	sample := func(seed int64) ([]string, ScanStats) {
+		var e *Engine
+		findings := process(t, func(eng *Engine) {
+			e = eng
+			e.Options.SampleRate = 0.1
+			e.Options.EmailOnly = true
+			e.Sampler = rand.New(rand.NewSource(seed))
+		}, commits...)
+		var emails []string
+		for _, f := range byType(findings, "email") {
+			emails = append(emails, f.Email)
+		}
+		return emails, e.Stats
+	}
+
+	first, stats := sample(42)
+	if stats.CommitsProcessed != 5000 {
+		t.Errorf("processed %d commits, want all 5000 fetched", stats.CommitsProcessed)
+	}
+	if stats.CommitsSampled < 400 || stats.CommitsSampled > 600 {
+		t.Errorf("sampled %d of 5000 commits, want about 500", stats.CommitsSampled)
+	}
+	if len(first) != stats.CommitsSampled {
+		t.Errorf("reported %d authors for %d sampled commits", len(first), stats.CommitsSampled)
+	}
+	if again, _ := sample(42); !slices.Equal(first, again) {
+		t.Error("same seed picked a different sample")
+	}
+	if other, _ := sample(7); slices.Equal(first, other) {
+		t.Error("different seeds picked the same sample")
+	}
+}
+
+func TestCoauthorOnlyReportsOnlyTrailerIdentities(t *testing.T) {
+	findings := process(t, func(e *Engine) { e.Options.CoauthorOnly = true },
+		Commit{ID: "1", Time: commitTime, Location: "https://example.com/c/1",
+			Author:    Person{Name: "Alice", Email: "alice@acme.io"},
+			Committer: Person{Name: "CI", Email: "ci-runner@acme.io"},
+			Message:   "Fix parser on macOS; ping dave@acme.io\n\nCo-authored-by: Bob <bob@acme.io>\nSigned-off-by: Carol <carol@acme.io>\nSigned-off-by: Alice <alice@acme.io>"},
+		Commit{ID: "2", Time: time.Unix(0, 0), Author: Person{Name: "Erin", Email: "erin@acme.io"}, Message: "Solo change"},
+	)
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Type+":"+f.Signature+":"+f.Email)
+	}
+	want := "email:Co-authored-by:bob@acme.io email:Signed-off-by:carol@acme.io"
+	if strings.Join(got, " ") != want {
+		t.Errorf("findings = %v, want only %s", got, want)
+	}
+}
+
+func TestTZOffsetFilter(t *testing.T) {
+	india := time.FixedZone("IST", 5*3600+1800)
+	commits := []Commit{
+		{ID: "1", Time: time.Date(2024, 5, 1, 10, 0, 0, 0, india), Author: Person{Name: "Alice", Email: "alice@acme.io"}},
+		{ID: "2", Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.FixedZone("", -7*3600)), Author: Person{Name: "Bob", Email: "bob@acme.io"}},
+		{ID: "3", Time: time.Date(2024, 5, 1, 4, 30, 0, 0, time.UTC), Author: Person{Name: "Carol", Email: "carol@acme.io"}}, // same instant as 1, other offset
+		{ID: "4", Author: Person{Name: "Dave", Email: "dave@acme.io"}},                                                       // undated
+	}
+	emails := func(offsets ...string) string {
+		findings := process(t, func(e *Engine) {
+			for _, o := range offsets {
+				if e.Options.TZOffsets == nil {
+					e.Options.TZOffsets = map[string]bool{}
+				}
+				e.Options.TZOffsets[o] = true
+			}
+		}, commits...)
+		var out []string
+		for _, f := range byType(findings, "email") {
+			out = append(out, f.Email)
+		}
+		return strings.Join(out, " ")
+	}
+	if got := emails("+05:30"); got != "alice@acme.io" {
+		t.Errorf("--tz-offset +05:30 reported %q, want only alice", got)
+	}
+	if got := emails("+05:30", "-07:00"); got != "alice@acme.io bob@acme.io" {
+		t.Errorf("two offsets reported %q, want alice and bob", got)
+	}
+	if got := emails(); got != "alice@acme.io bob@acme.io carol@acme.io dave@acme.io" {
+		t.Errorf("no filter reported %q, want everyone", got)
+	}
+}
@@ -0,0 +1,319 @@
+// Package correlate runs several provider scanners for the same people and
+// joins their findings into one dossier per email address.
+package correlate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/0x4f53/dossier"
+	"github.com/0x4f53/dossier/bitbucket"
+	"github.com/0x4f53/dossier/github"
+	"github.com/0x4f53/dossier/gitlab"
+	"github.com/0x4f53/dossier/sourcehut"
+)
+
+// ========================== Structs ==========================
+
+// Entry is everything found for one email address, on every provider
+type Entry struct {
+	Email     string            `json:"email"`
+	Names     []string          `json:"names,omitempty"`
+	Providers []string          `json:"providers"`
+	Findings  []dossier.Finding `json:"findings"`
+}
+
+// Target is one username to scan on one provider
+type Target struct {
+	Provider string
+	Username string
+}
+
+// ========================== Correlation ==========================
+
+// Correlate groups findings by email, case-insensitively. Every finding
+// keeps its Provider, so each data point stays attributed to its platform.
+// Findings without an email (usernames from metadata files, for one) have
+// no identity to join on and are left out. Entries seen on the most
+// providers come first.
+func Correlate(findings []dossier.Finding) []Entry {
+	byEmail := map[string]*Entry{}
+	names := map[string]map[string]bool{} // email -> lowercased names already listed
+	providers := map[string]map[string]bool{}
+	for _, f := range findings {
+		if f.Email == "" {
+			continue
+		}
+		key := strings.ToLower(f.Email)
+		e, ok := byEmail[key]
+		if !ok {
+			e = &Entry{Email: f.Email}
+			byEmail[key] = e
+			names[key] = map[string]bool{}
+			providers[key] = map[string]bool{}
+		}
+		e.Findings = append(e.Findings, f)
+		if f.Name != "" && !names[key][strings.ToLower(f.Name)] {
+			names[key][strings.ToLower(f.Name)] = true
+			e.Names = append(e.Names, f.Name)
+		}
+		if f.Provider != "" && !providers[key][f.Provider] {
+			providers[key][f.Provider] = true
+			e.Providers = append(e.Providers, f.Provider)
+		}
+	}
+
+	entries := make([]Entry, 0, len(byEmail))
+	for _, e := range byEmail {
+		sort.Strings(e.Providers)
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if len(entries[i].Providers) != len(entries[j].Providers) {
+			return len(entries[i].Providers) > len(entries[j].Providers)
+		}
+		return strings.ToLower(entries[i].Email) < strings.ToLower(entries[j].Email)
+	})
+	return entries
+}
+
+// ParseTargets expands the command line: "name" is scanned on every
+// provider, "provider:name" only on that one
+func ParseTargets(args, providers []string) ([]Target, error) {
+	known := map[string]bool{}
+	for _, p := range providers {
+		known[p] = true
+	}
+	var targets []Target
+	for _, arg := range args {
+		if p, name, ok := strings.Cut(arg, ":"); ok {
+			if !known[p] {
+				return nil, fmt.Errorf("unknown or disabled provider %q in %q (want one of %s)", p, arg, strings.Join(providers, ", "))
+			}
+			targets = append(targets, Target{p, name})
+			continue
+		}
+		for _, p := range providers {
+			targets = append(targets, Target{p, arg})
+		}
+	}
+	return targets, nil
+}
+
+// ========================== Scanners ==========================
+
+var allProviders = []string{"github", "gitlab", "bitbucket", "sourcehut"}
+
+// newScanners builds one scanner per provider that can run with the
+// credentials at hand. Each collects its findings without printing them.
+// Credentials the provider's own tool would refuse are an error here too.
+func newScanners(providers []string, tokens dossier.Tokens, cfg *dossier.Config, blacklist []*regexp.Regexp) (map[string]dossier.Scanner, error) {
+	scanners := map[string]dossier.Scanner{}
+	for _, p := range providers {
+		switch p {
+		case "github":
+			s := github.NewScanner(tokens.GitHub, cfg, blacklist)
+			if v := os.Getenv("GITHUB_API_URL"); v != "" {
+				s.BaseURL = strings.TrimSuffix(v, "/")
+			}
+			s.Reporter = nil
+			scanners[p] = s
+		case "gitlab":
+			s := gitlab.NewScanner(tokens.GitLab, cfg, blacklist)
+			if v := os.Getenv("GITLAB_URL"); v != "" {
+				s.BaseURL = strings.TrimSuffix(v, "/")
+			}
+			s.Reporter = nil
+			scanners[p] = s
+		case "bitbucket":
+			s := bitbucket.NewScanner(tokens.BitbucketAccessToken, cfg, blacklist)
+			if tokens.BitbucketAppPassword != "" {
+				if tokens.BitbucketUsername == "" {
+					return nil, errors.New("BITBUCKET_APP_PASSWORD needs BITBUCKET_USERNAME as well")
+				}
+				s.User, s.Token = tokens.BitbucketUsername, tokens.BitbucketAppPassword
+			}
+			s.Reporter = nil
+			scanners[p] = s
+		case "sourcehut":
+			if tokens.SourceHut == "" {
+				dossier.Log.Warnln("⚠️  Skipping SourceHut: no SRHT_TOKEN in .env or the environment")
+				continue
+			}
+			s := sourcehut.NewScanner(tokens.SourceHut, cfg, blacklist)
+			s.Reporter = nil
+			scanners[p] = s
+		}
+	}
+	return scanners, nil
+}
+
+// ========================== Output ==========================
+
+func printText(w io.Writer, entries []Entry) {
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s (%s)\n", e.Email, strings.Join(e.Providers, ", "))
+		if len(e.Names) > 0 {
+			fmt.Fprintf(w, "  names: %s\n", strings.Join(e.Names, ", "))
+		}
+		for _, f := range e.Findings {
+			line := fmt.Sprintf("  [%s] %s", f.Provider, f.Type)
+			if f.Signature != "" {
+				line += ": " + f.Signature
+			}
+			if f.Value != "" {
+				line += " " + f.Value
+			}
+			if f.Secret != "" {
+				line += " " + f.Secret
+			}
+			if f.Date != "" {
+				line += " on " + f.Date
+			}
+			if f.Location != "" {
+				line += " at " + f.Location
+			}
+			fmt.Fprintln(w, line)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// ========================== Main ==========================
+
+// Main runs the cross-provider command line tool
+func Main() {
+	providerList := flag.String("providers", "github,gitlab,bitbucket", "comma-separated providers to scan: "+strings.Join(allProviders, ", "))
+	format := flag.String("format", "text", "output format: text or json")
+	output := flag.String("output", "", "write the dossier to this file instead of stdout")
+	crossOnly := flag.Bool("cross-only", false, "only show emails found on more than one provider")
+	signaturesFile := flag.String("signatures", "", "signature file (default $DOSSIER_SIGNATURES, else signatures.yaml in the working directory, then in ~/.config/dossier, then the built-in set)")
+	blacklistFile := flag.String("blacklist", "", "email blacklist file (default $DOSSIER_BLACKLIST, else blacklist.txt in the working directory, then in ~/.config/dossier, then the built-in list)")
+	verbose := flag.Bool("verbose", false, "also log every API request to stderr")
+	quiet := flag.Bool("quiet", false, "only log warnings and errors to stderr")
+	flag.Parse()
+	if err := dossier.Log.SetVerbosity(*verbose, *quiet); err != nil {
+		dossier.Log.Errorln("Error:", err)
+		os.Exit(1)
+	}
+
+	if flag.NArg() < 1 {
+		dossier.Log.Errorln("Usage: go run ./cmd/correlate [flags] <username|provider:username>...")
+		dossier.Log.Errorln("       a bare username is scanned on every provider in --providers")
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "json" {
+		dossier.Log.Errorf("Invalid --format %q (want text or json)\n", *format)
+		os.Exit(1)
+	}
+	var providers []string
+	for _, p := range strings.Split(*providerList, ",") {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" {
+			continue
+		}
+		if !slices.Contains(allProviders, p) {
+			dossier.Log.Errorf("Invalid --providers entry %q (want %s)\n", p, strings.Join(allProviders, ", "))
+			os.Exit(1)
+		}
+		providers = append(providers, p)
+	}
+	targets, err := ParseTargets(flag.Args(), providers)
+	if err != nil {
+		dossier.Log.Errorln("Error:", err)
+		os.Exit(1)
+	}
+
+	var cfg *dossier.Config
+	if path := dossier.ConfigPath(*signaturesFile, "DOSSIER_SIGNATURES", "signatures.yaml"); path != "" {
+		cfg, err = dossier.LoadPatterns(path)
+	} else {
+		cfg, err = dossier.DefaultPatterns()
+	}
+	if err != nil {
+		dossier.Log.Errorln("Error reading YAML:", err)
+		os.Exit(1)
+	}
+	var blacklist []*regexp.Regexp
+	if path := dossier.ConfigPath(*blacklistFile, "DOSSIER_BLACKLIST", "blacklist.txt"); path != "" {
+		blacklist, err = dossier.LoadBlacklist(path)
+	} else {
+		blacklist, err = dossier.DefaultBlacklist()
+	}
+	if err != nil {
+		dossier.Log.Errorln("Error reading blacklist:", err)
+		os.Exit(1)
+	}
+	tokens, err := dossier.LoadTokens(".env")
+	if err != nil {
+		dossier.Log.Errorln("Error reading .env:", err)
+		os.Exit(1)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			dossier.Log.Errorln("Error opening output:", err)
+			os.Exit(1)
+		}
+		defer out.Close()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	scanners, err := newScanners(providers, tokens, cfg, blacklist)
+	if err != nil {
+		dossier.Log.Errorln("Error:", err)
+		os.Exit(1)
+	}
+	var findings []dossier.Finding
+	for _, t := range targets {
+		s, ok := scanners[t.Provider]
+		if !ok {
+			continue
+		}
+		dossier.Log.Infof("=== %s on %s ===\n", t.Username, t.Provider)
+		found, err := s.ScanUser(ctx, t.Username)
+		findings = append(findings, found...) // keep what was found before an error
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			dossier.Log.Warnf("⚠️  %s on %s: %v\n", t.Username, t.Provider, err)
+		}
+	}
+
+	entries := Correlate(findings)
+	if *crossOnly {
+		kept := entries[:0]
+		for _, e := range entries {
+			if len(e.Providers) > 1 {
+				kept = append(kept, e)
+			}
+		}
+		entries = kept
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(entries); err != nil {
+			dossier.Log.Errorln("Error writing output:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	printText(out, entries)
+}
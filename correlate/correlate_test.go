@@ -0,0 +1,111 @@
+package correlate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/0x4f53/dossier"
+	"github.com/0x4f53/dossier/bitbucket"
+)
+
+func TestCorrelate(t *testing.T) {
+	email := func(provider, addr, name string) dossier.Finding {
+		return dossier.Finding{Type: "email", Provider: provider, Email: addr, Name: name}
+	}
+	tests := []struct {
+		name     string
+		findings []dossier.Finding
+		want     []Entry // Findings checked by count only
+		counts   []int
+	}{
+		{
+			name: "joined across providers case-insensitively",
+			findings: []dossier.Finding{
+				email("gitlab", "Alice@acme.io", "Alice"),
+				email("github", "alice@acme.io", "alice"),
+				email("github", "alice@ACME.io", "A. Smith"),
+			},
+			want:   []Entry{{Email: "Alice@acme.io", Names: []string{"Alice", "A. Smith"}, Providers: []string{"github", "gitlab"}}},
+			counts: []int{3},
+		},
+		{
+			name: "most providers first, then by email",
+			findings: []dossier.Finding{
+				email("github", "carol@acme.io", ""),
+				email("bitbucket", "Bob@acme.io", "Bob"),
+				email("github", "dave@acme.io", "Dave"),
+				email("sourcehut", "dave@acme.io", ""),
+				email("gitlab", "alice@acme.io", ""),
+			},
+			want: []Entry{
+				{Email: "dave@acme.io", Names: []string{"Dave"}, Providers: []string{"github", "sourcehut"}},
+				{Email: "alice@acme.io", Providers: []string{"gitlab"}},
+				{Email: "Bob@acme.io", Names: []string{"Bob"}, Providers: []string{"bitbucket"}},
+				{Email: "carol@acme.io", Providers: []string{"github"}},
+			},
+			counts: []int{2, 1, 1, 1},
+		},
+		{
+			name: "findings without an email left out",
+			findings: []dossier.Finding{
+				{Type: "account", Provider: "github", Value: "alice"},
+				{Type: "os", Provider: "github", Signature: "Fedora Linux"},
+				{Type: "saas_credential", Provider: "gitlab", Email: "alice@acme.io", Signature: "stripe_secret_key"},
+			},
+			want:   []Entry{{Email: "alice@acme.io", Providers: []string{"gitlab"}}},
+			counts: []int{1},
+		},
+		{name: "nothing to join", findings: []dossier.Finding{{Type: "account", Value: "alice"}}, want: []Entry{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Correlate(tt.findings)
+			for i := range got {
+				if i < len(tt.counts) && len(got[i].Findings) != tt.counts[i] {
+					t.Errorf("%s has %d findings, want %d", got[i].Email, len(got[i].Findings), tt.counts[i])
+				}
+				got[i].Findings = nil
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTargets(t *testing.T) {
+	providers := []string{"github", "gitlab"}
+	tests := []struct {
+		name    string
+		args    []string
+		want    []Target
+		wantErr bool
+	}{
+		{"bare name on every provider", []string{"alice"}, []Target{{"github", "alice"}, {"gitlab", "alice"}}, false},
+		{"provider-qualified", []string{"gitlab:asmith", "bob"}, []Target{{"gitlab", "asmith"}, {"github", "bob"}, {"gitlab", "bob"}}, false},
+		{"unknown provider", []string{"alice", "codeberg:alice"}, nil, true},
+		{"provider not enabled", []string{"bitbucket:alice"}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTargets(tt.args, providers)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewScannersBitbucketCredentials(t *testing.T) {
+	if _, err := newScanners([]string{"bitbucket"}, dossier.Tokens{BitbucketAppPassword: "app-pass"}, nil, nil); err == nil {
+		t.Error("app password without a username accepted")
+	}
+	scanners, err := newScanners([]string{"bitbucket"}, dossier.Tokens{BitbucketUsername: "alice", BitbucketAppPassword: "app-pass", BitbucketAccessToken: "tok"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := scanners["bitbucket"].(*bitbucket.BitbucketScanner); s.User != "alice" || s.Token != "app-pass" {
+		t.Errorf("user/token = %q/%q, want the app password over the access token", s.User, s.Token)
+	}
+}
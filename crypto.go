@@ -0,0 +1,143 @@
+package dossier
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// ========================== Crypto Addresses ==========================
+
+type CryptoAddress struct {
+	Coin    string // "Bitcoin" or "Ethereum"
+	Address string
+	Offset  int
+}
+
+var (
+	btcBase58Address = regexp.MustCompile(`\b[13][1-9A-HJ-NP-Za-km-z]{25,34}\b`)
+	btcBech32Address = regexp.MustCompile(`(?i)\bbc1[02-9ac-hj-np-z]{11,71}\b`)
+	ethAddress       = regexp.MustCompile(`\b0x[0-9a-fA-F]{40}\b`)
+)
+
+// FindCryptoAddresses only returns addresses whose checksum verifies,
+// which weeds out most random base58/hex lookalikes.
+func FindCryptoAddresses(text string) []CryptoAddress {
+	var found []CryptoAddress
+	for _, loc := range btcBase58Address.FindAllStringIndex(text, -1) {
+		if a := text[loc[0]:loc[1]]; validBase58Check(a) {
+			found = append(found, CryptoAddress{Coin: "Bitcoin", Address: a, Offset: loc[0]})
+		}
+	}
+	for _, loc := range btcBech32Address.FindAllStringIndex(text, -1) {
+		if a := text[loc[0]:loc[1]]; validBech32(a) {
+			found = append(found, CryptoAddress{Coin: "Bitcoin", Address: a, Offset: loc[0]})
+		}
+	}
+	for _, loc := range ethAddress.FindAllStringIndex(text, -1) {
+		if a := text[loc[0]:loc[1]]; validEIP55(a) {
+			found = append(found, CryptoAddress{Coin: "Ethereum", Address: a, Offset: loc[0]})
+		}
+	}
+	return found
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// validBase58Check accepts P2PKH (0x00) and P2SH (0x05) mainnet addresses
+func validBase58Check(addr string) bool {
+	n := new(big.Int)
+	for _, r := range addr {
+		i := strings.IndexRune(base58Alphabet, r)
+		if i < 0 {
+			return false
+		}
+		n.Mul(n, big.NewInt(58))
+		n.Add(n, big.NewInt(int64(i)))
+	}
+	decoded := n.Bytes()
+	for _, r := range addr { // leading '1's encode leading zero bytes
+		if r != '1' {
+			break
+		}
+		decoded = append([]byte{0}, decoded...)
+	}
+	if len(decoded) != 25 || (decoded[0] != 0x00 && decoded[0] != 0x05) {
+		return false
+	}
+	first := sha256.Sum256(decoded[:21])
+	second := sha256.Sum256(first[:])
+	return bytes.Equal(second[:4], decoded[21:])
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// validBech32 checks BIP-173 (segwit v0) and BIP-350 (bech32m, v1+) checksums
+func validBech32(addr string) bool {
+	if addr != strings.ToLower(addr) && addr != strings.ToUpper(addr) {
+		return false // mixed case is invalid
+	}
+	addr = strings.ToLower(addr)
+	sep := strings.LastIndexByte(addr, '1')
+	hrp, data := addr[:sep], addr[sep+1:]
+	if len(data) < 6 {
+		return false
+	}
+	values := make([]int, 0, len(hrp)*2+1+len(data))
+	for _, c := range hrp {
+		values = append(values, int(c)>>5)
+	}
+	values = append(values, 0)
+	for _, c := range hrp {
+		values = append(values, int(c)&31)
+	}
+	for _, c := range data {
+		values = append(values, strings.IndexRune(bech32Charset, c))
+	}
+
+	gen := [5]int{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := 1
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ v
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	witnessVersion := strings.IndexRune(bech32Charset, rune(data[0]))
+	if witnessVersion == 0 {
+		return chk == 1
+	}
+	return chk == 0x2bc830a3
+}
+
+// validEIP55 verifies the mixed-case checksum of an Ethereum address.
+// All-lowercase or all-uppercase addresses carry no checksum and are
+// rejected: 0x and 40 hex digits is just as likely a SHA-1 or other hash.
+func validEIP55(addr string) bool {
+	hexPart := addr[2:]
+	lower := strings.ToLower(hexPart)
+	if hexPart == lower || hexPart == strings.ToUpper(hexPart) {
+		return false
+	}
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(lower))
+	hash := hex.EncodeToString(h.Sum(nil))
+	for i, c := range hexPart {
+		if c >= '0' && c <= '9' {
+			continue
+		}
+		upper := hash[i] >= '8'
+		if upper != (c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,36 @@
+package dossier
+
+import "testing"
+
+func TestFindCryptoAddresses(t *testing.T) {
+	tests := []struct {
+		name, text string
+		want       []string // coin and address
+	}{
+		{"eip-55 checksummed", "tips: 0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", []string{"Ethereum 0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"}},
+		{"eip-55 checksummed 2", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", []string{"Ethereum 0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"}},
+		{"eip-55 one letter flipped", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", nil},
+		{"all lowercase", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", nil},
+		{"all uppercase", "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", nil},
+		{"sha-1 with 0x", "reverts 0xda39a3ee5e6b4b0d3255bfef95601890afd80709", nil},
+		{"bitcoin p2pkh", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", []string{"Bitcoin 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"}},
+		{"bitcoin bad checksum", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb", nil},
+		{"bech32", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", []string{"Bitcoin bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, a := range FindCryptoAddresses(tt.text) {
+				got = append(got, a.Coin+" "+a.Address)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
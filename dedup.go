@@ -0,0 +1,156 @@
+package dossier
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ========================== Dedup ==========================
+
+// DedupReporter passes only the first email finding per address (and name,
+// with ByName) on to Next. It holds them until Flush so each can carry how
+// many times the address was seen; every other finding passes straight through.
+type DedupReporter struct {
+	Next   Reporter
+	ByName bool
+
+	mu      sync.Mutex
+	order   []string
+	first   map[string]Finding
+	emitted map[string]bool
+}
+
+func NewDedupReporter(next Reporter, byName bool) *DedupReporter {
+	return &DedupReporter{Next: next, ByName: byName, first: map[string]Finding{}, emitted: map[string]bool{}}
+}
+
+func (r *DedupReporter) key(f Finding) string {
+	key := strings.ToLower(f.Email)
+	if r.ByName {
+		key += "|" + strings.ToLower(strings.TrimSpace(f.Name))
+	}
+	return key
+}
+
+func (r *DedupReporter) Report(f Finding) {
+	if f.Type != "email" {
+		r.Next.Report(f)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := r.key(f)
+	if r.emitted[key] {
+		return
+	}
+	if held, ok := r.first[key]; ok {
+		held.Seen++
+		r.first[key] = held
+		return
+	}
+	f.Seen = 1
+	r.first[key] = f
+	r.order = append(r.order, key)
+}
+
+// Flush reports the held findings in first-seen order. Later sightings of an
+// address already flushed are dropped without being counted.
+func (r *DedupReporter) Flush() {
+	r.mu.Lock()
+	for _, key := range r.order {
+		r.Next.Report(r.first[key])
+		r.emitted[key] = true
+		delete(r.first, key)
+	}
+	r.order = nil
+	r.mu.Unlock()
+	if f, ok := r.Next.(Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *DedupReporter) Summarize(s Summary) {
+	if next, ok := r.Next.(Summarizer); ok {
+		next.Summarize(s)
+	}
+}
+
+func (r *DedupReporter) Close() error {
+	r.Flush()
+	if c, ok := r.Next.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// SeenFilter drops findings already reported in an earlier --watch cycle or
+// --dedup-across-runs run. Under --dedup it sits behind the DedupReporter,
+// which has to count every sighting before the repeats are dropped. With
+// ByName (--dedup-by-name) each name of an address is new once.
+type SeenFilter struct {
+	Next   Reporter
+	Seen   map[string]bool
+	Store  io.Writer // appends each new key for --dedup-across-runs; may be nil
+	ByName bool
+}
+
+func (r *SeenFilter) key(f Finding) string {
+	if r.ByName && f.Type == "email" {
+		return f.Key() + "|" + strings.ToLower(strings.TrimSpace(f.Name))
+	}
+	return f.Key()
+}
+
+func (r *SeenFilter) Report(f Finding) {
+	key := r.key(f)
+	if r.Seen[key] {
+		return
+	}
+	r.Seen[key] = true
+	if r.Store != nil {
+		fmt.Fprintln(r.Store, key)
+	}
+	r.Next.Report(f)
+}
+
+func (r *SeenFilter) Flush() {
+	if f, ok := r.Next.(Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *SeenFilter) Summarize(s Summary) {
+	if next, ok := r.Next.(Summarizer); ok {
+		next.Summarize(s)
+	}
+}
+
+func (r *SeenFilter) Close() error {
+	if c, ok := r.Next.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// LoadSeenStore reads the finding keys reported by earlier runs, one per
+// line, and opens the file to append new ones.
+func LoadSeenStore(filename string) (map[string]bool, *os.File, error) {
+	keys := map[string]bool{}
+	if data, err := os.ReadFile(filename); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if line != "" {
+				keys[line] = true
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, nil, err
+	}
+	return keys, file, nil
+}
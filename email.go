@@ -0,0 +1,349 @@
+package dossier
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// ========================== Blacklist ==========================
+
+func LoadBlacklist(filename string) ([]*regexp.Regexp, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseBlacklist(file, filename)
+}
+
+// DefaultBlacklist returns the built-in blacklist.txt
+func DefaultBlacklist() ([]*regexp.Regexp, error) {
+	return parseBlacklist(bytes.NewReader(defaultBlacklist), "blacklist.txt (built in)")
+}
+
+func parseBlacklist(r io.Reader, filename string) ([]*regexp.Regexp, error) {
+	var regexes []*regexp.Regexp
+	var errs []error
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		re, err := regexp.Compile(line)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s:%d: %w", filename, n, err))
+			continue
+		}
+		regexes = append(regexes, re)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...) // every bad line at once, not just the first
+	}
+	return regexes, nil
+}
+
+func IsBlacklisted(email string, blacklist []*regexp.Regexp) bool {
+	return firstMatch(email, blacklist) != nil
+}
+
+func firstMatch(email string, patterns []*regexp.Regexp) *regexp.Regexp {
+	for _, re := range patterns {
+		if re.MatchString(email) {
+			return re
+		}
+	}
+	return nil
+}
+
+// Blacklist is a fixed pattern list that caches its verdict per address,
+// since the same authors recur across thousands of commits. The patterns
+// can't change after NewBlacklist, so the cache never goes stale. A nil
+// *Blacklist matches nothing.
+type Blacklist struct {
+	patterns []*regexp.Regexp
+	mu       sync.Mutex
+	matches  map[string]*regexp.Regexp
+}
+
+func NewBlacklist(patterns []*regexp.Regexp) *Blacklist {
+	return &Blacklist{patterns: slices.Clone(patterns), matches: map[string]*regexp.Regexp{}}
+}
+
+// Patterns returns a copy of the patterns
+func (b *Blacklist) Patterns() []*regexp.Regexp {
+	if b == nil {
+		return nil
+	}
+	return slices.Clone(b.patterns)
+}
+
+func (b *Blacklist) Len() int {
+	if b == nil {
+		return 0
+	}
+	return len(b.patterns)
+}
+
+// Match returns the first pattern email matches, or nil
+func (b *Blacklist) Match(email string) *regexp.Regexp {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	re, ok := b.matches[email]
+	if !ok {
+		re = firstMatch(email, b.patterns)
+		b.matches[email] = re
+	}
+	return re
+}
+
+// IgnoreCase recompiles patterns to match regardless of case, since
+// addresses like Bob@Gmail.com are the same mailbox as bob@gmail.com
+func IgnoreCase(patterns []*regexp.Regexp) []*regexp.Regexp {
+	out := make([]*regexp.Regexp, len(patterns))
+	for i, re := range patterns {
+		out[i] = regexp.MustCompile("(?i)" + re.String()) // already known to compile
+	}
+	return out
+}
+
+// ========================== Whitelist ==========================
+
+// LoadWhitelist reads an allow-list in the blacklist's format: one regex per
+// line, # for comments
+func LoadWhitelist(filename string) ([]*regexp.Regexp, error) {
+	return LoadBlacklist(filename)
+}
+
+// Whitelisted returns the first whitelist pattern email matches, or nil
+func Whitelisted(email string, whitelist []*regexp.Regexp) *regexp.Regexp {
+	return firstMatch(email, whitelist)
+}
+
+// ========================== Email Validation ==========================
+
+func IsValidEmail(addr string) bool {
+	if !strings.Contains(addr, "@") {
+		return false
+	}
+
+	parsed, err := mail.ParseAddress(addr)
+	if err != nil {
+		return false
+	}
+
+	at := strings.LastIndex(parsed.Address, "@")
+	if at < 0 {
+		return false
+	}
+	domain := parsed.Address[at+1:]
+
+	if !strings.Contains(domain, ".") {
+		return false
+	}
+
+	eTLD, icann := publicsuffix.PublicSuffix(domain)
+	if eTLD == "" || !icann {
+		return false
+	}
+
+	if _, err := url.Parse("http://" + domain); err != nil {
+		return false
+	}
+
+	return true
+}
+
+func EmailDomain(addr string) string {
+	at := strings.LastIndex(addr, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(addr[at+1:])
+}
+
+// ========================== MX Verification ==========================
+
+// MXResolver looks up a domain's mail exchangers; *net.Resolver satisfies
+// it and tests can swap in a fake
+type MXResolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+}
+
+// MXChecker tells whether an address's domain can receive mail (--verify-mx).
+// Answers are cached per domain, so each is looked up once per run.
+type MXChecker struct {
+	Resolver MXResolver
+	Timeout  time.Duration // per lookup
+
+	mu    sync.Mutex
+	cache map[string]bool
+}
+
+func NewMXChecker() *MXChecker {
+	return &MXChecker{Resolver: net.DefaultResolver, Timeout: 5 * time.Second}
+}
+
+// HasMX reports whether the address's domain publishes a usable MX record.
+// A lookup that fails for any reason other than the domain or its records
+// not existing counts as a pass and isn't cached, so a flaky resolver
+// doesn't drop real addresses.
+func (c *MXChecker) HasMX(addr string) bool {
+	domain := EmailDomain(addr)
+	c.mu.Lock()
+	ok, cached := c.cache[domain]
+	c.mu.Unlock()
+	if cached {
+		return ok
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+	records, err := c.Resolver.LookupMX(ctx, domain)
+	var dnsErr *net.DNSError
+	if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+		return true
+	}
+	ok = false
+	for _, mx := range records {
+		if mx.Host != "." && mx.Host != "" { // "." is a null MX: the domain takes no mail
+			ok = true
+			break
+		}
+	}
+
+	c.mu.Lock()
+	if c.cache == nil {
+		c.cache = map[string]bool{}
+	}
+	c.cache[domain] = ok
+	c.mu.Unlock()
+	return ok
+}
+
+// ========================== Email Classes ==========================
+
+var noreplyDomains = []string{
+	"users.noreply.github.com", "noreply.github.com",
+	"users.noreply.gitlab.com", "noreply.gitlab.com",
+	"users.noreply.bitbucket.org",
+}
+
+var botLocalRe = regexp.MustCompile(`(?i)(\[bot\]|[-_.+]bot$|^bot[-_.+]|^(dependabot|renovate|greenkeeper|snyk-bot|github-actions|gitlab-bot|semantic-release-bot)$)`)
+
+var roleLocalParts = map[string]bool{
+	"admin": true, "administrator": true, "info": true, "support": true, "security": true,
+	"contact": true, "hello": true, "team": true, "dev": true, "devs": true, "webmaster": true,
+	"postmaster": true, "hostmaster": true, "root": true, "sales": true, "help": true,
+	"noreply": true, "no-reply": true, "abuse": true, "ops": true, "office": true,
+	"git": true, "maintainers": true, "opensource": true, "oss": true, "press": true,
+}
+
+// ClassifyEmail buckets an address as real, platform_noreply, bot or role
+func ClassifyEmail(addr string) string {
+	addr = strings.ToLower(addr)
+	at := strings.LastIndex(addr, "@")
+	if at < 0 {
+		return "real"
+	}
+	local, domain := addr[:at], addr[at+1:]
+	if botLocalRe.MatchString(local) {
+		return "bot"
+	}
+	for _, d := range noreplyDomains {
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return "platform_noreply"
+		}
+	}
+	if roleLocalParts[local] {
+		return "role"
+	}
+	return "real"
+}
+
+// Mailboxes that exist only to send, such as noreply@github.com for commits
+// made in the web UI
+var noreplyLocalParts = map[string]bool{
+	"noreply": true, "no-reply": true, "donotreply": true, "do-not-reply": true,
+}
+
+// IsNoReply reports whether an address was generated by a platform or bot
+// rather than belonging to a person
+func IsNoReply(addr string) bool {
+	switch ClassifyEmail(addr) {
+	case "platform_noreply", "bot":
+		return true
+	}
+	at := strings.LastIndex(addr, "@")
+	return at >= 0 && noreplyLocalParts[strings.ToLower(addr[:at])]
+}
+
+// ========================== Wrapped Emails ==========================
+
+// wrappedEmailRe finds an address broken by whitespace or a line wrap
+// around the @, as in "john\n@example.com" or "john @ example.com"
+var wrappedEmailRe = regexp.MustCompile(`([A-Za-z0-9._%+-]+)([ \t]*\r?\n?[ \t]*)@([ \t]*\r?\n?[ \t]*)([A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+
+// unwrapEmails glues wrapped addresses back together, but only when the
+// joined result is a valid email, so unrelated tokens stay apart.
+func unwrapEmails(text string) string {
+	return wrappedEmailRe.ReplaceAllStringFunc(text, func(m string) string {
+		parts := wrappedEmailRe.FindStringSubmatch(m)
+		// A line wrap, or spaces on both sides; "ping @alice.dev" is a mention
+		wrapped := strings.Contains(parts[2]+parts[3], "\n")
+		if !wrapped && (parts[2] == "" || parts[3] == "") {
+			return m
+		}
+		joined := parts[1] + "@" + parts[4]
+		if !IsValidEmail(joined) {
+			return m
+		}
+		return joined
+	})
+}
+
+// ExtractEmails returns the distinct addresses in free text
+func ExtractEmails(text string) []string {
+	var emails []string
+	found := map[string]bool{}
+	for _, e := range bareEmailRe.FindAllString(unwrapEmails(text), -1) {
+		if !found[strings.ToLower(e)] {
+			found[strings.ToLower(e)] = true
+			emails = append(emails, e)
+		}
+	}
+	return emails
+}
+
+// MessageEmails returns the addresses in a commit message body other than
+// the author's and those already covered by trailers
+func MessageEmails(message, author string) []string {
+	var emails []string
+	for _, e := range ExtractEmails(trailerRe.ReplaceAllString(message, "")) {
+		if !strings.EqualFold(e, author) {
+			emails = append(emails, e)
+		}
+	}
+	return emails
+}
@@ -0,0 +1,292 @@
+package dossier
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoadBlacklist(t *testing.T) {
+	path := writeFile(t, "blacklist.txt", "# platform addresses\nnoreply\\.github\\.com\n\n  ^root@  \n")
+	list, err := LoadBlacklist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, re := range list {
+		got = append(got, re.String())
+	}
+	if strings.Join(got, " ") != `noreply\.github\.com ^root@` {
+		t.Errorf("patterns = %q, want comments and blank lines skipped, lines trimmed", got)
+	}
+
+	path = writeFile(t, "blacklist.txt", "ok\n(bad\nfine\n[worse\n")
+	_, err = LoadBlacklist(path)
+	if err == nil || !strings.Contains(err.Error(), path+":2:") || !strings.Contains(err.Error(), path+":4:") {
+		t.Errorf("err = %v, want both bad lines with their numbers", err)
+	}
+
+	if _, err := LoadBlacklist(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("missing file: no error")
+	}
+}
+
+func TestIsBlacklisted(t *testing.T) {
+	builtin, err := DefaultBlacklist()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		email string
+		list  []*regexp.Regexp
+		want  bool
+	}{
+		{"12345+alice@users.noreply.github.com", builtin, true},
+		{"alice@acme.io", builtin, false},
+		{"alice@acme.io", nil, false},
+		{"root@acme.io", []*regexp.Regexp{regexp.MustCompile(`^root@`)}, true},
+		{"ROOT@acme.io", []*regexp.Regexp{regexp.MustCompile(`^root@`)}, false},
+		{"ROOT@acme.io", IgnoreCase([]*regexp.Regexp{regexp.MustCompile(`^root@`)}), true},
+	}
+	for _, tt := range tests {
+		if got := IsBlacklisted(tt.email, tt.list); got != tt.want {
+			t.Errorf("IsBlacklisted(%q) = %v, want %v", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestIsValidEmail(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"alice@acme.io", true},
+		{"alice.smith+git@mail.acme.co.uk", true},
+		{"Alice <alice@acme.io>", true},
+		{"", false},
+		{"alice", false},
+		{"alice@", false},
+		{"@acme.io", false},
+		{"alice@localhost", false},
+		{"alice@acme.local", false},
+		{"alice@example.test", false},
+		{"alice@acme.blogspot.com", false}, // private suffix, not ICANN
+		{"alice@@acme.io", false},
+	}
+	for _, tt := range tests {
+		if got := IsValidEmail(tt.addr); got != tt.want {
+			t.Errorf("IsValidEmail(%q) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyEmail(t *testing.T) {
+	tests := []struct {
+		addr, want string
+	}{
+		{"alice@acme.io", "real"},
+		{"Alice.Smith@acme.io", "real"},
+		{"robot@acme.io", "real"}, // "bot" only as a separate word
+		{"12345+alice@users.noreply.github.com", "platform_noreply"},
+		{"alice@noreply.gitlab.com", "platform_noreply"},
+		{"alice@users.noreply.bitbucket.org", "platform_noreply"},
+		{"49699333+dependabot[bot]@users.noreply.github.com", "bot"},
+		{"renovate@whitesourcesoftware.com", "bot"},
+		{"release-bot@acme.io", "bot"},
+		{"bot.ci@acme.io", "bot"},
+		{"security@acme.io", "role"},
+		{"Support@acme.io", "role"},
+		{"noreply@acme.io", "role"},
+		{"not-an-email", "real"},
+	}
+	counts := map[string]int{}
+	for _, tt := range tests {
+		got := ClassifyEmail(tt.addr)
+		if got != tt.want {
+			t.Errorf("ClassifyEmail(%q) = %q, want %q", tt.addr, got, tt.want)
+		}
+		counts[got]++
+	}
+	if counts["real"] != 4 || counts["platform_noreply"] != 3 || counts["bot"] != 4 || counts["role"] != 3 {
+		t.Errorf("buckets = %v", counts)
+	}
+}
+
+func TestUnwrapEmails(t *testing.T) {
+	tests := []struct {
+		name, text, want string
+	}{
+		{"line wrap before @", "Reported by john.smith\n@acme.io yesterday", "Reported by john.smith@acme.io yesterday"},
+		{"line wrap after @", "contact: john@\nacme.io", "contact: john@acme.io"},
+		{"indented continuation", "cc: john\r\n    @acme.io", "cc: john@acme.io"},
+		{"spaces both sides", "mail john @ acme.io for access", "mail john@acme.io for access"},
+		{"already whole", "mail john@acme.io", "mail john@acme.io"},
+
+		// Must not merge
+		{"mention", "ping @alice.dev about it", "ping @alice.dev about it"},
+		{"space before only", "thanks team @acme.io", "thanks team @acme.io"},
+		{"space after only", "scp build@ acme.io:/srv", "scp build@ acme.io:/srv"},
+		{"not a valid domain", "see main\n@config.local", "see main\n@config.local"},
+		{"blank line between", "john\n\n@acme.io", "john\n\n@acme.io"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unwrapEmails(tt.text); got != tt.want {
+				t.Errorf("unwrapEmails(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlacklistMatch(t *testing.T) {
+	patterns := []*regexp.Regexp{regexp.MustCompile(`@gmail\.com$`), regexp.MustCompile(`^noreply@`)}
+	gmailOnly := NewBlacklist(patterns[:1])
+	both := NewBlacklist(patterns)
+
+	// Each list keeps its own verdicts
+	for i := 0; i < 2; i++ {
+		if gmailOnly.Match("noreply@acme.io") != nil {
+			t.Error("gmail-only list matched noreply@acme.io")
+		}
+		if re := both.Match("noreply@acme.io"); re != patterns[1] {
+			t.Errorf("both.Match(noreply@acme.io) = %v, want %v", re, patterns[1])
+		}
+	}
+
+	// Editing the caller's slice afterwards changes nothing
+	patterns[0] = regexp.MustCompile(`@acme\.io$`)
+	if both.Match("bob@acme.io") != nil || both.Match("bob@gmail.com") == nil {
+		t.Error("blacklist changed when the slice it was built from did")
+	}
+
+	var none *Blacklist
+	if none.Match("bob@gmail.com") != nil || none.Len() != 0 {
+		t.Error("nil blacklist matched")
+	}
+}
+
+func TestBlacklistConcurrentUse(t *testing.T) {
+	b := NewBlacklist([]*regexp.Regexp{regexp.MustCompile(`^user[0-9]*0@`)})
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				addr := fmt.Sprintf("user%d@acme.io", i)
+				if got, want := b.Match(addr) != nil, i%10 == 0; got != want {
+					t.Errorf("Match(%s) = %v, want %v", addr, got, want)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// benchmarkBlacklist is a realistic custom list: disposable-mail domains
+// and role accounts, matched case-insensitively
+func benchmarkBlacklist() []*regexp.Regexp {
+	var patterns []*regexp.Regexp
+	for _, domain := range []string{"mailinator", "guerrillamail", "10minutemail", "tempmail", "yopmail", "trashmail", "sharklasers", "dispostable", "maildrop", "getnada"} {
+		patterns = append(patterns, regexp.MustCompile(`@(.+\.)?`+domain+`\.(com|net|org)$`))
+	}
+	for _, role := range []string{"admin", "support", "info", "security", "webmaster", "postmaster", "ci", "build", "deploy", "release"} {
+		patterns = append(patterns, regexp.MustCompile(`^`+role+`[-+._]?[0-9]*@`))
+	}
+	return IgnoreCase(patterns)
+}
+
+func benchmarkAddrs() []string {
+	addrs := make([]string, 50) // a few authors over many commits
+	for i := range addrs {
+		addrs[i] = fmt.Sprintf("dev%d@acme.io", i)
+	}
+	return addrs
+}
+
+func BenchmarkIsBlacklisted(b *testing.B) {
+	patterns := benchmarkBlacklist()
+	addrs := benchmarkAddrs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		IsBlacklisted(addrs[i%len(addrs)], patterns)
+	}
+}
+
+func BenchmarkBlacklistMatch(b *testing.B) {
+	patterns := benchmarkBlacklist()
+	list := NewBlacklist(patterns)
+	addrs := benchmarkAddrs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		list.Match(addrs[i%len(addrs)])
+	}
+}
+
+// fakeMX answers LookupMX from a table and counts the lookups per domain
+type fakeMX struct {
+	answers map[string][]*net.MX
+	errs    map[string]error
+	lookups map[string]int
+}
+
+func (r *fakeMX) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	r.lookups[name]++
+	if err, ok := r.errs[name]; ok {
+		return nil, err
+	}
+	return r.answers[name], nil
+}
+
+func TestMXCheckerHasMX(t *testing.T) {
+	r := &fakeMX{
+		answers: map[string][]*net.MX{
+			"acme.io":    {{Host: "mx1.acme.io.", Pref: 10}, {Host: "mx2.acme.io.", Pref: 20}},
+			"nomail.io":  {{Host: ".", Pref: 0}},
+			"backup.io":  {{Host: ".", Pref: 0}, {Host: "mx.backup.io.", Pref: 10}},
+			"records.io": {},
+		},
+		errs: map[string]error{
+			"gone.io":  &net.DNSError{Err: "no such host", Name: "gone.io", IsNotFound: true},
+			"flaky.io": &net.DNSError{Err: "i/o timeout", Name: "flaky.io", IsTimeout: true, IsTemporary: true},
+		},
+		lookups: map[string]int{},
+	}
+	c := &MXChecker{Resolver: r, Timeout: time.Second}
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"alice@acme.io", true},
+		{"bob@ACME.io", true},
+		{"carol@nomail.io", false},
+		{"dave@backup.io", true},
+		{"erin@records.io", false},
+		{"frank@gone.io", false},
+		{"grace@gone.io", false},
+		{"heidi@flaky.io", true},
+		{"ivan@flaky.io", true},
+	}
+	for _, tt := range tests {
+		if got := c.HasMX(tt.addr); got != tt.want {
+			t.Errorf("HasMX(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+	for domain, want := range map[string]int{"acme.io": 1, "nomail.io": 1, "backup.io": 1, "records.io": 1, "gone.io": 1, "flaky.io": 2} {
+		if r.lookups[domain] != want {
+			t.Errorf("%s looked up %d times, want %d", domain, r.lookups[domain], want)
+		}
+	}
+	if _, cached := c.cache["flaky.io"]; cached {
+		t.Error("temporary failure cached")
+	}
+	if ok, cached := c.cache["gone.io"]; !cached || ok {
+		t.Errorf("gone.io cached as %v (cached %v), want a cached failure", ok, cached)
+	}
+}
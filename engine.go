@@ -0,0 +1,574 @@
+package dossier
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ========================== Options ==========================
+
+// Options are the per-scan switches set from the command line. A provider
+// ignores the ones its API has no use for.
+type Options struct {
+	MaxPages          int // safety cap on pages fetched per listing (0 = no cap)
+	PerPage           int // --per-page, clamped by each provider's maximum
+	Prefetch          int // --prefetch: extra commit pages requested alongside each one
+	RepoSort          string
+	RepoLimit         int
+	ActiveSince       time.Time
+	Since             time.Time // --since: only commits authored at or after this time
+	Until             time.Time // --until: only commits authored before this time
+	OldestFirst       bool
+	ProfileOnly       bool
+	DetectLanguage    bool
+	SkipBinaryLike    bool
+	SkipNoReply       bool
+	EmailOnly         bool
+	ScanMetadata      bool
+	OnlyWithSecrets   bool
+	CoauthorOnly      bool
+	MatchRepos        bool
+	CommitterToo      bool
+	ScanGists         bool
+	ResolveOrgMembers bool
+	ScanContributed   bool
+	OrderByActivity   bool // scan the most recently active repos first
+	SampleRate        float64
+	DateSkew          time.Duration
+	TZOffsets         map[string]bool // --tz-offset, normalised to "-07:00"
+	Retry             RetryPolicy
+	RequestTimeout    time.Duration // per request, including reading the body (0 = no limit)
+	MaxRateLimitWait  time.Duration // longest sleep for a rate limit to reset before giving up on a request
+
+	EmailFormat         string        // --email-format: plain, mailto or angle
+	NormalizeNames      bool          // --normalize-names
+	NameSimilarity      float64       // --name-similarity threshold for the summary (0 = off)
+	Explain             string        // --explain: the address whose filtering is logged
+	MaxBufferedFindings int           // findings --only-with-secrets holds per repo before spilling to disk (0 = no cap)
+	FlushInterval       time.Duration // 0 = buffered reporters only flush when a scan ends
+}
+
+// DefaultOptions matches the command line defaults
+func DefaultOptions() Options {
+	return Options{
+		MaxPages:   1000,
+		PerPage:    100,
+		SampleRate: 1.0,
+		DateSkew:   24 * time.Hour,
+		Retry:      RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: 30 * time.Second, Jitter: 0.2},
+
+		RequestTimeout:   30 * time.Second,
+		MaxRateLimitWait: time.Hour,
+
+		EmailFormat:         "plain",
+		MaxBufferedFindings: 10000,
+	}
+}
+
+// ========================== Engine ==========================
+
+// Engine is the provider-independent half of a scan: the filters and the
+// reporter chain every finding goes through, and the counters, identities
+// and cached responses the scan builds up. Each provider scanner embeds
+// one, so scans with different settings or tokens can share a process.
+type Engine struct {
+	Provider   string // set as Finding.Provider
+	Doer       Doer
+	Config     *Config
+	Blacklist  *Blacklist
+	Whitelist  *Blacklist       // --whitelist; nil reports every address
+	Redactions []*regexp.Regexp // --redact-config
+	MX         *MXChecker       // --verify-mx; nil skips the lookups
+	Reporter   Reporter
+	Options    Options
+	Progress   *ProgressReporter // --progress on a terminal; nil draws nothing
+	Sampler    *rand.Rand        // --seed for --sample-rate; nil seeds from the clock
+	Seen       map[string]bool   // findings already reported, tracked in --watch and --dedup-across-runs
+	SeenStore  io.Writer         // --dedup-across-runs: keys reported by earlier invocations
+
+	// Authorize adds the provider's credentials and API headers to each request
+	Authorize func(*http.Request)
+
+	// Hosts other than the API host that redirects may lead to (--trusted-redirect-hosts)
+	TrustedRedirectHosts map[string]bool
+
+	Responses *ResponseCache // nil (--no-response-cache) fetches every request
+	Disk      *DiskCache     // --cache-dir; nil fetches everything
+
+	Stats      ScanStats
+	Identities *IdentityStore
+	Spans      RepoSpans
+	Topics     map[string]int // topic -> scanned repos tagged with it
+
+	classes   map[string]string // lowercased email -> ClassifyEmail
+	mu        sync.Mutex        // guards Stats while pages are fetched concurrently
+	lastFlush time.Time
+}
+
+func NewEngine(provider string, cfg *Config, blacklist []*regexp.Regexp) *Engine {
+	e := &Engine{
+		Provider:             provider,
+		Config:               cfg,
+		Blacklist:            NewBlacklist(blacklist),
+		Options:              DefaultOptions(),
+		TrustedRedirectHosts: map[string]bool{},
+		Responses:            NewResponseCache(256, 32<<20),
+		Identities:           NewIdentityStore(),
+	}
+	e.Doer = NewHTTPClient(e.checkRedirect)
+	e.Reset()
+	return e
+}
+
+// Reset forgets what the last scan found, so each --watch cycle reports
+// its own summary. Cached responses are kept but revalidated before reuse.
+func (e *Engine) Reset() {
+	e.Stats = ScanStats{}
+	e.Identities.Reset()
+	e.Spans = RepoSpans{}
+	e.Topics = map[string]int{}
+	e.classes = map[string]string{}
+	e.Responses.Expire()
+	e.lastFlush = time.Now()
+}
+
+// count updates the stats under the lock, for code that may run
+// concurrently with --prefetch
+func (e *Engine) count(update func(*ScanStats)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	update(&e.Stats)
+}
+
+// RepoFailed logs a repo whose commits stopped coming and keeps it for the
+// summary. The scan goes on with the commits fetched so far.
+func (e *Engine) RepoFailed(repo string, fetched int, err error) {
+	Log.Errorf("Stopped fetching commits of %s after %d, scanning those: %v\n", repo, fetched, err)
+	e.count(func(s *ScanStats) {
+		s.FailedRepos = append(s.FailedRepos, RepoFailure{Repo: repo, Error: err.Error()})
+	})
+}
+
+// PageLimitReached guards pagination loops against APIs that never return
+// an empty or final page.
+func (e *Engine) PageLimitReached(page int, what string) bool {
+	if e.Options.MaxPages > 0 && page > e.Options.MaxPages {
+		Log.Warnf("⚠️  Stopped fetching %s after %d pages (--max-pages)\n", what, e.Options.MaxPages)
+		return true
+	}
+	return false
+}
+
+// ========================== Email Validation ==========================
+
+func (e *Engine) ShouldReport(addr string) bool {
+	if addr == "" {
+		return false
+	}
+	e.Stats.EmailsSeen++
+	if !IsValidEmail(addr) {
+		e.explain(addr, "dropped: failed IsValidEmail")
+		return false
+	}
+	e.Stats.EmailsValid++
+	if e.Whitelist != nil {
+		re := e.Whitelist.Match(addr)
+		if re == nil {
+			e.Stats.EmailsUnlisted++
+			e.explain(addr, "dropped: matches no --whitelist pattern")
+			return false
+		}
+		e.explain(addr, "whitelisted by %s", re)
+	}
+	if re := e.Blacklist.Match(addr); re != nil {
+		e.Stats.EmailsBlacklisted++
+		e.explain(addr, "dropped: blacklisted by %s", re)
+		return false
+	}
+	if e.MX != nil && !e.MX.HasMX(addr) {
+		e.Stats.EmailsNoMX++
+		e.explain(addr, "dropped: %s has no MX records (--verify-mx)", EmailDomain(addr))
+		return false
+	}
+	if e.explaining(addr) {
+		gates := []string{"IsValidEmail"}
+		if e.Whitelist != nil {
+			gates = append(gates, "--whitelist")
+		}
+		gates = append(gates, "the blacklist")
+		if e.MX != nil {
+			gates = append(gates, "--verify-mx")
+		}
+		last := len(gates) - 1
+		e.explain(addr, "passed %s and %s", strings.Join(gates[:last], ", "), gates[last])
+	}
+	return true
+}
+
+// explaining reports whether --explain is tracing this address
+func (e *Engine) explaining(addr string) bool {
+	return e.Options.Explain != "" && strings.EqualFold(addr, e.Options.Explain)
+}
+
+// explain logs one step of the --explain decision path to stderr
+func (e *Engine) explain(addr, format string, args ...interface{}) {
+	if e.explaining(addr) {
+		fmt.Fprintf(os.Stderr, "explain %s: %s\n", addr, fmt.Sprintf(format, args...))
+	}
+}
+
+// ========================== Reporting ==========================
+
+// Report runs a finding through the noreply, redaction and dedup filters
+// and hands it to the Reporter
+func (e *Engine) Report(f Finding) {
+	f.Provider = e.Provider
+	if e.Options.NormalizeNames && f.Name != "" {
+		if name := NormalizeName(f.Name); name != f.Name {
+			f.RawName, f.Name = f.Name, name
+		}
+	}
+	if f.Type == "email" {
+		f.Class = ClassifyEmail(f.Email)
+		e.classes[strings.ToLower(f.Email)] = f.Class
+		e.explain(f.Email, "classified as %s", f.Class)
+		f.IsNoReply = IsNoReply(f.Email)
+		if f.IsNoReply && e.Options.SkipNoReply {
+			e.explain(f.Email, "dropped: noreply or bot address (--skip-noreply)")
+			return
+		}
+	}
+	if len(e.Redactions) > 0 {
+		f = e.redact(f)
+	}
+	if e.Seen != nil {
+		if e.Seen[f.Key()] {
+			e.explain(f.Email, "dropped %s finding at %s: already reported", f.Type, f.Location)
+			return
+		}
+		e.Seen[f.Key()] = true
+		if e.SeenStore != nil {
+			fmt.Fprintln(e.SeenStore, f.Key())
+		}
+	}
+	e.explain(f.Email, "reported %s finding at %s", f.Type, f.Location)
+	e.Reporter.Report(f)
+	if e.Options.FlushInterval > 0 && time.Since(e.lastFlush) >= e.Options.FlushInterval {
+		e.FlushReporter()
+	}
+}
+
+// Dedup puts a --dedup DedupReporter in front of the reporter. Any Seen set
+// moves behind it into a SeenFilter, so sightings of an address already
+// reported still count towards its seen total.
+func (e *Engine) Dedup(byName bool) {
+	next := e.Reporter
+	if e.Seen != nil {
+		next = &SeenFilter{Next: next, Seen: e.Seen, Store: e.SeenStore, ByName: byName}
+		e.Seen, e.SeenStore = nil, nil
+	}
+	e.Reporter = NewDedupReporter(next, byName)
+}
+
+// redact masks every match of the custom patterns in every string field,
+// so nothing sensitive reaches any output format.
+func (e *Engine) redact(f Finding) Finding {
+	v := reflect.ValueOf(&f).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if field := v.Field(i); field.Kind() == reflect.String && field.CanSet() {
+			s := field.String()
+			for _, re := range e.Redactions {
+				s = re.ReplaceAllString(s, "****")
+			}
+			field.SetString(s)
+		}
+	}
+	return f
+}
+
+// WithRepoBuffer prints header and runs scan. With --only-with-secrets both
+// the header and the findings are dropped unless the scan found a secret.
+func (e *Engine) WithRepoBuffer(header string, scan func()) {
+	if !e.Options.OnlyWithSecrets {
+		Log.Infof("%s", header)
+		scan()
+		return
+	}
+	out, buf := e.Reporter, &RepoBuffer{Max: e.Options.MaxBufferedFindings}
+	e.Reporter = buf
+	scan()
+	e.Reporter = out
+	if buf.HasSecrets() {
+		Log.Infof("%s", header)
+		buf.Replay(e.Reporter)
+	} else {
+		buf.Discard()
+	}
+}
+
+func (e *Engine) FlushReporter() {
+	if f, ok := e.Reporter.(Flusher); ok {
+		f.Flush()
+	}
+	e.lastFlush = time.Now()
+}
+
+// Reporters that write to files implement io.Closer; close before exiting
+// so compressed output gets its trailer.
+func (e *Engine) CloseReporter() {
+	if c, ok := e.Reporter.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			Log.Errorln("Error closing output:", err)
+		}
+	}
+}
+
+// Collect runs a scan with a Collector in front of the reporter and
+// returns what it reported
+func (e *Engine) Collect(scan func() error) ([]Finding, error) {
+	c := &Collector{Next: e.Reporter}
+	e.Reporter = c
+	err := scan()
+	e.Reporter = c.Next
+	return c.Findings, err
+}
+
+// ReportRepoMatches runs the repo_names, os and utility signatures over a
+// repo's name, description and topics (--match-repos)
+func (e *Engine) ReportRepoMatches(name, description string, topics []string, location string) {
+	text := strings.Join(append([]string{name, description}, topics...), "\n")
+	for _, patterns := range [][]Pattern{e.Config.RepoNames, e.Config.OperatingSystems, e.Config.Utilities} {
+		for _, m := range SearchPatterns(text, patterns) {
+			e.Report(Finding{Type: "repo_match", Signature: m, Value: name, Location: location})
+		}
+	}
+}
+
+// ReportMetadata reports each email and username in one metadata file once
+func (e *Engine) ReportMetadata(path, content, location string) {
+	reported := map[string]bool{}
+	for _, c := range ParseMetadataFile(path, content) {
+		key := strings.ToLower(c.Email + c.Username)
+		if reported[key] {
+			continue
+		}
+		reported[key] = true
+		if c.Username != "" {
+			e.Report(Finding{Type: "username", Signature: path, Value: c.Username, Location: location})
+		} else if e.ShouldReport(c.Email) {
+			e.Report(Finding{Type: "email", Signature: path, Email: c.Email, Name: c.Name, Location: location})
+		}
+	}
+}
+
+// ========================== Commit Filters ==========================
+
+// SampleCommit keeps each commit with probability --sample-rate
+func (e *Engine) SampleCommit() bool {
+	if e.Options.SampleRate >= 1 {
+		return true
+	}
+	if e.Sampler == nil {
+		e.Sampler = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	if e.Sampler.Float64() >= e.Options.SampleRate {
+		return false
+	}
+	e.Stats.CommitsSampled++
+	return true
+}
+
+// MatchesOffset reports whether a commit was made in one of the --tz-offset
+// offsets; with no filter every commit matches.
+func (e *Engine) MatchesOffset(t time.Time) bool {
+	if len(e.Options.TZOffsets) == 0 {
+		return true
+	}
+	return !t.IsZero() && e.Options.TZOffsets[t.Format("-07:00")]
+}
+
+// InDateRange reports whether a commit's author date falls within --since
+// and --until; an undated commit only passes when neither is set.
+func (e *Engine) InDateRange(t time.Time) bool {
+	if e.Options.Since.IsZero() && e.Options.Until.IsZero() {
+		return true
+	}
+	return !t.IsZero() && !t.Before(e.Options.Since) && (e.Options.Until.IsZero() || t.Before(e.Options.Until))
+}
+
+// SuspiciousDate explains why a commit timestamp looks forged or broken:
+// too far in the future, or at the Unix epoch.
+func (e *Engine) SuspiciousDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	if t.After(time.Now().Add(e.Options.DateSkew)) {
+		return "future"
+	}
+	if y, m, d := t.UTC().Date(); y == 1970 && m == time.January && d == 1 {
+		return "epoch"
+	}
+	return ""
+}
+
+// ========================== Summary ==========================
+
+// Summary is the scan's counts so far
+func (e *Engine) Summary() Summary {
+	stats := e.Stats
+	return Summary{
+		Type:           "summary",
+		Provider:       e.Provider,
+		ScanStats:      stats,
+		EmailsInvalid:  stats.EmailsSeen - stats.EmailsValid,
+		EmailsReported: stats.EmailsValid - stats.EmailsUnlisted - stats.EmailsBlacklisted - stats.EmailsNoMX,
+	}
+}
+
+// SummarizeReporter ends a scan's machine-readable output with its Summary
+func (e *Engine) SummarizeReporter() {
+	if s, ok := e.Reporter.(Summarizer); ok {
+		s.Summarize(e.Summary())
+	}
+}
+
+// PrintSummary logs the counts and aggregates to stderr, leaving stdout to
+// the findings
+func (e *Engine) PrintSummary() {
+	summary := e.Summary()
+	stats := summary.ScanStats
+	Log.Infoln("=== Summary ===")
+	Log.Infof("Repos scanned: %d\n", stats.ReposScanned)
+	Log.Infof("Pages fetched: %d\n", stats.PagesFetched)
+	if stats.ResponsesReused > 0 {
+		Log.Infof("Pages reused from memory: %d\n", stats.ResponsesReused)
+	}
+	if e.Disk != nil {
+		Log.Infof("Pages from the disk cache: %d\n", stats.ResponsesFromDisk)
+	}
+	if stats.NotModified > 0 {
+		Log.Infof("Pages not modified since last fetched: %d\n", stats.NotModified)
+	}
+	Log.Infof("Commits processed: %d\n", stats.CommitsProcessed)
+	if e.Options.SampleRate < 1 {
+		Log.Infof("Commits sampled: %d (--sample-rate %g)\n", stats.CommitsSampled, e.Options.SampleRate)
+	}
+	if stats.BinaryLikeSkipped > 0 {
+		Log.Infof("Binary-looking messages skipped: %d\n", stats.BinaryLikeSkipped)
+	}
+	Log.Infof("Candidate emails: %d\n", stats.EmailsSeen)
+	Log.Infof("Valid: %d (%.1f%%)\n", stats.EmailsValid, percent(stats.EmailsValid, stats.EmailsSeen))
+	Log.Infof("Invalid: %d\n", summary.EmailsInvalid)
+	if e.Whitelist != nil {
+		Log.Infof("Not whitelisted: %d\n", stats.EmailsUnlisted)
+	}
+	Log.Infof("Blacklisted: %d\n", stats.EmailsBlacklisted)
+	if e.MX != nil {
+		Log.Infof("No MX records: %d\n", stats.EmailsNoMX)
+	}
+	Log.Infof("Reported: %d\n", summary.EmailsReported)
+	if len(e.classes) > 0 {
+		counts := map[string]int{}
+		for _, class := range e.classes {
+			counts[class]++
+		}
+		Log.Infof("Distinct emails: %d real, %d platform noreply, %d bot, %d role\n",
+			counts["real"], counts["platform_noreply"], counts["bot"], counts["role"])
+	}
+
+	if len(stats.FailedRepos) > 0 {
+		Log.Infoln("\n=== Failed Repositories ===")
+		for _, f := range stats.FailedRepos {
+			Log.Infof("%s: %s\n", f.Repo, f.Error)
+		}
+	}
+
+	if spans := e.Spans.Sorted(); len(spans) > 0 {
+		Log.Infoln("\n=== Repositories ===")
+		for _, span := range spans {
+			Log.Infoln(span)
+		}
+	}
+
+	if len(e.Topics) > 0 {
+		topics := make([]string, 0, len(e.Topics))
+		for t := range e.Topics {
+			topics = append(topics, t)
+		}
+		sort.Slice(topics, func(i, j int) bool {
+			if e.Topics[topics[i]] != e.Topics[topics[j]] {
+				return e.Topics[topics[i]] > e.Topics[topics[j]]
+			}
+			return topics[i] < topics[j]
+		})
+		Log.Infoln("\n=== Repository topics ===")
+		for _, t := range topics {
+			Log.Infof("%s: %d repos\n", t, e.Topics[t])
+		}
+	}
+
+	if ids := e.Identities.Identities(); len(ids) > 0 {
+		Log.Infoln("\n=== Identities ===")
+		for _, id := range ids {
+			line := fmt.Sprintf("%s: %s", id.Email, id.CanonicalName())
+			if aliases := id.Aliases(); len(aliases) > 0 {
+				line += fmt.Sprintf(" (aliases: %s)", strings.Join(aliases, ", "))
+			}
+			Log.Infoln(line)
+			if len(id.Languages) > 0 {
+				Log.Infof("    languages: %s\n", LanguageBreakdown(id.Languages))
+			}
+		}
+	}
+
+	var signers []*Identity
+	for _, id := range e.Identities.Identities() {
+		if id.Signed > 0 {
+			signers = append(signers, id)
+		}
+	}
+	if len(signers) > 0 {
+		Log.Infoln("\n=== Commit signing ===")
+		for _, id := range signers {
+			line := fmt.Sprintf("%s: %d commits, %.0f%% signed", id.Email, id.Commits, percent(id.Signed, id.Commits))
+			if key := id.SigningKey(); key != "" {
+				line += fmt.Sprintf(" (%s)", key)
+			}
+			Log.Infoln(line)
+		}
+	}
+
+	var inferences []ProfileInference
+	for _, id := range e.Identities.Identities() {
+		if p, ok := InferProfile(id); ok {
+			inferences = append(inferences, p)
+		}
+	}
+	if len(inferences) > 0 {
+		Log.Infoln("\n=== Profile inferences ===")
+		for _, p := range inferences {
+			Log.Infof("%s: likely %s employee in %s\n", p.Email, p.Employer, p.Region)
+			for _, ev := range p.Evidence {
+				Log.Infof("    %s\n", ev)
+			}
+		}
+	}
+
+	if e.Options.NameSimilarity > 0 {
+		if matches := e.Identities.SimilarNames(e.Options.NameSimilarity); len(matches) > 0 {
+			Log.Infoln("\n=== Possibly the same person ===")
+			for _, m := range matches {
+				Log.Infof("%s (%s) ~ %s (%s): %.2f\n", m.A.Email, m.A.CanonicalName(), m.B.Email, m.B.CanonicalName(), m.Score)
+			}
+		}
+	}
+}
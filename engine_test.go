@@ -0,0 +1,245 @@
+package dossier
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShouldReportCounters(t *testing.T) {
+	e := NewEngine("github", nil, []*regexp.Regexp{regexp.MustCompile(`@gmail\.com$`)})
+	for _, addr := range []string{
+		"alice@acme.io",     // valid
+		"bob@acme.io",       // valid
+		"carol@gmail.com",   // valid, blacklisted
+		"not-an-email",      // invalid
+		"dave@localhost",    // invalid: no public suffix
+		"",                  // not a candidate
+		"erin@example.test", // invalid: reserved TLD
+	} {
+		e.ShouldReport(addr)
+	}
+	s := e.Summary()
+	if s.EmailsSeen != 6 || s.EmailsValid != 3 || s.EmailsBlacklisted != 1 {
+		t.Errorf("seen/valid/blacklisted = %d/%d/%d, want 6/3/1", s.EmailsSeen, s.EmailsValid, s.EmailsBlacklisted)
+	}
+	if s.EmailsInvalid != 3 || s.EmailsReported != 2 {
+		t.Errorf("invalid/reported = %d/%d, want 3/2", s.EmailsInvalid, s.EmailsReported)
+	}
+}
+
+func TestSummaryRecordEndsJSONOutput(t *testing.T) {
+	dir := t.TempDir()
+	e := NewEngine("github", nil, nil)
+	e.ShouldReport("alice@acme.io")
+	e.ShouldReport("nope")
+
+	path := filepath.Join(dir, "out.jsonl")
+	r, err := NewJSONLReporter(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Reporter = r
+	e.Report(Finding{Type: "email", Email: "alice@acme.io"})
+	e.SummarizeReporter()
+	e.CloseReporter()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want a finding and a summary:\n%s", len(lines), data)
+	}
+	var last map[string]any
+	if err := json.Unmarshal([]byte(lines[1]), &last); err != nil {
+		t.Fatal(err)
+	}
+	if last["type"] != "summary" || last["emails_seen"] != 2.0 || last["emails_valid"] != 1.0 || last["emails_invalid"] != 1.0 || last["emails_reported"] != 1.0 {
+		t.Errorf("summary line = %s", lines[1])
+	}
+
+	path = filepath.Join(dir, "out.json")
+	jr, err := NewJSONReporter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Reporter = jr
+	e.Report(Finding{Type: "email", Email: "alice@acme.io"})
+	e.SummarizeReporter()
+	e.CloseReporter()
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []map[string]any
+	if err := json.Unmarshal(data, &records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0]["type"] != "email" || records[1]["type"] != "summary" {
+		t.Errorf("records = %v, want the finding then the summary", records)
+	}
+}
+
+func TestPrintSummaryGoesToTheLog(t *testing.T) {
+	var buf strings.Builder
+	w := Log.W
+	Log.W = &buf
+	defer func() { Log.W = w }()
+
+	e := NewEngine("github", nil, nil)
+	e.ShouldReport("alice@acme.io")
+	e.PrintSummary()
+	if !strings.Contains(buf.String(), "=== Summary ===") || !strings.Contains(buf.String(), "Candidate emails: 1\n") {
+		t.Errorf("log = %q, want the summary", buf.String())
+	}
+}
+
+func TestOnlyWithSecretsKeepsReposWithSecrets(t *testing.T) {
+	var log strings.Builder
+	w := Log.W
+	Log.W = &log
+	defer func() { Log.W = w }()
+
+	e := NewEngine("github", nil, nil)
+	e.Options.OnlyWithSecrets = true
+	c := &Collector{}
+	e.Reporter = c
+	e.WithRepoBuffer("Repo: alice/clean", func() {
+		e.Report(Finding{Type: "email", Email: "alice@acme.io", Repo: "clean"})
+		e.Report(Finding{Type: "os", Email: "alice@acme.io", Signature: "Fedora Linux", Repo: "clean"})
+	})
+	e.WithRepoBuffer("Repo: alice/leaky", func() {
+		e.Report(Finding{Type: "email", Email: "bob@acme.io", Repo: "leaky"})
+		e.Report(Finding{Type: "saas_credential", Email: "bob@acme.io", Signature: "Stripe", Repo: "leaky"})
+	})
+
+	if c != e.Reporter {
+		t.Fatal("reporter not restored after the repo")
+	}
+	var got []string
+	for _, f := range c.Findings {
+		got = append(got, f.Repo+":"+f.Type)
+	}
+	if strings.Join(got, " ") != "leaky:email leaky:saas_credential" {
+		t.Errorf("findings = %v, want only the leaky repo's, in order", got)
+	}
+	if strings.Contains(log.String(), "alice/clean") || !strings.Contains(log.String(), "alice/leaky") {
+		t.Errorf("log = %q, want only the leaky repo's header", log.String())
+	}
+}
+
+func TestSuspiciousDate(t *testing.T) {
+	e := NewEngine("github", nil, nil)
+	now := time.Now()
+	tests := []struct {
+		name string
+		when time.Time
+		want string
+	}{
+		{"ordinary", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), ""},
+		{"unknown", time.Time{}, ""},
+		{"within the skew", now.Add(12 * time.Hour), ""},
+		{"next year", now.AddDate(1, 0, 0), "future"},
+		{"epoch zero", time.Unix(0, 0), "epoch"},
+		{"epoch in another zone", time.Unix(0, 0).In(time.FixedZone("", -5*3600)), "epoch"},
+		{"later on epoch day", time.Unix(3600*20, 0), "epoch"},
+		{"day after the epoch", time.Unix(86400, 0), ""},
+	}
+	for _, tt := range tests {
+		if got := e.SuspiciousDate(tt.when); got != tt.want {
+			t.Errorf("%s: SuspiciousDate(%v) = %q, want %q", tt.name, tt.when, got, tt.want)
+		}
+	}
+
+	e.Options.DateSkew = 0
+	if got := e.SuspiciousDate(now.Add(time.Hour)); got != "future" {
+		t.Errorf("--date-skew 0: an hour ahead = %q, want future", got)
+	}
+}
+
+func TestSuspiciousDateFindings(t *testing.T) {
+	cfg, err := DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine("github", cfg, nil)
+	c := &Collector{}
+	e.Reporter = c
+	e.ProcessCommits([]Commit{
+		{ID: "1", Time: time.Now().AddDate(5, 0, 0), Author: Person{Name: "Alice", Email: "alice@acme.io"}},
+		{ID: "2", Time: time.Unix(0, 0), Author: Person{Name: "Bob", Email: "bob@acme.io"}},
+		{ID: "3", Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Author: Person{Name: "Carol", Email: "carol@acme.io"}},
+	})
+	var got []string
+	for _, f := range c.Findings {
+		if f.Type == "suspicious_date" {
+			got = append(got, f.Email+":"+f.Signature)
+		}
+	}
+	if strings.Join(got, " ") != "alice@acme.io:future bob@acme.io:epoch" {
+		t.Errorf("suspicious_date findings = %v", got)
+	}
+}
+
+func TestExplainCoversEachGate(t *testing.T) {
+	tests := []struct {
+		name  string
+		addr  string
+		setup func(e *Engine)
+		want  []string // lines, in order
+	}{
+		{"invalid", "alice@localhost", nil, []string{"dropped: failed IsValidEmail"}},
+		{"not whitelisted", "alice@acme.io", func(e *Engine) {
+			e.Whitelist = NewBlacklist([]*regexp.Regexp{regexp.MustCompile(`@corp\.io$`)})
+		}, []string{"dropped: matches no --whitelist pattern"}},
+		{"blacklisted", "ci@acme.io", func(e *Engine) {
+			e.Whitelist = NewBlacklist([]*regexp.Regexp{regexp.MustCompile(`@acme\.io$`)})
+			e.Blacklist = NewBlacklist([]*regexp.Regexp{regexp.MustCompile(`^ci@`)})
+		}, []string{`whitelisted by @acme\.io$`, "dropped: blacklisted by ^ci@"}},
+		{"no mx", "alice@dead.io", func(e *Engine) {
+			e.MX = &MXChecker{cache: map[string]bool{"dead.io": false, "acme.io": true}}
+		}, []string{"dropped: dead.io has no MX records (--verify-mx)"}},
+		{"passed every gate", "alice@acme.io", func(e *Engine) {
+			e.Whitelist = NewBlacklist([]*regexp.Regexp{regexp.MustCompile(`@acme\.io$`)})
+			e.MX = &MXChecker{cache: map[string]bool{"acme.io": true}}
+		}, []string{`whitelisted by @acme\.io$`, "passed IsValidEmail, --whitelist, the blacklist and --verify-mx", "classified as real"}},
+		{"bot", "release-bot@acme.io", func(e *Engine) { e.Options.SkipNoReply = true },
+			[]string{"passed IsValidEmail and the blacklist", "classified as bot", "dropped: noreply or bot address (--skip-noreply)"}},
+		{"deduplicated", "support@acme.io", func(e *Engine) { e.Seen = map[string]bool{} },
+			[]string{"passed IsValidEmail and the blacklist", "classified as role", "reported email finding at https://example.com/c/1",
+				"passed IsValidEmail and the blacklist", "classified as role", "dropped email finding at https://example.com/c/2: already reported"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := capture(t, &os.Stderr, func() {
+				process(t, func(e *Engine) {
+					e.Options.Explain = strings.ToUpper(tt.addr) // matched case-insensitively
+					e.Options.EmailOnly = true
+					if tt.setup != nil {
+						tt.setup(e)
+					}
+				},
+					Commit{ID: "1", Location: "https://example.com/c/1", Time: time.Now(), Author: Person{Name: "A", Email: tt.addr}},
+					Commit{ID: "2", Location: "https://example.com/c/2", Time: time.Now(), Author: Person{Name: "A", Email: tt.addr}},
+					Commit{ID: "3", Location: "https://example.com/c/3", Time: time.Now(), Author: Person{Name: "B", Email: "bob@acme.io"}},
+				)
+			})
+			var want []string
+			for _, line := range tt.want {
+				want = append(want, "explain "+tt.addr+": "+line)
+			}
+			got := strings.Split(strings.TrimSpace(out), "\n")
+			if strings.Join(got[:min(len(want), len(got))], "\n") != strings.Join(want, "\n") {
+				t.Errorf("explanation:\n%s\nwant it to start:\n%s", out, strings.Join(want, "\n"))
+			}
+			if strings.Contains(out, "bob@acme.io") {
+				t.Errorf("explained an address that was not asked for:\n%s", out)
+			}
+		})
+	}
+}
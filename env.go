@@ -0,0 +1,130 @@
+package dossier
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ========================== Env Loader ==========================
+
+// Tokens are the provider credentials, read from .env or the environment
+type Tokens struct {
+	GitHub               string `env:"GITHUB_TOKEN"`
+	GitLab               string `env:"GITLAB_TOKEN"`
+	BitbucketUsername    string `env:"BITBUCKET_USERNAME"`
+	BitbucketAppPassword string `env:"BITBUCKET_APP_PASSWORD"`
+	BitbucketAccessToken string `env:"BITBUCKET_ACCESS_TOKEN"` // OAuth, repository or workspace token
+	AzureDevOps          string `env:"AZURE_DEVOPS_PAT"`
+	SourceHut            string `env:"SRHT_TOKEN"`
+}
+
+// LoadTokens fills Tokens from a .env file, falling back to environment
+// variables for keys the file doesn't set. A missing file is not an error.
+func LoadTokens(filename string) (Tokens, error) {
+	env, err := LoadEnv(filename)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Tokens{}, err
+	}
+	var t Tokens
+	v := reflect.ValueOf(&t).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		key := v.Type().Field(i).Tag.Get("env")
+		if val, ok := env[key]; ok {
+			v.Field(i).SetString(val)
+		} else {
+			v.Field(i).SetString(os.Getenv(key))
+		}
+	}
+	return t, nil
+}
+
+// LoadEnv parses a .env file into a map. Lines may start with "export ",
+// values may be single or double quoted, and unquoted values end at a " #"
+// comment. The first assignment of a key wins.
+func LoadEnv(filename string) (map[string]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	env := map[string]string{}
+	var errs []error
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			errs = append(errs, fmt.Errorf("%s:%d: want KEY=value", filename, n))
+			continue
+		}
+		value, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s:%d: %s: %w", filename, n, key, err))
+			continue
+		}
+		if _, dup := env[key]; !dup {
+			env[key] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return env, nil
+}
+
+// parseEnvValue unquotes a value and drops any trailing comment. Double
+// quotes take Go escapes such as \n and \"; single quotes are literal.
+func parseEnvValue(v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+	switch q := v[0]; q {
+	case '"', '\'':
+		end := 1
+		for ; end < len(v) && v[end] != q; end++ {
+			if q == '"' && v[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(v) {
+			return "", errors.New("unterminated quote")
+		}
+		if rest := strings.TrimSpace(v[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected %q after closing quote", rest)
+		}
+		if q == '\'' {
+			return v[1:end], nil
+		}
+		return strconv.Unquote(v[:end+1])
+	}
+	if i := strings.Index(v, " #"); i >= 0 {
+		v = v[:i]
+	} else if i := strings.Index(v, "\t#"); i >= 0 {
+		v = v[:i]
+	}
+	return strings.TrimSpace(v), nil
+}
+
+// LoadEnvToken returns one key from LoadTokens' sources: the .env file, then
+// the environment
+func LoadEnvToken(filename, key string) string {
+	if env, err := LoadEnv(filename); err == nil {
+		if v, ok := env[key]; ok {
+			return v
+		}
+	}
+	return os.Getenv(key)
+}
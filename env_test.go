@@ -0,0 +1,83 @@
+package dossier
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleEnv = `# provider tokens
+export GITHUB_TOKEN=ghp_file
+GITLAB_TOKEN = "glpat-\"quoted\"" # comment
+SRHT_TOKEN='lit\eral # kept'
+BITBUCKET_USERNAME=alice#1 # comment
+BITBUCKET_USERNAME=ignored
+EMPTY=
+`
+
+func TestLoadEnvToken(t *testing.T) {
+	path := writeFile(t, ".env", sampleEnv)
+	t.Setenv("GITHUB_TOKEN", "ghp_env")
+	t.Setenv("AZURE_DEVOPS_PAT", "azure_env")
+	tests := []struct {
+		file, key, want string
+	}{
+		{path, "GITHUB_TOKEN", "ghp_file"}, // the file wins over the environment
+		{path, "GITLAB_TOKEN", `glpat-"quoted"`},
+		{path, "SRHT_TOKEN", `lit\eral # kept`},
+		{path, "BITBUCKET_USERNAME", "alice#1"}, // "#" needs a space before it
+		{path, "EMPTY", ""},
+		{path, "AZURE_DEVOPS_PAT", "azure_env"},
+		{path, "UNSET_KEY", ""},
+		{filepath.Join(t.TempDir(), "missing"), "GITHUB_TOKEN", "ghp_env"},
+	}
+	for _, tt := range tests {
+		if got := LoadEnvToken(tt.file, tt.key); got != tt.want {
+			t.Errorf("LoadEnvToken(%s) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestLoadTokens(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "ghp_env")
+	t.Setenv("AZURE_DEVOPS_PAT", "azure_env")
+	t.Setenv("BITBUCKET_APP_PASSWORD", "")
+	t.Setenv("BITBUCKET_ACCESS_TOKEN", "")
+
+	tokens, err := LoadTokens(writeFile(t, ".env", sampleEnv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Tokens{
+		GitHub:            "ghp_file",
+		GitLab:            `glpat-"quoted"`,
+		BitbucketUsername: "alice#1",
+		AzureDevOps:       "azure_env",
+		SourceHut:         `lit\eral # kept`,
+	}
+	if tokens != want {
+		t.Errorf("tokens = %+v, want %+v", tokens, want)
+	}
+
+	tokens, err = LoadTokens(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || tokens.GitHub != "ghp_env" {
+		t.Errorf("missing file: tokens = %+v, err = %v, want the environment", tokens, err)
+	}
+
+	tests := []struct {
+		name, env, wantErr string
+	}{
+		{"no equals", "GITHUB_TOKEN\n", ":1: want KEY=value"},
+		{"space in key", "GITHUB TOKEN=x\n", ":1: want KEY=value"},
+		{"unterminated quote", "# tokens\nGITHUB_TOKEN=\"ghp\n", ":2: GITHUB_TOKEN: unterminated quote"},
+		{"text after quote", "GITLAB_TOKEN='a' b\n", `:1: GITLAB_TOKEN: unexpected "b" after closing quote`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadTokens(writeFile(t, ".env", tt.env))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package dossier
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// ========================== Findings ==========================
+
+type Finding struct {
+	Type      string `json:"type"` // "email", "domain_match", "os", "utility", "repo_match" or a credential type
+	Signature string `json:"signature,omitempty"`
+	Email     string `json:"email,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Value     string `json:"value,omitempty"`
+	Secret    string `json:"secret,omitempty"` // redacted
+	Encoding  string `json:"encoding,omitempty"`
+	Date      string `json:"date,omitempty"`
+	Repo      string `json:"repo,omitempty"` // repo the commit came from, on every provider
+	Location  string `json:"location,omitempty"`
+	Class     string `json:"class,omitempty"`    // emails only: real, platform_noreply, bot or role
+	Offset    int    `json:"offset,omitempty"`   // byte offset of the match in the scanned message
+	Line      int    `json:"line,omitempty"`     // 1-based line of the match
+	RawName   string `json:"raw_name,omitempty"` // Name before --normalize-names, when it changed
+	Provider  string `json:"provider,omitempty"` // "github", "gitlab", "bitbucket", "azure" or "sourcehut"
+	Seen      int    `json:"seen,omitempty"`     // --dedup: times this email was found in the run
+	IsNoReply bool   `json:"noreply,omitempty"`  // emails only: platform-generated or bot, not a person
+
+	secretHash string // credentials: SHA-256 of the unredacted value, for Key
+}
+
+// At records where in the scanned text the finding's match starts
+func (f Finding) At(text string, offset int) Finding {
+	f.Offset = offset
+	f.Line = strings.Count(text[:offset], "\n") + 1
+	return f
+}
+
+// WithSecret records a matched credential, redacted for output and hashed
+// so Key can tell one secret from another
+func (f Finding) WithSecret(value string) Finding {
+	f.Secret = Redact(value)
+	sum := sha256.Sum256([]byte(value))
+	f.secretHash = hex.EncodeToString(sum[:])
+	return f
+}
+
+// Key identifies a finding for --watch and --dedup-across-runs. It leaves
+// out where the finding was seen, so an email is keyed on its address and a
+// credential on its signature and value, and neither is new again in the
+// next commit or repo that repeats it.
+func (f Finding) Key() string {
+	switch {
+	case f.secretHash != "":
+		return strings.Join([]string{f.Type, f.Signature, f.secretHash}, "|")
+	case f.Type == "email":
+		return strings.Join([]string{f.Type, strings.ToLower(f.Email)}, "|")
+	}
+	return strings.Join([]string{f.Type, f.Signature, strings.ToLower(f.Email), f.Value}, "|")
+}
+
+// ========================== Reporting ==========================
+
+type Reporter interface {
+	Report(f Finding)
+}
+
+// Reporters that buffer output implement Flusher, called once a scan ends
+type Flusher interface {
+	Flush()
+}
+
+// Reporters with machine-readable output implement Summarizer, called with
+// the counts once a scan ends
+type Summarizer interface {
+	Summarize(s Summary)
+}
+
+// Summary is the record a scan's JSON output ends with: what it covered and
+// what became of the candidate emails
+type Summary struct {
+	Type     string `json:"type"` // always "summary"
+	Provider string `json:"provider,omitempty"`
+	ScanStats
+	EmailsInvalid  int `json:"emails_invalid"`
+	EmailsReported int `json:"emails_reported"`
+}
+
+// JSONReporter writes every finding as one JSON array once the scan ends,
+// with ISO 8601 dates, for jq and dashboards. Each scan's summary follows
+// its findings in the array.
+type JSONReporter struct {
+	w       io.Writer
+	file    *os.File
+	records []any
+}
+
+// NewJSONReporter writes to path, or to stdout when path is empty
+func NewJSONReporter(path string) (*JSONReporter, error) {
+	r := &JSONReporter{w: os.Stdout, records: []any{}}
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		r.w, r.file = file, file
+	}
+	return r, nil
+}
+
+func (r *JSONReporter) Report(f Finding) {
+	f.Date = ISODate(f.Date)
+	r.records = append(r.records, f)
+}
+
+func (r *JSONReporter) Summarize(s Summary) {
+	r.records = append(r.records, s)
+}
+
+func (r *JSONReporter) Close() error {
+	enc := json.NewEncoder(r.w)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(r.records)
+	if r.file != nil {
+		if cerr := r.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// NDJSONReporter streams one JSON object per line to any writer, flushing
+// after each so a killed scan still leaves complete lines behind
+type NDJSONReporter struct {
+	w   io.Writer
+	enc *json.Encoder
+}
+
+func NewNDJSONReporter(w io.Writer) *NDJSONReporter {
+	return &NDJSONReporter{w: w, enc: json.NewEncoder(w)}
+}
+
+func (r *NDJSONReporter) Report(f Finding) {
+	r.enc.Encode(f)
+	r.Flush()
+}
+
+func (r *NDJSONReporter) Summarize(s Summary) {
+	r.enc.Encode(s)
+	r.Flush()
+}
+
+// Flush pushes buffered writers such as bufio.Writer; files and stdout are
+// written through already
+func (r *NDJSONReporter) Flush() {
+	if w, ok := r.w.(interface{ Flush() error }); ok {
+		w.Flush()
+	}
+}
+
+// CSVReporter writes one row per finding under a header row, with ISO 8601
+// dates. Fields a finding type doesn't use are left empty.
+type CSVReporter struct {
+	w    *csv.Writer
+	file *os.File
+}
+
+var csvHeader = []string{"type", "email", "name", "date", "location", "signature", "provider"}
+
+// NewCSVReporter writes to path, or to stdout when path is empty
+func NewCSVReporter(path string) (*CSVReporter, error) {
+	r := &CSVReporter{w: csv.NewWriter(os.Stdout)}
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		r.w, r.file = csv.NewWriter(file), file
+	}
+	if err := r.w.Write(csvHeader); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *CSVReporter) Report(f Finding) {
+	r.w.Write([]string{csvCell(f.Type), csvCell(f.Email), csvCell(f.Name), ISODate(f.Date), csvCell(f.Location), csvCell(f.Signature), f.Provider})
+}
+
+func (r *CSVReporter) Flush() {
+	r.w.Flush()
+}
+
+func (r *CSVReporter) Close() error {
+	r.w.Flush()
+	err := r.w.Error()
+	if r.file != nil {
+		if cerr := r.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// csvCell stops commit-controlled text such as "=HYPERLINK(...)" from being
+// evaluated as a formula when the file is opened in a spreadsheet
+func csvCell(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// ISODate turns a finding's "2006-01-02 15:04:05 MST" date into RFC 3339.
+// Dates in any other form are returned unchanged.
+func ISODate(date string) string {
+	for _, layout := range []string{"2006-01-02 15:04:05 -0700", "2006-01-02 15:04:05 MST"} {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t.Format(time.RFC3339)
+		}
+	}
+	return date
+}
+
+// Collector keeps every finding it is given and passes it on to Next, if
+// set, so a scan can both print its findings and return them
+type Collector struct {
+	Next     Reporter
+	Findings []Finding
+}
+
+func (c *Collector) Report(f Finding) {
+	c.Findings = append(c.Findings, f)
+	if c.Next != nil {
+		c.Next.Report(f)
+	}
+}
+
+func (c *Collector) Flush() {
+	if f, ok := c.Next.(Flusher); ok {
+		f.Flush()
+	}
+}
+
+// ========================== Scanners ==========================
+
+// Scanner is the contract every provider implements, so one driver can run
+// several providers and gather their findings. ScanUser still reports to the
+// provider's own Reporter as it goes; set that to nil to only collect.
+type Scanner interface {
+	ScanUser(ctx context.Context, username string) ([]Finding, error)
+}
@@ -0,0 +1,40 @@
+package dossier
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindingAt(t *testing.T) {
+	text := "Fix deploy\n\nkey=SECRET\r\nmore\nSECRET at line five"
+	tests := []struct {
+		name     string
+		offset   int
+		wantLine int
+	}{
+		{"start of text", 0, 1},
+		{"end of first line", strings.Index(text, "\n"), 1},
+		{"after a blank line", strings.Index(text, "key="), 3},
+		{"mid line", strings.Index(text, "SECRET"), 3},
+		{"after CRLF", strings.Index(text, "more"), 4},
+		{"last line", strings.LastIndex(text, "SECRET"), 5},
+		{"end of text", len(text), 5},
+	}
+	for _, tt := range tests {
+		f := Finding{Type: "saas_credential"}.At(text, tt.offset)
+		if f.Offset != tt.offset || f.Line != tt.wantLine {
+			t.Errorf("%s: offset/line = %d/%d, want %d/%d", tt.name, f.Offset, f.Line, tt.offset, tt.wantLine)
+		}
+	}
+}
+
+func TestSecretFindingLineInCommitMessage(t *testing.T) {
+	msg := "Add config\n\nSee the notes below.\n  api_key: " + fakeGoogleKey + "\n"
+	found := byType(process(t, nil, Commit{ID: "1", Time: commitTime, Author: Person{Name: "Alice", Email: "alice@acme.io"}, Message: msg}), "google_credential")
+	if len(found) != 1 {
+		t.Fatalf("google_credential findings = %+v, want 1", found)
+	}
+	if f := found[0]; f.Line != 4 || f.Offset != strings.Index(msg, fakeGoogleKey) {
+		t.Errorf("line/offset = %d/%d, want 4/%d", f.Line, f.Offset, strings.Index(msg, fakeGoogleKey))
+	}
+}
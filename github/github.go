@@ -0,0 +1,1055 @@
+package github
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/0x4f53/dossier"
+)
+
+// ========================== Structs ==========================
+
+type CommitInfo struct {
+	Author struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+		Date  string `json:"date"`
+	} `json:"author"`
+	Committer struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+		Date  string `json:"date"`
+	} `json:"committer"`
+	Message      string        `json:"message"`
+	Verification *Verification `json:"verification,omitempty"`
+}
+
+type Verification struct {
+	Verified  bool   `json:"verified"`
+	Reason    string `json:"reason"`
+	Signature string `json:"signature"`
+}
+
+// GitHubAccount is the GitHub user a commit's git author or committer
+// email is linked to; null when the email matches no account.
+type GitHubAccount struct {
+	Login   string `json:"login"`
+	HTMLURL string `json:"html_url"`
+}
+
+type CommitItem struct {
+	SHA       string         `json:"sha"`
+	HTMLURL   string         `json:"html_url"`
+	Commit    CommitInfo     `json:"commit"`
+	Author    *GitHubAccount `json:"author,omitempty"`
+	Committer *GitHubAccount `json:"committer,omitempty"`
+}
+
+type SearchResponse struct {
+	Items []CommitItem `json:"items"`
+}
+
+type Repo struct {
+	Name     string    `json:"name"`
+	FullName string    `json:"full_name"`
+	Fork     bool      `json:"fork"`
+	Stars    int       `json:"stargazers_count"`
+	PushedAt time.Time `json:"pushed_at"`
+
+	HTMLURL     string   `json:"html_url"`
+	Description string   `json:"description"`
+	Topics      []string `json:"topics"`
+}
+
+// header is printed before a repo's findings, with its description and
+// topics as context on what the target works on
+func (r Repo) header() string {
+	h := fmt.Sprintf("Scanning repo: %s\n", r.FullName)
+	if r.Description != "" {
+		h += fmt.Sprintf("Description: %s\n", r.Description)
+	}
+	if len(r.Topics) > 0 {
+		h += fmt.Sprintf("Topics: %s\n", strings.Join(r.Topics, ", "))
+	}
+	return h
+}
+
+// ========================== Constants ==========================
+
+const providerName = "github"
+
+// maxPerPage is the largest page size GitHub accepts
+const maxPerPage = 100
+
+// defaultBaseURL is the API root unless --api-base or $GITHUB_API_URL says otherwise
+const defaultBaseURL = "https://api.github.com"
+
+// ========================== Scanner ==========================
+
+// GitHubScanner holds everything one scan needs, so several can run in a process
+// and tests can supply their own Doer and Reporter
+type GitHubScanner struct {
+	*dossier.Engine
+	Token      string
+	BaseURL    string // API root, e.g. https://ghe.example.com/api/v3 for GitHub Enterprise Server
+	APIVersion string // sent as X-GitHub-Api-Version unless empty
+
+	scanned map[string]bool // lowercased full names, so contributed repos aren't scanned twice
+}
+
+var _ dossier.Scanner = (*GitHubScanner)(nil)
+
+func NewScanner(token string, cfg *dossier.Config, blacklist []*regexp.Regexp) *GitHubScanner {
+	s := &GitHubScanner{
+		Engine:     dossier.NewEngine(providerName, cfg, blacklist),
+		Token:      token,
+		BaseURL:    defaultBaseURL,
+		APIVersion: "2022-11-28",
+	}
+	s.Reporter = dossier.TextReporter{}
+	s.Authorize = s.authorize
+	return s
+}
+
+// pageSize clamps --per-page to what the API allows
+func (s *GitHubScanner) pageSize() int {
+	return min(max(s.Options.PerPage, 1), maxPerPage)
+}
+
+// ========================== HTTP Helpers ==========================
+
+func (s *GitHubScanner) authorize(req *http.Request) {
+	if s.Token != "" {
+		req.Header.Set("Authorization", "token "+s.Token)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if s.APIVersion != "" {
+		req.Header.Set("X-GitHub-Api-Version", s.APIVersion)
+	}
+}
+
+// ========================== Commit Processing ==========================
+
+// commits converts API commits for ProcessCommits. The os and utility
+// signatures run over the whole commit as JSON, which also covers the
+// tree and parent URLs.
+func (s *GitHubScanner) commits(items []CommitItem) []dossier.Commit {
+	out := make([]dossier.Commit, len(items))
+	for i, c := range items {
+		content := c
+		content.Author, content.Committer = nil, nil // accounts aren't commit content
+		content.Commit.Verification = nil
+		commitJSON, _ := json.Marshal(content)
+
+		commitTime, _ := time.Parse(time.RFC3339, c.Commit.Author.Date)
+		out[i] = dossier.Commit{
+			ID:        c.SHA,
+			Repo:      repoFromURL(c.HTMLURL),
+			Location:  c.HTMLURL,
+			Time:      commitTime,
+			Date:      c.Commit.Author.Date,
+			Author:    person(c.Commit.Author.Name, c.Commit.Author.Email, c.Author),
+			Committer: person(c.Commit.Committer.Name, c.Commit.Committer.Email, c.Committer),
+			Message:   c.Commit.Message,
+			Text:      string(commitJSON),
+		}
+		if v := c.Commit.Verification; v != nil && v.Signature != "" {
+			out[i].Signed, out[i].SigningKey = true, signingKeyID(v.Signature)
+		}
+	}
+	return out
+}
+
+func person(name, email string, account *GitHubAccount) dossier.Person {
+	p := dossier.Person{Name: name, Email: email}
+	if account != nil {
+		p.Login, p.URL = account.Login, account.HTMLURL
+	}
+	return p
+}
+
+// "https://github.com/owner/repo/commit/sha" -> "owner/repo"
+func repoFromURL(commitURL string) string {
+	u, err := url.Parse(commitURL)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[0] + "/" + parts[1]
+}
+
+// ========================== Global Commits Mode ==========================
+
+func (s *GitHubScanner) ScanGlobalCommits(ctx context.Context, username string, ascending bool) {
+	order := "asc"
+	if !ascending {
+		order = "desc"
+	}
+	page := 1
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		if s.PageLimitReached(page, "commit search results for "+username) {
+			break
+		}
+		url := fmt.Sprintf(
+			"%s/search/commits?q=author:%s%s&sort=author-date&order=%s&per_page=%d&page=%d",
+			s.BaseURL, username, s.searchDateQualifier(), order, s.pageSize(), page,
+		)
+		body, status, _, err := s.Get(ctx, url)
+		if err != nil {
+			dossier.Log.Errorf("Error: %v\n", err)
+			return
+		}
+		if status != 200 {
+			if status == 422 {
+				dossier.Log.Warnln("Reached 1000-result limit for search API.")
+				return
+			}
+			dossier.Log.Errorf("GitHub API error %d\n%s\n", status, string(body))
+			return
+		}
+
+		var searchResp SearchResponse
+		if err := dossier.DecodeJSON(url, status, body, &searchResp); err != nil {
+			dossier.Log.Errorf("Error parsing response: %v\n", err)
+			return
+		}
+
+		if len(searchResp.Items) == 0 {
+			break
+		}
+
+		s.ProcessCommits(s.commits(searchResp.Items))
+		page++
+	}
+}
+
+// ========================== Repo Commits Mode ==========================
+
+func (s *GitHubScanner) GetUserRepos(ctx context.Context, username string) ([]Repo, error) {
+	return s.listRepos(ctx, s.BaseURL+"/users/"+username+"/repos", "repos of "+username)
+}
+
+// GetOrgRepos lists an organization's repos; with a member's token this
+// includes its private and internal ones too
+func (s *GitHubScanner) GetOrgRepos(ctx context.Context, org string) ([]Repo, error) {
+	return s.listRepos(ctx, s.BaseURL+"/orgs/"+org+"/repos", "repos of "+org)
+}
+
+func (s *GitHubScanner) listRepos(ctx context.Context, base, what string) ([]Repo, error) {
+	page := 1
+	var repos []Repo
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if s.PageLimitReached(page, what) {
+			break
+		}
+		url := fmt.Sprintf("%s?per_page=%d&page=%d%s", base, s.pageSize(), page, s.repoSortQuery())
+		body, status, _, err := s.Get(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		if status != 200 {
+			return nil, fmt.Errorf("GitHub API error %d\n%s", status, string(body))
+		}
+
+		var tmp []Repo
+		if err := dossier.DecodeJSON(url, status, body, &tmp); err != nil {
+			return nil, err
+		}
+		if len(tmp) == 0 {
+			break
+		}
+		repos = append(repos, tmp...)
+		page++
+	}
+	if s.Options.RepoSort == "stars" { // not a server-side sort option for repo listings
+		sort.SliceStable(repos, func(i, j int) bool { return repos[i].Stars > repos[j].Stars })
+	}
+	return repos, nil
+}
+
+// Event is an entry of a user's public activity timeline
+type Event struct {
+	Type string `json:"type"`
+	Repo struct {
+		Name string `json:"name"`
+	} `json:"repo"`
+}
+
+// GetPushedRepos lists the repos a user pushed to according to their public
+// events, most recent first. GitHub keeps only the last 300 events.
+func (s *GitHubScanner) GetPushedRepos(ctx context.Context, username string) ([]string, error) {
+	var repos []string
+	found := map[string]bool{}
+	for page := 1; page <= 3; page++ {
+		url := fmt.Sprintf("%s/users/%s/events/public?per_page=%d&page=%d", s.BaseURL, username, s.pageSize(), page)
+		body, status, _, err := s.Get(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		if status == 422 {
+			break // paged past the retained events
+		}
+		if status != 200 {
+			return nil, fmt.Errorf("GitHub API error %d\n%s", status, string(body))
+		}
+
+		var events []Event
+		if err := dossier.DecodeJSON(url, status, body, &events); err != nil {
+			return nil, err
+		}
+		if len(events) == 0 {
+			break
+		}
+		for _, e := range events {
+			if e.Type == "PushEvent" && !found[e.Repo.Name] {
+				found[e.Repo.Name] = true
+				repos = append(repos, e.Repo.Name)
+			}
+		}
+	}
+	return repos, nil
+}
+
+// GetOrgMembers lists an organization's public members. It returns nil
+// without an error when the name is a user rather than an org.
+func (s *GitHubScanner) GetOrgMembers(ctx context.Context, org string) ([]GitHubAccount, error) {
+	page := 1
+	members := []GitHubAccount{}
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if s.PageLimitReached(page, "members of "+org) {
+			break
+		}
+		url := fmt.Sprintf("%s/orgs/%s/members?per_page=%d&page=%d", s.BaseURL, org, s.pageSize(), page)
+		body, status, _, err := s.Get(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		if status == 404 {
+			return nil, nil
+		}
+		if status != 200 {
+			return nil, fmt.Errorf("GitHub API error %d\n%s", status, string(body))
+		}
+
+		var tmp []GitHubAccount
+		if err := dossier.DecodeJSON(url, status, body, &tmp); err != nil {
+			return nil, err
+		}
+		if len(tmp) == 0 {
+			break
+		}
+		members = append(members, tmp...)
+		page++
+	}
+	return members, nil
+}
+
+// commitDateQuery narrows commit listings to --since/--until. GitHub applies
+// them to the commit date, so ProcessCommits still checks the author date.
+func (s *GitHubScanner) commitDateQuery() string {
+	q := ""
+	if !s.Options.Since.IsZero() {
+		q += "&since=" + s.Options.Since.UTC().Format(time.RFC3339)
+	}
+	if !s.Options.Until.IsZero() {
+		q += "&until=" + s.Options.Until.UTC().Format(time.RFC3339)
+	}
+	return q
+}
+
+// searchDateQualifier is commitDateQuery for the commit search, which can
+// filter on the author date itself
+func (s *GitHubScanner) searchDateQualifier() string {
+	if s.Options.Since.IsZero() && s.Options.Until.IsZero() {
+		return ""
+	}
+	from, to := "*", "*"
+	if !s.Options.Since.IsZero() {
+		from = s.Options.Since.UTC().Format(time.RFC3339)
+	}
+	if !s.Options.Until.IsZero() {
+		to = s.Options.Until.Add(-time.Second).UTC().Format(time.RFC3339) // the range is inclusive
+	}
+	return "+author-date:" + url.QueryEscape(from+".."+to)
+}
+
+func (s *GitHubScanner) repoSortQuery() string {
+	switch s.Options.RepoSort {
+	case "updated", "created", "pushed":
+		return "&sort=" + s.Options.RepoSort + "&direction=desc"
+	}
+	return ""
+}
+
+func (s *GitHubScanner) ScanRepoCommits(ctx context.Context, repoFullName string, ascending bool) {
+	s.scanRepoCommits(ctx, repoFullName, "", ascending)
+}
+
+// scanRepoCommits scans a repo's commits, narrowed by an extra query such
+// as "&author=login" for repos the user contributed to but doesn't own
+func (s *GitHubScanner) scanRepoCommits(ctx context.Context, repoFullName, query string, ascending bool) {
+	page := 1
+	var allCommits []CommitItem
+	urlFor := func(page int) string {
+		return fmt.Sprintf("%s/repos/%s/commits?per_page=%d&page=%d%s%s", s.BaseURL, repoFullName, s.pageSize(), page, query, s.commitDateQuery())
+	}
+pages:
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		if s.PageLimitReached(page, "commits of "+repoFullName) {
+			break
+		}
+		for _, p := range s.FetchPages(ctx, urlFor, page) {
+			if p.Err != nil {
+				s.RepoFailed(repoFullName, len(allCommits), p.Err)
+				break pages
+			}
+			if p.Status == 409 {
+				// "Git Repository is empty"
+				dossier.Log.Infof("Repo %s is empty, skipping\n", repoFullName)
+				return
+			}
+			if p.Status != 200 {
+				s.RepoFailed(repoFullName, len(allCommits), fmt.Errorf("GitHub API error %d: %s", p.Status, dossier.Truncate(strings.TrimSpace(string(p.Body)), 200)))
+				break pages
+			}
+
+			var commits []CommitItem
+			if err := dossier.DecodeJSON(p.URL, p.Status, p.Body, &commits); err != nil {
+				s.RepoFailed(repoFullName, len(allCommits), err)
+				break pages
+			}
+			if len(commits) == 0 {
+				break pages
+			}
+
+			allCommits = append(allCommits, commits...)
+			page++
+		}
+	}
+
+	// The API lists newest first; reverse for oldest first
+	if ascending {
+		for i, j := 0, len(allCommits)-1; i < j; i, j = i+1, j-1 {
+			allCommits[i], allCommits[j] = allCommits[j], allCommits[i]
+		}
+	}
+
+	s.ProcessCommits(s.commits(allCommits))
+}
+
+// ========================== Metadata Files ==========================
+
+// ScanRepoMetadata reads well-known contributor files via the contents API
+func (s *GitHubScanner) ScanRepoMetadata(ctx context.Context, repoFullName string) {
+	for _, candidates := range dossier.MetadataFiles {
+		for _, path := range candidates {
+			var file struct {
+				Content string `json:"content"`
+				HTMLURL string `json:"html_url"`
+			}
+			if err := s.getJSON(ctx, fmt.Sprintf("%s/repos/%s/contents/%s", s.BaseURL, repoFullName, path), &file); err != nil {
+				continue // usually a 404: no such file
+			}
+			content, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(file.Content, "\n", ""))
+			if err != nil {
+				continue
+			}
+			s.ReportMetadata(path, string(content), file.HTMLURL)
+			break
+		}
+	}
+}
+
+// ========================== Commit Signatures ==========================
+
+// signingKeyID names the key behind an armored commit signature: the
+// OpenPGP issuer key ID, or the SHA256 fingerprint of an SSH signing key.
+func signingKeyID(armored string) string {
+	data := dearmor(armored)
+	switch {
+	case strings.Contains(armored, "BEGIN PGP SIGNATURE"):
+		if id := pgpIssuer(data); id != "" {
+			return "GPG key " + id
+		}
+	case strings.Contains(armored, "BEGIN SSH SIGNATURE"):
+		if fp := sshSigFingerprint(data); fp != "" {
+			return "SSH key " + fp
+		}
+	}
+	return ""
+}
+
+// dearmor decodes the base64 body of an ASCII-armored block, skipping
+// armor headers and the CRC line.
+func dearmor(armored string) []byte {
+	var body strings.Builder
+	inBlock, inHeaders := false, false
+	for _, line := range strings.Split(armored, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "-----BEGIN"):
+			inBlock, inHeaders = true, strings.Contains(line, "PGP")
+		case strings.HasPrefix(line, "-----END"):
+			inBlock = false
+		case !inBlock:
+		case inHeaders:
+			if line == "" {
+				inHeaders = false
+			} else if !strings.Contains(line, ": ") {
+				inHeaders = false // no headers at all
+				body.WriteString(line)
+			}
+		case strings.HasPrefix(line, "="):
+			// CRC24 checksum
+		default:
+			body.WriteString(line)
+		}
+	}
+	data, _ := base64.StdEncoding.DecodeString(body.String())
+	return data
+}
+
+// pgpIssuer reads the issuer key ID from a v4 signature packet
+func pgpIssuer(data []byte) string {
+	if len(data) < 2 || data[0]&0x80 == 0 {
+		return ""
+	}
+	var tag, length, off int
+	if data[0]&0x40 != 0 { // new format
+		tag = int(data[0] & 0x3f)
+		switch l := int(data[1]); {
+		case l < 192:
+			length, off = l, 2
+		case l < 224 && len(data) > 2:
+			length, off = (l-192)<<8+int(data[2])+192, 3
+		case l == 255 && len(data) > 5:
+			length, off = int(data[2])<<24|int(data[3])<<16|int(data[4])<<8|int(data[5]), 6
+		default:
+			return ""
+		}
+	} else { // old format
+		tag = int(data[0]>>2) & 0x0f
+		switch data[0] & 3 {
+		case 0:
+			length, off = int(data[1]), 2
+		case 1:
+			if len(data) < 3 {
+				return ""
+			}
+			length, off = int(data[1])<<8|int(data[2]), 3
+		case 2:
+			if len(data) < 5 {
+				return ""
+			}
+			length, off = int(data[1])<<24|int(data[2])<<16|int(data[3])<<8|int(data[4]), 5
+		default:
+			length, off = len(data)-1, 1
+		}
+	}
+	if tag != 2 || off+length > len(data) || length < 6 {
+		return ""
+	}
+	sig := data[off : off+length]
+	if sig[0] != 4 {
+		return ""
+	}
+
+	// Hashed then unhashed subpacket areas, each with a 2-byte length
+	var issuer string
+	pos := 4
+	for area := 0; area < 2 && pos+2 <= len(sig); area++ {
+		n := int(sig[pos])<<8 | int(sig[pos+1])
+		pos += 2
+		if pos+n > len(sig) {
+			return issuer
+		}
+		sub := sig[pos : pos+n]
+		pos += n
+		for len(sub) > 0 {
+			var l, hdr int
+			switch b := int(sub[0]); {
+			case b < 192:
+				l, hdr = b, 1
+			case b < 255 && len(sub) > 1:
+				l, hdr = (b-192)<<8+int(sub[1])+192, 2
+			case b == 255 && len(sub) > 4:
+				l, hdr = int(sub[1])<<24|int(sub[2])<<16|int(sub[3])<<8|int(sub[4]), 5
+			default:
+				return issuer
+			}
+			if l < 1 || hdr+l > len(sub) {
+				return issuer
+			}
+			typ, body := sub[hdr]&0x7f, sub[hdr+1:hdr+l]
+			switch {
+			case typ == 16 && len(body) == 8: // issuer key ID
+				issuer = strings.ToUpper(hex.EncodeToString(body))
+			case typ == 33 && len(body) == 21 && issuer == "": // issuer fingerprint (v4)
+				issuer = strings.ToUpper(hex.EncodeToString(body[13:]))
+			}
+			sub = sub[hdr+l:]
+		}
+	}
+	return issuer
+}
+
+// sshSigFingerprint reads the public key out of an SSHSIG blob
+func sshSigFingerprint(data []byte) string {
+	if len(data) < 14 || string(data[:6]) != "SSHSIG" {
+		return ""
+	}
+	rest := data[10:] // magic + uint32 version
+	n := int(rest[0])<<24 | int(rest[1])<<16 | int(rest[2])<<8 | int(rest[3])
+	if 4+n > len(rest) {
+		return ""
+	}
+	sum := sha256.Sum256(rest[4 : 4+n])
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// ========================== Gists ==========================
+
+type Gist struct {
+	ID      string `json:"id"`
+	HTMLURL string `json:"html_url"`
+	Files   map[string]struct {
+		Filename string `json:"filename"`
+		RawURL   string `json:"raw_url"`
+		Size     int    `json:"size"`
+	} `json:"files"`
+}
+
+const maxGistFileSize = 1 << 20
+
+func (s *GitHubScanner) GetUserGists(ctx context.Context, username string) ([]Gist, error) {
+	var gists []Gist
+	for page := 1; ; page++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if s.PageLimitReached(page, "gists of "+username) {
+			break
+		}
+		var batch []Gist
+		if err := s.getJSON(ctx, fmt.Sprintf("%s/users/%s/gists?per_page=%d&page=%d", s.BaseURL, username, s.pageSize(), page), &batch); err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		gists = append(gists, batch...)
+	}
+	return gists, nil
+}
+
+// ScanGists runs the email, secret and address extractors over every file
+// of the user's public gists.
+func (s *GitHubScanner) ScanGists(ctx context.Context, username string) error {
+	gists, err := s.GetUserGists(ctx, username)
+	if err != nil {
+		return err
+	}
+	for _, g := range gists {
+		for _, file := range g.Files {
+			if file.Size > maxGistFileSize {
+				dossier.Log.Warnf("Skipping %s in gist %s: larger than %d bytes\n", file.Filename, g.ID, maxGistFileSize)
+				continue
+			}
+			body, status, _, err := s.Get(ctx, file.RawURL)
+			if err != nil || status != 200 {
+				dossier.Log.Warnf("Could not fetch %s in gist %s\n", file.Filename, g.ID)
+				continue
+			}
+			s.ScanGistFile(g, file.Filename, string(body))
+		}
+	}
+	return nil
+}
+
+func (s *GitHubScanner) ScanGistFile(g Gist, filename, content string) {
+	if s.Options.SkipBinaryLike && dossier.LooksBinary(content) {
+		s.Stats.BinaryLikeSkipped++
+		return
+	}
+	location := g.HTMLURL
+	for _, email := range dossier.ExtractEmails(content) {
+		if s.ShouldReport(email) {
+			s.Report(dossier.Finding{Type: "email", Signature: "gist:" + filename, Email: email, Location: location})
+		}
+	}
+	if s.Options.EmailOnly {
+		return
+	}
+	for _, m := range dossier.FindSecrets(content, dossier.GoogleCredentialPatterns) {
+		s.Report(dossier.Finding{Type: "google_credential", Signature: m.ID, Location: location}.WithSecret(m.Value).At(content, m.Offset))
+	}
+	for _, m := range dossier.FindSecrets(content, dossier.SaaSCredentialPatterns) {
+		s.Report(dossier.Finding{Type: "saas_credential", Signature: m.ID, Location: location}.WithSecret(m.Value).At(content, m.Offset))
+	}
+	for _, r := range dossier.FindRegistryCredentials(content) {
+		s.Report(dossier.Finding{Type: "registry_credential", Signature: r.Kind, Value: r.Registry, Location: location}.WithSecret(r.Value).At(content, r.Offset))
+	}
+	for _, m := range dossier.DecodeAndRescan(content, dossier.EncodedSecretPatterns) {
+		s.Report(dossier.Finding{Type: "encoded_secret", Signature: m.ID, Encoding: m.Encoding, Location: location}.WithSecret(m.Value).At(content, m.Offset))
+	}
+	for _, a := range dossier.FindCryptoAddresses(content) {
+		s.Report(dossier.Finding{Type: "crypto_address", Signature: a.Coin, Value: a.Address, Location: location}.At(content, a.Offset))
+	}
+}
+
+// ========================== Profile Mode ==========================
+
+type GitHubUser struct {
+	Login   string `json:"login"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	HTMLURL string `json:"html_url"`
+}
+
+type GPGKey struct {
+	KeyID  string `json:"key_id"`
+	Emails []struct {
+		Email    string `json:"email"`
+		Verified bool   `json:"verified"`
+	} `json:"emails"`
+}
+
+func (s *GitHubScanner) getJSON(ctx context.Context, url string, v any) error {
+	body, status, _, err := s.Get(ctx, url)
+	if err != nil {
+		return err
+	}
+	if status != 200 {
+		return fmt.Errorf("GitHub API error %d\n%s", status, string(body))
+	}
+	return dossier.DecodeJSON(url, status, body, v)
+}
+
+// ScanProfile reports the public profile email and GPG key emails without
+// touching any commit endpoints.
+func (s *GitHubScanner) ScanProfile(ctx context.Context, username string) error {
+	var user GitHubUser
+	if err := s.getJSON(ctx, fmt.Sprintf("%s/users/%s", s.BaseURL, username), &user); err != nil {
+		return fmt.Errorf("fetching profile: %w", err)
+	}
+	var keys []GPGKey
+	if err := s.getJSON(ctx, fmt.Sprintf("%s/users/%s/gpg_keys", s.BaseURL, username), &keys); err != nil {
+		return fmt.Errorf("fetching GPG keys: %w", err)
+	}
+
+	reported := map[string]bool{}
+	report := func(email, location string) {
+		if reported[strings.ToLower(email)] || !s.ShouldReport(email) {
+			return
+		}
+		reported[strings.ToLower(email)] = true
+		s.Report(dossier.Finding{Type: "email", Email: email, Name: user.Name, Location: location})
+	}
+	report(user.Email, user.HTMLURL)
+	for _, k := range keys {
+		for _, e := range k.Emails {
+			report(e.Email, user.HTMLURL+".gpg")
+		}
+	}
+	return nil
+}
+
+// ========================== Scanning ==========================
+
+// ScanUser scans a user and returns the findings reported along the way
+func (s *GitHubScanner) ScanUser(ctx context.Context, username string) ([]dossier.Finding, error) {
+	return s.Collect(func() error { return s.scanUser(ctx, username) })
+}
+
+// ScanOrg is ScanUser for an organization
+func (s *GitHubScanner) ScanOrg(ctx context.Context, org string) ([]dossier.Finding, error) {
+	return s.Collect(func() error { return s.scanOrg(ctx, org) })
+}
+
+// ScanRepo is ScanUser for a single repo ("owner/name")
+func (s *GitHubScanner) ScanRepo(ctx context.Context, fullName string) ([]dossier.Finding, error) {
+	return s.Collect(func() error { return s.scanRepo(ctx, fullName) })
+}
+
+func (s *GitHubScanner) scanUser(ctx context.Context, username string) error {
+	if s.Options.ProfileOnly {
+		dossier.Log.Infof("Fetching profile emails for user: %s\n\n", username)
+		return s.ScanProfile(ctx, username)
+	}
+
+	dossier.Log.Infof("Scanning commits for user: %s\n\n", username)
+	s.scanned = map[string]bool{}
+
+	// 1. Commit search, which also finds commits outside the user's own repos
+	s.WithRepoBuffer("=== Commit search ===\n", func() {
+		s.ScanGlobalCommits(ctx, username, s.Options.OldestFirst)
+	})
+
+	// 2. Repo-by-repo scanning (full)
+	dossier.Log.Infoln("=== Per-repo scan (all commits) ===")
+	if err := s.scanUserRepos(ctx, username); err != nil {
+		return err
+	}
+
+	// 3. Repos the user pushed to without owning them
+	if s.Options.ScanContributed {
+		pushed, err := s.GetPushedRepos(ctx, username)
+		if err != nil {
+			return fmt.Errorf("fetching events: %w", err)
+		}
+		scanned := 0
+		for i, name := range pushed {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.Progress.SetRepo(i+1, len(pushed))
+			if s.scanned[strings.ToLower(name)] {
+				continue
+			}
+			if s.Options.RepoLimit > 0 && scanned >= s.Options.RepoLimit {
+				dossier.Log.Warnf("Reached --repo-limit of %d repos\n", s.Options.RepoLimit)
+				break
+			}
+			scanned++
+			s.Stats.ReposScanned++
+			s.scanned[strings.ToLower(name)] = true
+			s.WithRepoBuffer(fmt.Sprintf("Scanning contributed repo: %s\n", name), func() {
+				s.scanRepoCommits(ctx, name, "&author="+username, s.Options.OldestFirst)
+			})
+		}
+	}
+
+	// 4. Personal repos of the org's public members
+	if s.Options.ResolveOrgMembers {
+		if err := s.scanOrgMembers(ctx, username); err != nil {
+			return err
+		}
+	}
+
+	// 5. Public gists
+	if s.Options.ScanGists {
+		s.WithRepoBuffer("=== Gists ===\n", func() {
+			if err := s.ScanGists(ctx, username); err != nil {
+				dossier.Log.Errorln("Error fetching gists:", err)
+			}
+		})
+	}
+	return nil
+}
+
+// scanOrg scans every repo of an org and, with --resolve-org-members, its
+// public members' personal repos
+func (s *GitHubScanner) scanOrg(ctx context.Context, org string) error {
+	dossier.Log.Infof("Scanning commits for organization: %s\n\n", org)
+	s.scanned = map[string]bool{}
+	repos, err := s.GetOrgRepos(ctx, org)
+	if err != nil {
+		return fmt.Errorf("fetching org repos: %w", err)
+	}
+	if err := s.scanRepos(ctx, repos); err != nil {
+		return err
+	}
+	if !s.Options.ResolveOrgMembers {
+		return nil
+	}
+	return s.scanOrgMembers(ctx, org)
+}
+
+// scanRepo scans a single repo by full name, e.g. "owner/name"
+func (s *GitHubScanner) scanRepo(ctx context.Context, fullName string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	s.Stats.ReposScanned++
+	s.WithRepoBuffer(fmt.Sprintf("Scanning repo: %s\n", fullName), func() {
+		s.ScanRepoCommits(ctx, fullName, s.Options.OldestFirst)
+		if s.Options.ScanMetadata {
+			s.ScanRepoMetadata(ctx, fullName)
+		}
+	})
+	return nil
+}
+
+func (s *GitHubScanner) scanOrgMembers(ctx context.Context, org string) error {
+	members, err := s.GetOrgMembers(ctx, org)
+	if err != nil {
+		return fmt.Errorf("fetching org members: %w", err)
+	}
+	if members == nil {
+		dossier.Log.Warnf("%s is not an organization, skipping --resolve-org-members\n", org)
+	}
+	for _, m := range members {
+		dossier.Log.Infof("\n=== Member: %s ===\n", m.Login)
+		if err := s.scanUserRepos(ctx, m.Login); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			dossier.Log.Errorf("Error scanning %s: %v\n", m.Login, err)
+		}
+	}
+	return nil
+}
+
+// scanUserRepos scans every non-fork repo owned by a user or org
+func (s *GitHubScanner) scanUserRepos(ctx context.Context, username string) error {
+	repos, err := s.GetUserRepos(ctx, username)
+	if err != nil {
+		return fmt.Errorf("fetching repos: %w", err)
+	}
+	return s.scanRepos(ctx, repos)
+}
+
+// scanRepos scans the commits of each listed repo that passes the fork,
+// --active-since and --repo-limit filters
+func (s *GitHubScanner) scanRepos(ctx context.Context, repos []Repo) error {
+	scanned := 0
+	for i, r := range repos {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.Progress.SetRepo(i+1, len(repos))
+		if r.Fork {
+			continue // skip forks by default
+		}
+		if !s.Options.ActiveSince.IsZero() && r.PushedAt.Before(s.Options.ActiveSince) {
+			dossier.Log.Infof("Skipping %s: no activity since %s\n", r.FullName, s.Options.ActiveSince.Format("2006-01-02"))
+			continue
+		}
+		if s.Options.RepoLimit > 0 && scanned >= s.Options.RepoLimit {
+			dossier.Log.Warnf("Reached --repo-limit of %d repos\n", s.Options.RepoLimit)
+			break
+		}
+		scanned++
+		s.Stats.ReposScanned++
+		s.scanned[strings.ToLower(r.FullName)] = true
+		for _, t := range r.Topics {
+			s.Topics[t]++
+		}
+		s.WithRepoBuffer(r.header(), func() {
+			if s.Options.MatchRepos {
+				s.ReportRepoMatches(r.Name, r.Description, r.Topics, r.HTMLURL)
+			}
+			s.ScanRepoCommits(ctx, r.FullName, s.Options.OldestFirst)
+			if s.Options.ScanMetadata {
+				s.ScanRepoMetadata(ctx, r.FullName)
+			}
+		})
+	}
+	return nil
+}
+
+// ========================== Main ==========================
+
+// Main runs the GitHub command line tool
+func Main() {
+	s := NewScanner("", nil, nil)
+	if v := os.Getenv("GITHUB_API_URL"); v != "" {
+		s.BaseURL = v
+	}
+	cmd := dossier.NewCommand(s.Engine)
+	flag.IntVar(&s.Options.Prefetch, "prefetch", 0, "commit pages to fetch ahead concurrently within a repo")
+	flag.IntVar(&s.Options.PerPage, "per-page", s.Options.PerPage, fmt.Sprintf("items requested per page of a listing (1-%d)", maxPerPage))
+	flag.StringVar(&s.APIVersion, "provider-version", s.APIVersion, "GitHub REST API version sent as X-GitHub-Api-Version (empty to omit)")
+	flag.IntVar(&s.Options.RepoLimit, "repo-limit", 0, "only scan the first N repos (0 = all), in --repo-sort order")
+	flag.StringVar(&s.Options.RepoSort, "repo-sort", "", "order repos by updated, created, pushed or stars before scanning")
+	flag.DurationVar(&s.Options.MaxRateLimitWait, "max-rate-limit-wait", s.Options.MaxRateLimitWait, "longest to sleep for a GitHub rate limit to reset before giving up on a request")
+	flag.BoolVar(&s.Options.CommitterToo, "committer-too", false, "with --author-email-only, include committer emails as well")
+	flag.BoolVar(&s.Options.ScanContributed, "include-contributed", false, "also scan the user's own commits in repos they pushed to but don't own, found via public events")
+	flag.BoolVar(&s.Options.ResolveOrgMembers, "resolve-org-members", false, "when the target is an org, also scan the personal repos of its public members")
+	flag.BoolVar(&s.Options.ScanGists, "gists", false, "also scan the files of the user's public gists for emails and secrets")
+	orgMode := flag.Bool("org", false, "treat the target as an organization and scan every repo listed under /orgs/{org}/repos")
+	repoFlag := flag.String("repo", "", "scan only this repository (owner/name) instead of all of a user's repos")
+	flag.StringVar(&s.BaseURL, "api-base", s.BaseURL, "API root URL, e.g. https://ghe.example.com/api/v3 for GitHub Enterprise Server (defaults to $GITHUB_API_URL, then https://api.github.com)")
+	cmd.Parse()
+	switch s.Options.RepoSort {
+	case "", "updated", "created", "pushed", "stars":
+	default:
+		dossier.Log.Errorf("Invalid --repo-sort %q (want updated, created, pushed or stars)\n", s.Options.RepoSort)
+		os.Exit(1)
+	}
+	if s.Options.CommitterToo && !s.Options.EmailOnly {
+		dossier.Log.Errorln("--committer-too requires --author-email-only")
+		os.Exit(1)
+	}
+	if s.Options.Prefetch < 0 {
+		dossier.Log.Errorln("Error: --prefetch must not be negative")
+		os.Exit(1)
+	}
+	if n := s.pageSize(); n != s.Options.PerPage {
+		dossier.Log.Warnf("⚠️  --per-page %d is outside 1-%d, using %d\n", s.Options.PerPage, maxPerPage, n)
+	}
+	s.BaseURL = strings.TrimRight(s.BaseURL, "/")
+	if u, err := url.Parse(s.BaseURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		dossier.Log.Errorf("Invalid --api-base %q (want an http(s) URL such as https://ghe.example.com/api/v3)\n", s.BaseURL)
+		os.Exit(1)
+	}
+	if flag.NArg() < 1 && *repoFlag == "" {
+		dossier.Log.Errorln("Usage: go run ./cmd/github [flags] <github-username>")
+		dossier.Log.Errorln("       go run ./cmd/github --repo owner/name [flags]")
+		dossier.Log.Errorln("       go run ./cmd/github --org [flags] <github-org>")
+		dossier.Log.Errorln("       go run ./cmd/github schema    (print the signatures.yaml JSON Schema)")
+		os.Exit(1)
+	}
+	username := flag.Arg(0)
+	scan := s.scanUser
+	if *orgMode {
+		if s.Options.ProfileOnly || s.Options.ScanContributed || s.Options.ScanGists {
+			dossier.Log.Errorln("--org cannot be combined with --include-email-from-profile-only, --include-contributed or --gists")
+			os.Exit(1)
+		}
+		scan = s.scanOrg
+	}
+	if *repoFlag != "" {
+		if flag.NArg() > 0 || *orgMode {
+			dossier.Log.Errorln("--repo cannot be combined with a username or --org")
+			os.Exit(1)
+		}
+		if owner, name, ok := strings.Cut(*repoFlag, "/"); !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+			dossier.Log.Errorf("Invalid --repo %q (want owner/name)\n", *repoFlag)
+			os.Exit(1)
+		}
+		username = *repoFlag
+		scan = s.scanRepo
+	}
+
+	tokens, err := dossier.LoadTokens(".env")
+	if err != nil {
+		dossier.Log.Errorln("Error reading .env:", err)
+		os.Exit(1)
+	}
+	s.Token = tokens.GitHub
+	if s.Token != "" {
+		dossier.Log.Infoln("🔑 Found GitHub token in .env or the environment!")
+	} else {
+		dossier.Log.Warnln("⚠️  No GitHub personal access token found in env, running unauthenticated (with rate limits)")
+	}
+
+	cmd.Run(username, scan)
+}
@@ -0,0 +1,830 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0x4f53/dossier"
+)
+
+// doerFunc lets a function stand in for the HTTP client
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
+
+func respond(req *http.Request, status int, body string) (*http.Response, error) {
+	return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+}
+
+// fakeGitHub serves one user with one repo holding one commit; every page
+// after the first is empty
+func fakeGitHub(t *testing.T) dossier.Doer {
+	return doerFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Query().Get("page") != "1" {
+			return respond(req, 200, "[]")
+		}
+		switch req.URL.Path {
+		case "/search/commits":
+			return respond(req, 200, `{"items":[]}`)
+		case "/users/alice/repos":
+			return respond(req, 200, `[{"name":"tool","full_name":"alice/tool","html_url":"https://github.com/alice/tool","pushed_at":"2024-05-01T00:00:00Z"}]`)
+		case "/repos/alice/tool/commits":
+			return respond(req, 200, `[{"sha":"abc","html_url":"https://github.com/alice/tool/commit/abc",
+				"commit":{"author":{"name":"Alice","email":"alice@acme.io","date":"2024-05-01T10:00:00Z"},
+				          "committer":{"name":"Alice","email":"alice@acme.io","date":"2024-05-01T10:00:00Z"},
+				          "message":"Fix parser\n\nCo-authored-by: Bob <bob@acme.io>"},
+				"author":{"login":"alice","html_url":"https://github.com/alice"}}]`)
+		}
+		t.Errorf("unexpected request %s", req.URL)
+		return respond(req, 404, `{"message":"Not Found"}`)
+	})
+}
+
+func TestScanUserEndToEnd(t *testing.T) {
+	cfg, err := dossier.DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewScanner("", cfg, nil)
+	s.Doer = fakeGitHub(t)
+	s.Reporter = nil
+
+	findings, err := s.ScanUser(context.Background(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]bool{}
+	for _, f := range findings {
+		if f.Provider != "github" {
+			t.Errorf("finding %+v has provider %q", f, f.Provider)
+		}
+		got[f.Type+" "+f.Email+f.Value] = true
+	}
+	for _, want := range []string{"email alice@acme.io", "email bob@acme.io", "account alice@acme.ioalice"} {
+		if !got[want] {
+			t.Errorf("missing finding %q in %v", want, got)
+		}
+	}
+	if s.Stats.ReposScanned != 1 || s.Stats.CommitsProcessed != 1 {
+		t.Errorf("stats = %+v, want 1 repo and 1 commit", s.Stats)
+	}
+	if ids := s.Identities.Identities(); len(ids) != 2 {
+		t.Errorf("identities = %d, want alice and bob", len(ids))
+	}
+}
+
+// Two scanners with different tokens must not answer each other's
+// requests from their response caches
+func TestScannersDoNotShareResponses(t *testing.T) {
+	byToken := func(req *http.Request) (*http.Response, error) {
+		return respond(req, 200, `{"login":"`+req.Header.Get("Authorization")+`"}`)
+	}
+	a, b := NewScanner("a", nil, nil), NewScanner("b", nil, nil)
+	a.Doer, b.Doer = doerFunc(byToken), doerFunc(byToken)
+
+	url := defaultBaseURL + "/user"
+	for _, s := range []*GitHubScanner{a, b, a} {
+		body, _, _, err := s.Get(context.Background(), url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `{"login":"token ` + s.Token + `"}`; string(body) != want {
+			t.Errorf("scanner %s got %s, want %s", s.Token, body, want)
+		}
+	}
+	if a.Stats.ResponsesReused != 1 || b.Stats.ResponsesReused != 0 {
+		t.Errorf("reused a=%d b=%d, want 1 and 0", a.Stats.ResponsesReused, b.Stats.ResponsesReused)
+	}
+}
+
+func TestPageLimitStopsEndlessPagination(t *testing.T) {
+	var log strings.Builder
+	w := dossier.Log.W
+	dossier.Log.W = &log
+	defer func() { dossier.Log.W = w }()
+
+	s := NewScanner("", nil, nil)
+	s.Options.MaxPages = 3
+	requests := 0
+	s.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+		requests++ // every page is full, so only --max-pages ends the loop
+		return respond(req, 200, `[{"name":"tool","full_name":"alice/tool"}]`)
+	})
+
+	repos, err := s.GetUserRepos(context.Background(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 3 || len(repos) != 3 {
+		t.Errorf("fetched %d pages and %d repos, want 3 and 3", requests, len(repos))
+	}
+	if !strings.Contains(log.String(), "Stopped fetching repos of alice after 3 pages (--max-pages)") {
+		t.Errorf("log = %q, want the --max-pages warning", log.String())
+	}
+}
+
+func TestRequestsCarryAPIHeaders(t *testing.T) {
+	for _, version := range []string{"2022-11-28", "2026-03-10"} {
+		s := NewScanner("secret", nil, nil)
+		s.APIVersion = version
+		var got http.Header
+		s.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+			got = req.Header
+			return respond(req, 200, `{}`)
+		})
+		if _, _, _, err := s.Get(context.Background(), defaultBaseURL+"/users/alice"); err != nil {
+			t.Fatal(err)
+		}
+		if v := got.Get("X-GitHub-Api-Version"); v != version {
+			t.Errorf("X-GitHub-Api-Version = %q, want %q", v, version)
+		}
+		if v := got.Get("Accept"); v != "application/vnd.github+json" {
+			t.Errorf("Accept = %q", v)
+		}
+		if v := got.Get("Authorization"); v != "token secret" {
+			t.Errorf("Authorization = %q", v)
+		}
+	}
+}
+
+// fakeRepoList serves alice's repos from Repos, with no commits in any of
+// them, and records which repos had their commits fetched, in order
+type fakeRepoList struct {
+	t       *testing.T
+	Repos   string     // JSON array for /users/alice/repos
+	Query   url.Values // of the repo listing request
+	Scanned []string
+}
+
+func (f *fakeRepoList) Do(req *http.Request) (*http.Response, error) {
+	path := req.URL.Path
+	switch {
+	case path == "/search/commits":
+		return respond(req, 200, `{"items":[]}`)
+	case path == "/users/alice/repos":
+		if req.URL.Query().Get("page") != "1" {
+			return respond(req, 200, "[]")
+		}
+		f.Query = req.URL.Query()
+		return respond(req, 200, f.Repos)
+	case strings.HasPrefix(path, "/repos/") && strings.HasSuffix(path, "/commits"):
+		f.Scanned = append(f.Scanned, strings.TrimSuffix(strings.TrimPrefix(path, "/repos/"), "/commits"))
+		return respond(req, 200, "[]")
+	}
+	f.t.Errorf("unexpected request %s", req.URL)
+	return respond(req, 404, `{"message":"Not Found"}`)
+}
+
+func TestRepoLimitFollowsRepoSort(t *testing.T) {
+	repos := `[{"full_name":"alice/few","stargazers_count":1},
+	           {"full_name":"alice/most","stargazers_count":10},
+	           {"full_name":"alice/fork","stargazers_count":50,"fork":true},
+	           {"full_name":"alice/some","stargazers_count":5}]`
+	tests := []struct {
+		sort      string
+		wantQuery string
+		want      []string
+	}{
+		{"", "", []string{"alice/few", "alice/most"}}, // listing order
+		{"pushed", "pushed", []string{"alice/few", "alice/most"}},
+		{"stars", "", []string{"alice/most", "alice/some"}}, // sorted here, forks skipped
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			fake := &fakeRepoList{t: t, Repos: repos}
+			s := NewScanner("", nil, nil)
+			s.Doer = fake
+			s.Reporter = nil
+			s.Options.RepoLimit = 2
+			s.Options.RepoSort = tt.sort
+			if _, err := s.ScanUser(context.Background(), "alice"); err != nil {
+				t.Fatal(err)
+			}
+			if got := fake.Query.Get("sort"); got != tt.wantQuery {
+				t.Errorf("sort query = %q, want %q", got, tt.wantQuery)
+			}
+			if strings.Join(fake.Scanned, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("scanned %v, want %v", fake.Scanned, tt.want)
+			}
+		})
+	}
+}
+
+func TestActiveSinceSkipsDormantRepos(t *testing.T) {
+	fake := &fakeRepoList{t: t, Repos: `[
+		{"full_name":"alice/fresh","pushed_at":"2024-05-01T00:00:00Z"},
+		{"full_name":"alice/dormant","pushed_at":"2019-01-01T00:00:00Z"},
+		{"full_name":"alice/edge","pushed_at":"2024-01-01T00:00:00Z"}]`}
+	s := NewScanner("", nil, nil)
+	s.Doer = fake
+	s.Reporter = nil
+	s.Options.ActiveSince = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := s.ScanUser(context.Background(), "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(fake.Scanned, " "); got != "alice/fresh alice/edge" {
+		t.Errorf("scanned %s, want alice/fresh alice/edge", got)
+	}
+	if s.Stats.ReposScanned != 2 {
+		t.Errorf("repos scanned = %d, want 2", s.Stats.ReposScanned)
+	}
+}
+
+func TestProfileOnlySkipsCommitEndpoints(t *testing.T) {
+	s := NewScanner("", nil, nil)
+	s.Reporter = nil
+	s.Options.ProfileOnly = true
+	var paths []string
+	s.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+		paths = append(paths, req.URL.Path)
+		switch req.URL.Path {
+		case "/users/alice":
+			return respond(req, 200, `{"login":"alice","name":"Alice","email":"alice@acme.io","html_url":"https://github.com/alice"}`)
+		case "/users/alice/gpg_keys":
+			return respond(req, 200, `[{"emails":[{"email":"alice@home.io"},{"email":"Alice@acme.io"}]}]`)
+		}
+		t.Errorf("unexpected request %s", req.URL)
+		return respond(req, 404, `{"message":"Not Found"}`)
+	})
+
+	findings, err := s.ScanUser(context.Background(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Email+" "+f.Location)
+	}
+	if want := "alice@acme.io https://github.com/alice,alice@home.io https://github.com/alice.gpg"; strings.Join(got, ",") != want {
+		t.Errorf("findings = %q, want %q", got, want)
+	}
+	if len(paths) != 2 || s.Stats.CommitsProcessed != 0 {
+		t.Errorf("requested %v and processed %d commits, want only the profile and its keys", paths, s.Stats.CommitsProcessed)
+	}
+}
+
+// authorOrder lists the author email findings in the order they were reported
+func authorOrder(findings []dossier.Finding) string {
+	var emails []string
+	for _, f := range findings {
+		if f.Type == "email" && f.Signature == "" {
+			emails = append(emails, f.Email)
+		}
+	}
+	return strings.Join(emails, " ")
+}
+
+func TestOrderAcrossPages(t *testing.T) {
+	commit := func(n string) string {
+		return `{"sha":"c` + n + `","commit":{"author":{"name":"C` + n + `","email":"c` + n + `@acme.io","date":"2024-05-0` + n + `T10:00:00Z"},"message":"Change ` + n + `"}}`
+	}
+	pages := map[string]string{"1": "[" + commit("3") + "," + commit("2") + "]", "2": "[" + commit("1") + "]"}
+	cfg, err := dossier.DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		oldestFirst bool
+		want        string
+	}{
+		{false, "c3@acme.io c2@acme.io c1@acme.io"},
+		{true, "c1@acme.io c2@acme.io c3@acme.io"},
+	} {
+		s := NewScanner("", cfg, nil)
+		c := &dossier.Collector{}
+		s.Reporter = c
+		s.Options.PerPage = 2
+		s.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+			if body, ok := pages[req.URL.Query().Get("page")]; ok {
+				return respond(req, 200, body)
+			}
+			return respond(req, 200, "[]")
+		})
+		s.ScanRepoCommits(context.Background(), "alice/tool", tt.oldestFirst)
+		if got := authorOrder(c.Findings); got != tt.want {
+			t.Errorf("oldest first %v: order %s, want %s", tt.oldestFirst, got, tt.want)
+		}
+		if s.Stats.CommitsProcessed != 3 {
+			t.Errorf("oldest first %v: processed %d commits, want 3", tt.oldestFirst, s.Stats.CommitsProcessed)
+		}
+	}
+}
+
+// sampleRepoCommit is a trimmed GET /repos/{owner}/{repo}/commits item made
+// in the web UI: the git author is a noreply address, the account is not
+const sampleRepoCommit = `{
+  "sha": "6dcb09b5b57875f334f61aebed695e2e4193db5e",
+  "html_url": "https://github.com/alice/tool/commit/6dcb09b5b57875f334f61aebed695e2e4193db5e",
+  "commit": {
+    "author": {"name": "Alice", "email": "12345+alice@users.noreply.github.com", "date": "2024-05-01T10:00:00Z"},
+    "committer": {"name": "GitHub", "email": "noreply@github.com", "date": "2024-05-01T10:00:00Z"},
+    "message": "Update README.md"
+  },
+  "author": {"login": "alice", "id": 12345, "html_url": "https://github.com/alice", "type": "User"},
+  "committer": null
+}`
+
+func TestRepoCommitCarriesAuthorLogin(t *testing.T) {
+	var item CommitItem
+	if err := json.Unmarshal([]byte(sampleRepoCommit), &item); err != nil {
+		t.Fatal(err)
+	}
+	if item.Author == nil || item.Author.Login != "alice" || item.Author.HTMLURL != "https://github.com/alice" {
+		t.Errorf("author = %+v, want alice's account", item.Author)
+	}
+	if item.Committer != nil {
+		t.Errorf("committer = %+v, want nil for an unlinked email", item.Committer)
+	}
+
+	cfg, err := dossier.DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	blacklist, err := dossier.DefaultBlacklist()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewScanner("", cfg, blacklist)
+	c := &dossier.Collector{}
+	s.Reporter = c
+	s.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Query().Get("page") == "1" {
+			return respond(req, 200, "["+sampleRepoCommit+"]")
+		}
+		return respond(req, 200, "[]")
+	})
+	s.ScanRepoCommits(context.Background(), "alice/tool", false)
+
+	var accounts []dossier.Finding
+	for _, f := range c.Findings {
+		switch f.Type {
+		case "account":
+			accounts = append(accounts, f)
+		case "email":
+			t.Errorf("reported blacklisted email %s", f.Email)
+		}
+	}
+	if len(accounts) != 1 || accounts[0].Value != "alice" || accounts[0].Location != "https://github.com/alice" || accounts[0].Name != "Alice" {
+		t.Errorf("account findings = %+v, want alice's login and profile", accounts)
+	}
+}
+
+func TestScanGists(t *testing.T) {
+	cfg, err := dossier.DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stripe := "sk_live_" + strings.Repeat("9xQ", 8)
+	s := NewScanner("", cfg, nil)
+	c := &dossier.Collector{}
+	s.Reporter = c
+	var fetched []string
+	s.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+		switch req.URL.Host + req.URL.Path {
+		case "api.github.com/users/alice/gists":
+			if req.URL.Query().Get("page") != "1" {
+				return respond(req, 200, "[]")
+			}
+			return respond(req, 200, `[{
+				"id": "aa5a315d61ae9438b18d",
+				"html_url": "https://gist.github.com/alice/aa5a315d61ae9438b18d",
+				"files": {
+					"deploy.env": {"filename": "deploy.env", "raw_url": "https://gist.githubusercontent.com/alice/aa5a315d61ae9438b18d/raw/deploy.env", "size": 80},
+					"dump.sql": {"filename": "dump.sql", "raw_url": "https://gist.githubusercontent.com/alice/aa5a315d61ae9438b18d/raw/dump.sql", "size": 5000000}
+				}
+			}]`)
+		case "gist.githubusercontent.com/alice/aa5a315d61ae9438b18d/raw/deploy.env":
+			fetched = append(fetched, req.URL.Path)
+			return respond(req, 200, "NOTIFY=ops@acme.io\nSTRIPE_KEY="+stripe+"\n")
+		}
+		t.Errorf("unexpected request %s", req.URL)
+		return respond(req, 404, `{"message":"Not Found"}`)
+	})
+	if err := s.ScanGists(context.Background(), "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fetched) != 1 {
+		t.Errorf("fetched %v, want only the small file", fetched)
+	}
+	const location = "https://gist.github.com/alice/aa5a315d61ae9438b18d"
+	var email, secret *dossier.Finding
+	for i, f := range c.Findings {
+		if f.Location != location {
+			t.Errorf("finding %+v not tagged with the gist URL", f)
+		}
+		switch f.Type {
+		case "email":
+			email = &c.Findings[i]
+		case "saas_credential":
+			secret = &c.Findings[i]
+		}
+	}
+	if email == nil || email.Email != "ops@acme.io" || email.Signature != "gist:deploy.env" {
+		t.Errorf("email finding = %+v", email)
+	}
+	if secret == nil || secret.Line != 2 || strings.Contains(secret.Secret, stripe) {
+		t.Errorf("secret finding = %+v, want a redacted key on line 2", secret)
+	}
+}
+
+func TestRepoTopicsAndDescription(t *testing.T) {
+	var repos []Repo
+	listing := `[
+		{"name":"tool","full_name":"alice/tool","description":"Fast log shipper","topics":["golang","observability"]},
+		{"name":"site","full_name":"alice/site","description":null,"topics":["golang"]},
+		{"name":"old","full_name":"alice/old"}]`
+	if err := json.Unmarshal([]byte(listing), &repos); err != nil {
+		t.Fatal(err)
+	}
+	if r := repos[0]; r.Description != "Fast log shipper" || strings.Join(r.Topics, ",") != "golang,observability" {
+		t.Errorf("decoded %+v", r)
+	}
+	if got, want := repos[0].header(), "Scanning repo: alice/tool\nDescription: Fast log shipper\nTopics: golang, observability\n"; got != want {
+		t.Errorf("header = %q, want %q", got, want)
+	}
+	if got, want := repos[2].header(), "Scanning repo: alice/old\n"; got != want {
+		t.Errorf("header without metadata = %q, want %q", got, want)
+	}
+
+	var log strings.Builder
+	w := dossier.Log.W
+	dossier.Log.W = &log
+	defer func() { dossier.Log.W = w }()
+	s := NewScanner("", nil, nil)
+	s.Doer = &fakeRepoList{t: t, Repos: listing}
+	s.Reporter = nil
+	if _, err := s.ScanUser(context.Background(), "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if s.Topics["golang"] != 2 || s.Topics["observability"] != 1 {
+		t.Errorf("topic counts = %v", s.Topics)
+	}
+	s.PrintSummary()
+	out := log.String()
+	if !strings.Contains(out, "Description: Fast log shipper\nTopics: golang, observability\n") {
+		t.Errorf("log has no repo header with description and topics:\n%s", out)
+	}
+	if !strings.Contains(out, "=== Repository topics ===\ngolang: 2 repos\nobservability: 1 repos\n") {
+		t.Errorf("summary has no topic breakdown:\n%s", out)
+	}
+}
+
+func TestResolveOrgMembersScansMemberRepos(t *testing.T) {
+	cfg, err := dossier.DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := func(repo, sha, name, email string) string {
+		return `[{"sha":"` + sha + `","html_url":"https://github.com/` + repo + `/commit/` + sha + `","commit":{"author":{"name":"` + name + `","email":"` + email + `","date":"2024-05-01T10:00:00Z"},"message":"Change"}}]`
+	}
+	for _, resolve := range []bool{false, true} {
+		var requested []string
+		s := NewScanner("", cfg, nil)
+		c := &dossier.Collector{}
+		s.Reporter = c
+		s.Options.ResolveOrgMembers = resolve
+		s.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Query().Get("page") != "1" {
+				return respond(req, 200, "[]")
+			}
+			requested = append(requested, req.URL.Path)
+			switch req.URL.Path {
+			case "/orgs/acme/repos":
+				return respond(req, 200, `[{"name":"core","full_name":"acme/core"}]`)
+			case "/orgs/acme/members":
+				return respond(req, 200, `[{"login":"alice","html_url":"https://github.com/alice"},{"login":"bob","html_url":"https://github.com/bob"}]`)
+			case "/users/alice/repos":
+				return respond(req, 200, `[{"name":"tool","full_name":"alice/tool"}]`)
+			case "/users/bob/repos":
+				return respond(req, 200, `[{"name":"dots","full_name":"bob/dots"}]`)
+			case "/repos/acme/core/commits":
+				return respond(req, 200, commit("acme/core", "c1", "Alice", "alice@acme.io"))
+			case "/repos/alice/tool/commits":
+				return respond(req, 200, commit("alice/tool", "c2", "Alice", "alice@acme.io"))
+			case "/repos/bob/dots/commits":
+				return respond(req, 200, commit("bob/dots", "c3", "Bob", "bob@home.io"))
+			}
+			t.Errorf("unexpected request %s", req.URL)
+			return respond(req, 404, `{"message":"Not Found"}`)
+		})
+		if _, err := s.ScanOrg(context.Background(), "acme"); err != nil {
+			t.Fatal(err)
+		}
+
+		want := "/orgs/acme/repos /repos/acme/core/commits"
+		if resolve {
+			want += " /orgs/acme/members /users/alice/repos /repos/alice/tool/commits /users/bob/repos /repos/bob/dots/commits"
+		}
+		if got := strings.Join(requested, " "); got != want {
+			t.Errorf("resolve %v: requested %s\nwant %s", resolve, got, want)
+		}
+		if !resolve {
+			continue
+		}
+		ids := s.Identities.Identities()
+		if len(ids) != 2 || ids[0].Email != "alice@acme.io" || ids[0].Commits != 2 || len(ids[0].Sources) != 2 || ids[1].Email != "bob@home.io" {
+			for _, id := range ids {
+				t.Errorf("identity %s: %d commits in %v", id.Email, id.Commits, id.Sources)
+			}
+			t.Error("want alice once across both scans, then bob")
+		}
+	}
+}
+
+func TestRepoLimitAppliesPerMember(t *testing.T) {
+	s := NewScanner("", nil, nil)
+	s.Reporter = nil
+	s.Options.ResolveOrgMembers = true
+	s.Options.RepoLimit = 1
+	var scanned []string
+	s.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Query().Get("page") != "1" {
+			return respond(req, 200, "[]")
+		}
+		switch req.URL.Path {
+		case "/orgs/acme/repos":
+			return respond(req, 200, `[{"name":"core","full_name":"acme/core"},{"name":"site","full_name":"acme/site"}]`)
+		case "/orgs/acme/members":
+			return respond(req, 200, `[{"login":"alice"},{"login":"bob"}]`)
+		case "/users/alice/repos":
+			return respond(req, 200, `[{"name":"tool","full_name":"alice/tool"},{"name":"blog","full_name":"alice/blog"}]`)
+		case "/users/bob/repos":
+			return respond(req, 200, `[{"name":"dots","full_name":"bob/dots"}]`)
+		}
+		if repo, ok := strings.CutSuffix(strings.TrimPrefix(req.URL.Path, "/repos/"), "/commits"); ok {
+			scanned = append(scanned, repo)
+			return respond(req, 200, "[]")
+		}
+		t.Errorf("unexpected request %s", req.URL)
+		return respond(req, 404, `{"message":"Not Found"}`)
+	})
+	if _, err := s.ScanOrg(context.Background(), "acme"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(scanned, " "), "acme/core alice/tool bob/dots"; got != want {
+		t.Errorf("scanned %s, want the first repo of the org and of each member", got)
+	}
+	if s.Stats.ReposScanned != 3 {
+		t.Errorf("repos scanned = %d, want 3", s.Stats.ReposScanned)
+	}
+}
+
+func TestPrefetchProcessesEachPageOnce(t *testing.T) {
+	cfg, err := dossier.DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 13 commits, two per page, newest first: c13 c12 | c11 c10 | ... | c1
+	const total = 13
+	for _, tt := range []struct {
+		prefetch, maxPages int
+		wantCommits        int
+	}{
+		{0, 0, total},
+		{3, 0, total},
+		{10, 0, total},
+		{3, 5, 10}, // the batch is cut at --max-pages
+	} {
+		var mu sync.Mutex
+		requests := map[int]int{}
+		s := NewScanner("", cfg, nil)
+		c := &dossier.Collector{}
+		s.Reporter = c
+		s.Options.PerPage = 2
+		s.Options.Prefetch = tt.prefetch
+		s.Options.MaxPages = tt.maxPages
+		s.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+			page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+			mu.Lock()
+			requests[page]++
+			mu.Unlock()
+			var items []string
+			for n := total - 2*(page-1); n > total-2*page && n > 0; n-- {
+				items = append(items, fmt.Sprintf(`{"sha":"c%d","commit":{"author":{"name":"C%d","email":"c%d@acme.io","date":"2024-05-01T10:00:00Z"},"message":"Change"}}`, n, n, n))
+			}
+			return respond(req, 200, "["+strings.Join(items, ",")+"]")
+		})
+		s.ScanRepoCommits(context.Background(), "alice/tool", false)
+
+		var want []string
+		for n := total; n > total-tt.wantCommits; n-- {
+			want = append(want, fmt.Sprintf("c%d@acme.io", n))
+		}
+		if got := authorOrder(c.Findings); got != strings.Join(want, " ") {
+			t.Errorf("prefetch %d, max pages %d: order %s\nwant %s", tt.prefetch, tt.maxPages, got, strings.Join(want, " "))
+		}
+		if s.Stats.CommitsProcessed != tt.wantCommits {
+			t.Errorf("prefetch %d, max pages %d: processed %d commits, want %d", tt.prefetch, tt.maxPages, s.Stats.CommitsProcessed, tt.wantCommits)
+		}
+		for page, n := range requests {
+			if n != 1 {
+				t.Errorf("prefetch %d: page %d requested %d times", tt.prefetch, page, n)
+			}
+			if tt.maxPages > 0 && page > tt.maxPages {
+				t.Errorf("prefetch %d: page %d requested past --max-pages %d", tt.prefetch, page, tt.maxPages)
+			}
+		}
+	}
+}
+
+func TestGetPushedRepos(t *testing.T) {
+	pages := map[string]string{
+		"1": `[
+			{"id":"3","type":"PushEvent","repo":{"id":1,"name":"acme/core","url":"https://api.github.com/repos/acme/core"},"payload":{"size":2}},
+			{"id":"2","type":"WatchEvent","repo":{"id":2,"name":"torvalds/linux"}},
+			{"id":"1","type":"PushEvent","repo":{"id":3,"name":"alice/tool"}}]`,
+		"2": `[
+			{"id":"0","type":"PushEvent","repo":{"id":1,"name":"acme/core"}},
+			{"id":"-1","type":"PullRequestEvent","repo":{"id":4,"name":"bob/lib"}},
+			{"id":"-2","type":"PushEvent","repo":{"id":5,"name":"oss/parser"}}]`,
+	}
+	var requested []string
+	s := NewScanner("", nil, nil)
+	s.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != "/users/alice/events/public" {
+			t.Errorf("unexpected request %s", req.URL)
+		}
+		page := req.URL.Query().Get("page")
+		requested = append(requested, page)
+		if body, ok := pages[page]; ok {
+			return respond(req, 200, body)
+		}
+		return respond(req, 422, `{"message":"In order to keep the API fast for everyone, pagination is limited for this resource."}`)
+	})
+	repos, err := s.GetPushedRepos(context.Background(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(repos, " "); got != "acme/core alice/tool oss/parser" {
+		t.Errorf("pushed repos = %s, want distinct push targets, most recent first", got)
+	}
+	if got := strings.Join(requested, " "); got != "1 2 3" {
+		t.Errorf("requested pages %s, want 1 2 3", got)
+	}
+}
+
+func TestMatchReposReportsRepoLevelMatches(t *testing.T) {
+	cfg, err := dossier.DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos := `[
+		{"name":"k8s-operators","full_name":"alice/k8s-operators","html_url":"https://github.com/alice/k8s-operators"},
+		{"name":"infra","full_name":"alice/infra","html_url":"https://github.com/alice/infra","description":"Terraform modules for the fedora build boxes"},
+		{"name":"notes","full_name":"alice/notes","html_url":"https://github.com/alice/notes","topics":["dotfiles"]},
+		{"name":"blog","full_name":"alice/blog","html_url":"https://github.com/alice/blog","description":"Personal site"}]`
+	for _, match := range []bool{false, true} {
+		s := NewScanner("", cfg, nil)
+		c := &dossier.Collector{}
+		s.Reporter = c
+		s.Doer = &fakeRepoList{t: t, Repos: repos}
+		s.Options.MatchRepos = match
+		if _, err := s.ScanUser(context.Background(), "alice"); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, f := range c.Findings {
+			if f.Type == "repo_match" {
+				got = append(got, f.Value+":"+f.Signature)
+				if f.Location != "https://github.com/alice/"+f.Value {
+					t.Errorf("%s matched at %s, want the repo URL", f.Value, f.Location)
+				}
+			}
+		}
+		want := ""
+		if match {
+			want = "k8s-operators:Kubernetes infra:Terraform infra:Fedora Linux notes:Dotfiles"
+		}
+		if strings.Join(got, " ") != want {
+			t.Errorf("--match-repos=%v: matches %v, want %s", match, got, want)
+		}
+	}
+}
+
+func TestEmptyRepoIsSkipped(t *testing.T) {
+	cfg, err := dossier.DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log strings.Builder
+	w := dossier.Log.W
+	dossier.Log.W = &log
+	defer func() { dossier.Log.W = w }()
+
+	s := NewScanner("", cfg, nil)
+	c := &dossier.Collector{}
+	s.Reporter = c
+	s.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+		switch req.URL.Path {
+		case "/users/alice/repos":
+			if req.URL.Query().Get("page") != "1" {
+				return respond(req, 200, "[]")
+			}
+			return respond(req, 200, `[{"full_name":"alice/empty"},{"full_name":"alice/tool"}]`)
+		case "/search/commits":
+			return respond(req, 200, `{"items":[]}`)
+		case "/repos/alice/empty/commits":
+			return respond(req, 409, `{"message":"Git Repository is empty.","documentation_url":"https://docs.github.com/rest/commits/commits#list-commits","status":"409"}`)
+		case "/repos/alice/tool/commits":
+			if req.URL.Query().Get("page") != "1" {
+				return respond(req, 200, "[]")
+			}
+			return respond(req, 200, `[{"sha":"c1","commit":{"author":{"name":"Alice","email":"alice@acme.io","date":"2024-05-01T10:00:00Z"},"message":"Init"}}]`)
+		}
+		t.Errorf("unexpected request %s", req.URL)
+		return respond(req, 404, `{"message":"Not Found"}`)
+	})
+	if _, err := s.ScanUser(context.Background(), "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(log.String(), "Repo alice/empty is empty, skipping\n") {
+		t.Errorf("log = %q, want the empty repo noted", log.String())
+	}
+	if strings.Contains(log.String(), "409") || len(s.Stats.FailedRepos) != 0 {
+		t.Errorf("empty repo treated as a failure: %v\n%s", s.Stats.FailedRepos, log.String())
+	}
+	if got := authorOrder(c.Findings); got != "alice@acme.io" {
+		t.Errorf("findings %s, want the next repo still scanned", got)
+	}
+}
+
+func TestPageSizeIsClamped(t *testing.T) {
+	for _, tt := range []struct{ perPage, want int }{
+		{-5, 1}, {0, 1}, {1, 1}, {50, 50}, {100, 100}, {101, 100}, {1000, 100},
+	} {
+		s := NewScanner("", nil, nil)
+		s.Options.PerPage = tt.perPage
+		if got := s.pageSize(); got != tt.want {
+			t.Errorf("--per-page %d: page size %d, want %d", tt.perPage, got, tt.want)
+		}
+	}
+
+	fake := &fakeRepoList{t: t, Repos: "[]"}
+	s := NewScanner("", nil, nil)
+	s.Doer = fake
+	s.Reporter = nil
+	s.Options.PerPage = 1000
+	if _, err := s.ScanUser(context.Background(), "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.Query.Get("per_page"); got != "100" {
+		t.Errorf("repo listing per_page = %s, want the clamped 100", got)
+	}
+}
+
+func TestScanResumesAfterRateLimit(t *testing.T) {
+	var log strings.Builder
+	w := dossier.Log.W
+	dossier.Log.W = &log
+	defer func() { dossier.Log.W = w }()
+
+	cfg, err := dossier.DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewScanner("", cfg, nil)
+	c := &dossier.Collector{}
+	s.Reporter = c
+	var sent []string
+	s.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+		page := req.URL.Query().Get("page")
+		sent = append(sent, page)
+		if page != "1" {
+			return respond(req, 200, "[]")
+		}
+		if len(sent) == 1 {
+			// The limit ran out this second, so it resets a second from now
+			resp, _ := respond(req, 403, `{"message":"API rate limit exceeded"}`)
+			resp.Header.Set("X-RateLimit-Remaining", "0")
+			resp.Header.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+			return resp, nil
+		}
+		return respond(req, 200, `[{"sha":"abc","commit":{"author":{"name":"Alice","email":"alice@acme.io","date":"2024-05-01T10:00:00Z"},"message":"Fix parser"}}]`)
+	})
+
+	start := time.Now()
+	s.ScanRepoCommits(context.Background(), "alice/tool", false)
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("resumed after %s, want a wait until the reset", waited)
+	}
+	if got := strings.Join(sent, ","); got != "1,1,2" {
+		t.Errorf("pages requested = %s, want the rate-limited page again, then the next", got)
+	}
+	if got := authorOrder(c.Findings); got != "alice@acme.io" {
+		t.Errorf("authors = %q, want the commit from the retried page", got)
+	}
+	if !strings.Contains(log.String(), "waiting 1s for the limit to reset") {
+		t.Errorf("log = %q, want the rate-limit wait", log.String())
+	}
+}
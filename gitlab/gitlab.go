@@ -0,0 +1,629 @@
+package gitlab
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/0x4f53/dossier"
+)
+
+// ========================== Structs ==========================
+
+type GitLabCommit struct {
+	ID             string `json:"id"`
+	ShortID        string `json:"short_id"`
+	Title          string `json:"title"`
+	Message        string `json:"message"`
+	AuthorName     string `json:"author_name"`
+	AuthorEmail    string `json:"author_email"`
+	AuthoredDate   string `json:"authored_date"`
+	CommitterName  string `json:"committer_name"`
+	CommitterEmail string `json:"committer_email"`
+	WebURL         string `json:"web_url,omitempty"` // GitLab doesn’t always include this
+}
+
+type GitLabProject struct {
+	ID                    int       `json:"id"`
+	Name                  string    `json:"name"`
+	Path                  string    `json:"path_with_namespace"`
+	WebURL                string    `json:"web_url"`
+	DefaultBranch         string    `json:"default_branch"`
+	Description           string    `json:"description"`
+	Topics                []string  `json:"topics"`
+	LastActivityAt        time.Time `json:"last_activity_at"`
+	RepositoryAccessLevel string    `json:"repository_access_level"` // "disabled" when the project has no repository
+	ForkedFromProject     *struct {
+		ID int `json:"id"`
+	} `json:"forked_from_project"`
+}
+
+type GitLabUser struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+// ========================== Constants ==========================
+
+const providerName = "gitlab"
+
+// maxPerPage is the largest page size GitLab accepts
+const maxPerPage = 100
+
+// defaultBaseURL is the instance scanned unless --api-base or $GITLAB_URL says otherwise
+const defaultBaseURL = "https://gitlab.com"
+
+// ========================== Scanner ==========================
+
+// GitLabScanner holds everything one scan needs, so several can run in a process
+// and tests can supply their own Doer and Reporter
+type GitLabScanner struct {
+	*dossier.Engine
+	Token   string
+	BaseURL string // instance URL, e.g. https://gitlab.example.com for a self-managed GitLab; the API is under /api/v4
+}
+
+var _ dossier.Scanner = (*GitLabScanner)(nil)
+
+func NewScanner(token string, cfg *dossier.Config, blacklist []*regexp.Regexp) *GitLabScanner {
+	s := &GitLabScanner{Engine: dossier.NewEngine(providerName, cfg, blacklist), Token: token, BaseURL: defaultBaseURL}
+	s.Reporter = dossier.TextReporter{}
+	s.Authorize = s.authorize
+	return s
+}
+
+// pageSize clamps --per-page to what the API allows
+func (s *GitLabScanner) pageSize() int {
+	return min(max(s.Options.PerPage, 1), maxPerPage)
+}
+
+// ========================== HTTP Helpers ==========================
+
+func (s *GitLabScanner) authorize(req *http.Request) {
+	if s.Token != "" {
+		req.Header.Set("PRIVATE-TOKEN", s.Token)
+	}
+}
+
+// ========================== Commit Processing ==========================
+
+// commits converts API commits for ProcessCommits. Committers are only
+// listed with --committer-too.
+func (s *GitLabScanner) commits(commits []GitLabCommit, projectURL string) []dossier.Commit {
+	out := make([]dossier.Commit, len(commits))
+	for i, c := range commits {
+		// The title is only the message's first line; signatures and tool
+		// banners usually sit in the body
+		message := c.Message
+		if message == "" {
+			message = c.Title
+		}
+		commitTime, _ := time.Parse(time.RFC3339, c.AuthoredDate)
+		out[i] = dossier.Commit{
+			ID:       c.ID,
+			Repo:     projectFromURL(projectURL),
+			Location: projectURL,
+			Time:     commitTime,
+			Date:     c.AuthoredDate,
+			Author:   dossier.Person{Name: c.AuthorName, Email: c.AuthorEmail},
+			Message:  message,
+			Text:     fmt.Sprintf("%s %s <%s>", message, c.AuthorName, c.AuthorEmail),
+		}
+		if s.Options.CommitterToo {
+			out[i].Committer = dossier.Person{Name: c.CommitterName, Email: c.CommitterEmail}
+		}
+	}
+	return out
+}
+
+// "https://gitlab.com/group/project" -> "group/project"
+func projectFromURL(projectURL string) string {
+	if u, err := url.Parse(projectURL); err == nil {
+		return strings.Trim(u.Path, "/")
+	}
+	return projectURL
+}
+
+// ========================== Repo Commits Mode ==========================
+
+func (s *GitLabScanner) GetUserID(ctx context.Context, username string) (int, error) {
+	user, err := s.GetUser(ctx, username)
+	return user.ID, err
+}
+
+func (s *GitLabScanner) GetUser(ctx context.Context, username string) (GitLabUser, error) {
+	url := fmt.Sprintf("%s/api/v4/users?username=%s", s.BaseURL, username)
+	body, status, _, err := s.Get(ctx, url)
+	if err != nil {
+		return GitLabUser{}, err
+	}
+	if status != 200 {
+		return GitLabUser{}, fmt.Errorf("GitLab API error %d\n%s", status, string(body))
+	}
+	var users []GitLabUser
+	if err := dossier.DecodeJSON(url, status, body, &users); err != nil {
+		return GitLabUser{}, err
+	}
+	if len(users) == 0 {
+		return GitLabUser{}, fmt.Errorf("No GitLab user found for %s", username)
+	}
+	return users[0], nil
+}
+
+// GitLabEvent is an entry of a user's contribution events
+type GitLabEvent struct {
+	ProjectID  int    `json:"project_id"`
+	ActionName string `json:"action_name"`
+}
+
+// GetPushedProjects lists the IDs of projects a user pushed to, most
+// recent first
+func (s *GitLabScanner) GetPushedProjects(ctx context.Context, userID int) ([]int, error) {
+	page := 1
+	var ids []int
+	found := map[int]bool{}
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if s.PageLimitReached(page, fmt.Sprintf("events of user %d", userID)) {
+			break
+		}
+		url := fmt.Sprintf("%s/api/v4/users/%d/events?action=pushed&per_page=%d&page=%d", s.BaseURL, userID, s.pageSize(), page)
+		body, status, _, err := s.Get(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		if status != 200 {
+			return nil, fmt.Errorf("GitLab API error %d\n%s", status, string(body))
+		}
+
+		var events []GitLabEvent
+		if err := dossier.DecodeJSON(url, status, body, &events); err != nil {
+			return nil, err
+		}
+		if len(events) == 0 {
+			break
+		}
+		for _, e := range events {
+			if e.ProjectID != 0 && !found[e.ProjectID] {
+				found[e.ProjectID] = true
+				ids = append(ids, e.ProjectID)
+			}
+		}
+		page++
+	}
+	return ids, nil
+}
+
+func (s *GitLabScanner) GetProject(ctx context.Context, id int) (GitLabProject, error) {
+	return s.getProject(ctx, fmt.Sprint(id))
+}
+
+// getProject looks a project up by numeric ID or URL-escaped path
+func (s *GitLabScanner) getProject(ctx context.Context, ref string) (GitLabProject, error) {
+	var p GitLabProject
+	url := s.BaseURL + "/api/v4/projects/" + ref
+	body, status, _, err := s.Get(ctx, url)
+	if err != nil {
+		return p, err
+	}
+	if status != 200 {
+		return p, fmt.Errorf("GitLab API error %d\n%s", status, string(body))
+	}
+	err = dossier.DecodeJSON(url, status, body, &p)
+	return p, err
+}
+
+func (s *GitLabScanner) GetUserProjects(ctx context.Context, userID int) ([]GitLabProject, error) {
+	return s.listProjects(ctx, fmt.Sprintf("%s/api/v4/users/%d/projects?", s.BaseURL, userID), fmt.Sprintf("projects of user %d", userID))
+}
+
+// GetGroupProjects lists a group's projects, subgroups included
+func (s *GitLabScanner) GetGroupProjects(ctx context.Context, group string) ([]GitLabProject, error) {
+	return s.listProjects(ctx, s.BaseURL+"/api/v4/groups/"+url.PathEscape(group)+"/projects?include_subgroups=true&", "projects of group "+group)
+}
+
+func (s *GitLabScanner) listProjects(ctx context.Context, base, what string) ([]GitLabProject, error) {
+	page := 1
+	var projects []GitLabProject
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if s.PageLimitReached(page, what) {
+			break
+		}
+		url := fmt.Sprintf("%sper_page=%d&page=%d%s", base, s.pageSize(), page, s.repoSortQuery())
+		body, status, _, err := s.Get(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		if status != 200 {
+			return nil, fmt.Errorf("GitLab API error %d\n%s", status, string(body))
+		}
+
+		var tmp []GitLabProject
+		if err := dossier.DecodeJSON(url, status, body, &tmp); err != nil {
+			return nil, err
+		}
+		if len(tmp) == 0 {
+			break
+		}
+		projects = append(projects, tmp...)
+		page++
+	}
+	return projects, nil
+}
+
+var repoSortFields = map[string]string{
+	"updated": "updated_at",
+	"created": "created_at",
+	"pushed":  "last_activity_at",
+	"stars":   "star_count",
+}
+
+func (s *GitLabScanner) repoSortQuery() string {
+	if field, ok := repoSortFields[s.Options.RepoSort]; ok {
+		return "&order_by=" + field + "&sort=desc"
+	}
+	return ""
+}
+
+func (s *GitLabScanner) ScanProjectCommits(ctx context.Context, project GitLabProject, ascending bool) {
+	s.scanProjectCommits(ctx, project, "", ascending)
+}
+
+// commitDateQuery narrows commit listings to --since/--until. GitLab applies
+// them to the commit date, so ProcessCommits still checks the author date.
+func (s *GitLabScanner) commitDateQuery() string {
+	q := ""
+	if !s.Options.Since.IsZero() {
+		q += "&since=" + s.Options.Since.UTC().Format(time.RFC3339)
+	}
+	if !s.Options.Until.IsZero() {
+		q += "&until=" + s.Options.Until.UTC().Format(time.RFC3339)
+	}
+	return q
+}
+
+// scanProjectCommits scans a project's commits, narrowed by an extra query
+// such as "&author=name" for projects the user contributed to
+func (s *GitLabScanner) scanProjectCommits(ctx context.Context, project GitLabProject, query string, ascending bool) {
+	page := 1
+	var allCommits []GitLabCommit
+
+	urlFor := func(page int) string {
+		return fmt.Sprintf("%s/api/v4/projects/%d/repository/commits?per_page=%d&page=%d%s%s", s.BaseURL, project.ID, s.pageSize(), page, query, s.commitDateQuery())
+	}
+pages:
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		if s.PageLimitReached(page, "commits of "+project.Path) {
+			break
+		}
+		for _, p := range s.FetchPages(ctx, urlFor, page) {
+			if p.Err != nil {
+				s.RepoFailed(project.Path, len(allCommits), p.Err)
+				break pages
+			}
+			if p.Status == 403 || p.Status == 404 {
+				// Repository disabled or restricted to members, nothing to scan
+				dossier.Log.Infof("Skipping %s: commits not accessible (HTTP %d)\n", project.Path, p.Status)
+				return
+			}
+			if p.Status != 200 {
+				s.RepoFailed(project.Path, len(allCommits), fmt.Errorf("GitLab API error %d: %s", p.Status, dossier.Truncate(strings.TrimSpace(string(p.Body)), 200)))
+				break pages
+			}
+
+			var commits []GitLabCommit
+			if err := dossier.DecodeJSON(p.URL, p.Status, p.Body, &commits); err != nil {
+				s.RepoFailed(project.Path, len(allCommits), err)
+				break pages
+			}
+			if len(commits) == 0 {
+				break pages
+			}
+
+			allCommits = append(allCommits, commits...)
+			page++
+		}
+	}
+
+	// Reverse order if user requested ascending (oldest first)
+	if ascending {
+		for i, j := 0, len(allCommits)-1; i < j; i, j = i+1, j-1 {
+			allCommits[i], allCommits[j] = allCommits[j], allCommits[i]
+		}
+	}
+
+	s.ProcessCommits(s.commits(allCommits, project.WebURL))
+}
+
+// ========================== Metadata Files ==========================
+
+// ScanProjectMetadata reads well-known contributor files from the default branch
+func (s *GitLabScanner) ScanProjectMetadata(ctx context.Context, project GitLabProject) {
+	if project.DefaultBranch == "" {
+		return // empty repository
+	}
+	for _, candidates := range dossier.MetadataFiles {
+		for _, path := range candidates {
+			u := fmt.Sprintf("%s/api/v4/projects/%d/repository/files/%s/raw?ref=%s",
+				s.BaseURL, project.ID, url.PathEscape(path), url.QueryEscape(project.DefaultBranch))
+			body, status, _, err := s.Get(ctx, u)
+			if err != nil || status != 200 {
+				continue // usually a 404: no such file
+			}
+			location := fmt.Sprintf("%s/-/blob/%s/%s", project.WebURL, project.DefaultBranch, path)
+			s.ReportMetadata(path, string(body), location)
+			break
+		}
+	}
+}
+
+// ========================== Profile Mode ==========================
+
+type GitLabProfile struct {
+	Name        string `json:"name"`
+	PublicEmail string `json:"public_email"`
+	WebURL      string `json:"web_url"`
+}
+
+// ScanProfile reports the public profile email without touching any
+// commit endpoints.
+func (s *GitLabScanner) ScanProfile(ctx context.Context, username string) error {
+	userID, err := s.GetUserID(ctx, username)
+	if err != nil {
+		return fmt.Errorf("fetching user: %w", err)
+	}
+	url := fmt.Sprintf("%s/api/v4/users/%d", s.BaseURL, userID)
+	body, status, _, err := s.Get(ctx, url)
+	if err != nil {
+		return fmt.Errorf("fetching profile: %w", err)
+	}
+	if status != 200 {
+		return fmt.Errorf("fetching profile: GitLab API error %d\n%s", status, string(body))
+	}
+	var profile GitLabProfile
+	if err := dossier.DecodeJSON(url, status, body, &profile); err != nil {
+		return fmt.Errorf("fetching profile: %w", err)
+	}
+	if s.ShouldReport(profile.PublicEmail) {
+		s.Report(dossier.Finding{Type: "email", Email: profile.PublicEmail, Name: profile.Name, Location: profile.WebURL})
+	}
+	return nil
+}
+
+// ========================== Scanning ==========================
+
+// ScanUser scans a user and returns the findings reported along the way
+func (s *GitLabScanner) ScanUser(ctx context.Context, username string) ([]dossier.Finding, error) {
+	return s.Collect(func() error { return s.scanUser(ctx, username) })
+}
+
+// ScanOrg is ScanUser for a group and its subgroups
+func (s *GitLabScanner) ScanOrg(ctx context.Context, group string) ([]dossier.Finding, error) {
+	return s.Collect(func() error { return s.scanOrg(ctx, group) })
+}
+
+// ScanRepo is ScanUser for a single project ("group/project")
+func (s *GitLabScanner) ScanRepo(ctx context.Context, path string) ([]dossier.Finding, error) {
+	return s.Collect(func() error { return s.scanRepo(ctx, path) })
+}
+
+func (s *GitLabScanner) scanUser(ctx context.Context, username string) error {
+	if s.Options.ProfileOnly {
+		dossier.Log.Infof("Fetching profile emails for user: %s\n\n", username)
+		return s.ScanProfile(ctx, username)
+	}
+
+	dossier.Log.Infof("Scanning GitLab commits for user: %s\n\n", username)
+
+	user, err := s.GetUser(ctx, username)
+	if err != nil {
+		return fmt.Errorf("fetching user: %w", err)
+	}
+
+	projects, err := s.GetUserProjects(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("fetching projects: %w", err)
+	}
+
+	owned := map[int]bool{}
+	for _, p := range projects {
+		owned[p.ID] = true
+	}
+	if err := s.scanProjects(ctx, projects); err != nil {
+		return err
+	}
+
+	// Projects the user pushed to without owning them
+	if s.Options.ScanContributed {
+		pushed, err := s.GetPushedProjects(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("fetching events: %w", err)
+		}
+		scanned := 0
+		for i, id := range pushed {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.Progress.SetRepo(i+1, len(pushed))
+			if owned[id] {
+				continue
+			}
+			if s.Options.RepoLimit > 0 && scanned >= s.Options.RepoLimit {
+				dossier.Log.Warnf("Reached --repo-limit of %d projects\n", s.Options.RepoLimit)
+				break
+			}
+			p, err := s.GetProject(ctx, id)
+			if err != nil {
+				dossier.Log.Warnf("Skipping project %d: %v\n", id, err)
+				continue
+			}
+			scanned++
+			s.Stats.ReposScanned++
+			s.WithRepoBuffer(fmt.Sprintf("Scanning contributed project: %s\n", p.Path), func() {
+				s.scanProjectCommits(ctx, p, "&author="+url.QueryEscape(user.Name), s.Options.OldestFirst)
+			})
+		}
+	}
+	return nil
+}
+
+// scanOrg scans every project in a group and its subgroups
+func (s *GitLabScanner) scanOrg(ctx context.Context, group string) error {
+	dossier.Log.Infof("Scanning GitLab commits for group: %s\n\n", group)
+	projects, err := s.GetGroupProjects(ctx, group)
+	if err != nil {
+		return fmt.Errorf("fetching group projects: %w", err)
+	}
+	return s.scanProjects(ctx, projects)
+}
+
+// scanRepo scans a single project by path, e.g. "group/project"
+func (s *GitLabScanner) scanRepo(ctx context.Context, path string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	p, err := s.getProject(ctx, url.PathEscape(path))
+	if err != nil {
+		return fmt.Errorf("fetching project: %w", err)
+	}
+	return s.scanProjects(ctx, []GitLabProject{p})
+}
+
+// scanProjects scans the given projects, skipping forks, disabled repos and
+// inactive ones, up to --repo-limit
+func (s *GitLabScanner) scanProjects(ctx context.Context, projects []GitLabProject) error {
+	scanned := 0
+	for i, p := range projects {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.Progress.SetRepo(i+1, len(projects))
+		if p.ForkedFromProject != nil {
+			continue // skip forks
+		}
+		if p.RepositoryAccessLevel == "disabled" {
+			dossier.Log.Infof("Skipping %s: repository feature is disabled\n", p.Path)
+			continue
+		}
+		if !s.Options.ActiveSince.IsZero() && p.LastActivityAt.Before(s.Options.ActiveSince) {
+			dossier.Log.Infof("Skipping %s: no activity since %s\n", p.Path, s.Options.ActiveSince.Format("2006-01-02"))
+			continue
+		}
+		if s.Options.RepoLimit > 0 && scanned >= s.Options.RepoLimit {
+			dossier.Log.Warnf("Reached --repo-limit of %d projects\n", s.Options.RepoLimit)
+			break
+		}
+		scanned++
+		s.Stats.ReposScanned++
+		s.WithRepoBuffer(fmt.Sprintf("Scanning project: %s\n", p.Path), func() {
+			if s.Options.MatchRepos {
+				s.ReportRepoMatches(p.Name, p.Description, p.Topics, p.WebURL)
+			}
+			s.ScanProjectCommits(ctx, p, s.Options.OldestFirst)
+			if s.Options.ScanMetadata {
+				s.ScanProjectMetadata(ctx, p)
+			}
+		})
+	}
+	return nil
+}
+
+// ========================== Main ==========================
+
+// Main runs the GitLab command line tool
+func Main() {
+	s := NewScanner("", nil, nil)
+	if v := os.Getenv("GITLAB_URL"); v != "" {
+		s.BaseURL = v
+	}
+	cmd := dossier.NewCommand(s.Engine)
+	flag.BoolVar(&s.Options.ScanContributed, "include-contributed", false, "also scan the user's own commits in projects they pushed to but don't own, found via events")
+	flag.IntVar(&s.Options.Prefetch, "prefetch", 0, "commit pages to fetch ahead concurrently within a repo")
+	flag.IntVar(&s.Options.PerPage, "per-page", s.Options.PerPage, fmt.Sprintf("items requested per page of a listing (1-%d)", maxPerPage))
+	flag.IntVar(&s.Options.RepoLimit, "repo-limit", 0, "only scan the first N repos (0 = all), in --repo-sort order")
+	flag.StringVar(&s.Options.RepoSort, "repo-sort", "", "order repos by updated, created, pushed or stars before scanning")
+	flag.BoolVar(&s.Options.CommitterToo, "committer-too", false, "with --author-email-only, include committer emails as well")
+	repoFlag := flag.String("repo", "", "scan only this repository (group/project) instead of all of a user's repos")
+	flag.StringVar(&s.BaseURL, "api-base", s.BaseURL, "GitLab instance URL, e.g. https://gitlab.example.com for a self-managed GitLab (defaults to $GITLAB_URL, then https://gitlab.com)")
+	gitlabHost := flag.String("gitlab-host", "", "host of a self-managed GitLab, e.g. gitlab.example.com; shorthand for --api-base https://{host}")
+	cmd.Parse()
+	if _, ok := repoSortFields[s.Options.RepoSort]; s.Options.RepoSort != "" && !ok {
+		dossier.Log.Errorf("Invalid --repo-sort %q (want updated, created, pushed or stars)\n", s.Options.RepoSort)
+		os.Exit(1)
+	}
+	if s.Options.CommitterToo && !s.Options.EmailOnly {
+		dossier.Log.Errorln("--committer-too requires --author-email-only")
+		os.Exit(1)
+	}
+	if s.Options.Prefetch < 0 {
+		dossier.Log.Errorln("Error: --prefetch must not be negative")
+		os.Exit(1)
+	}
+	if n := s.pageSize(); n != s.Options.PerPage {
+		dossier.Log.Warnf("⚠️  --per-page %d is outside 1-%d, using %d\n", s.Options.PerPage, maxPerPage, n)
+	}
+	if *gitlabHost != "" {
+		apiBaseSet := false
+		flag.Visit(func(f *flag.Flag) { apiBaseSet = apiBaseSet || f.Name == "api-base" })
+		if apiBaseSet {
+			dossier.Log.Errorln("--gitlab-host cannot be combined with --api-base")
+			os.Exit(1)
+		}
+		// Only a host and optional port: no scheme, credentials or path
+		if u, err := url.Parse("https://" + *gitlabHost); err != nil || u.Host != *gitlabHost || u.Hostname() == "" {
+			dossier.Log.Errorf("Invalid --gitlab-host %q (want a host name such as gitlab.example.com)\n", *gitlabHost)
+			os.Exit(1)
+		}
+		s.BaseURL = "https://" + *gitlabHost
+	}
+	s.BaseURL = strings.TrimRight(s.BaseURL, "/")
+	if u, err := url.Parse(s.BaseURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		dossier.Log.Errorf("Invalid --api-base %q (want an http(s) URL such as https://gitlab.example.com)\n", s.BaseURL)
+		os.Exit(1)
+	}
+	if flag.NArg() < 1 && *repoFlag == "" {
+		dossier.Log.Errorln("Usage: go run ./cmd/gitlab [flags] <gitlab-username>")
+		dossier.Log.Errorln("       go run ./cmd/gitlab --repo group/project [flags]")
+		dossier.Log.Errorln("       go run ./cmd/gitlab schema    (print the signatures.yaml JSON Schema)")
+		os.Exit(1)
+	}
+	username := flag.Arg(0)
+	scan := s.scanUser
+	if *repoFlag != "" {
+		if flag.NArg() > 0 {
+			dossier.Log.Errorln("--repo cannot be combined with a username")
+			os.Exit(1)
+		}
+		username = *repoFlag
+		scan = s.scanRepo
+	}
+
+	tokens, err := dossier.LoadTokens(".env")
+	if err != nil {
+		dossier.Log.Errorln("Error reading .env:", err)
+		os.Exit(1)
+	}
+	s.Token = tokens.GitLab
+	if s.Token != "" {
+		dossier.Log.Infoln("🔑 Found GitLab token in .env or the environment!")
+	} else {
+		dossier.Log.Warnln("⚠️  No GitLab personal access token found in env, running unauthenticated (with rate limits)")
+	}
+
+	cmd.Run(username, scan)
+}
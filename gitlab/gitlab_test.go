@@ -0,0 +1,156 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/0x4f53/dossier"
+)
+
+// doerFunc lets a function stand in for the HTTP client
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
+
+func respond(req *http.Request, status int, body string) (*http.Response, error) {
+	return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+}
+
+func TestScanOrgSkipsDisabledRepositories(t *testing.T) {
+	s := NewScanner("", nil, nil)
+	s.Reporter = nil
+	var fetched []string
+	s.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+		path := req.URL.EscapedPath()
+		switch {
+		case path == "/api/v4/groups/acme/projects":
+			if req.URL.Query().Get("page") != "1" {
+				return respond(req, 200, "[]")
+			}
+			return respond(req, 200, `[
+				{"id":1,"path_with_namespace":"acme/cli","repository_access_level":"enabled"},
+				{"id":2,"path_with_namespace":"acme/wiki-only","repository_access_level":"disabled"},
+				{"id":3,"path_with_namespace":"acme/lib"}]`)
+		case strings.HasSuffix(path, "/repository/commits"):
+			fetched = append(fetched, path)
+			return respond(req, 200, "[]")
+		}
+		t.Errorf("unexpected request %s", req.URL)
+		return respond(req, 404, `{"message":"404 Not Found"}`)
+	})
+
+	if _, err := s.ScanOrg(context.Background(), "acme"); err != nil {
+		t.Fatal(err)
+	}
+	want := "/api/v4/projects/1/repository/commits /api/v4/projects/3/repository/commits"
+	if got := strings.Join(fetched, " "); got != want {
+		t.Errorf("fetched commits of %s, want %s", got, want)
+	}
+	if s.Stats.ReposScanned != 2 {
+		t.Errorf("repos scanned = %d, want 2", s.Stats.ReposScanned)
+	}
+}
+
+// authorOrder lists the author email findings in the order they were reported
+func authorOrder(findings []dossier.Finding) string {
+	var emails []string
+	for _, f := range findings {
+		if f.Type == "email" && f.Signature == "" {
+			emails = append(emails, f.Email)
+		}
+	}
+	return strings.Join(emails, " ")
+}
+
+func TestOrderAcrossPages(t *testing.T) {
+	commit := func(n string) string {
+		return `{"id":"c` + n + `","author_name":"C` + n + `","author_email":"c` + n + `@acme.io","authored_date":"2024-05-0` + n + `T10:00:00Z","message":"Change ` + n + `"}`
+	}
+	pages := map[string]string{"1": "[" + commit("3") + "," + commit("2") + "]", "2": "[" + commit("1") + "]"}
+	cfg, err := dossier.DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		oldestFirst bool
+		want        string
+	}{
+		{false, "c3@acme.io c2@acme.io c1@acme.io"},
+		{true, "c1@acme.io c2@acme.io c3@acme.io"},
+	} {
+		s := NewScanner("", cfg, nil)
+		c := &dossier.Collector{}
+		s.Reporter = c
+		s.Options.PerPage = 2
+		s.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+			if body, ok := pages[req.URL.Query().Get("page")]; ok {
+				return respond(req, 200, body)
+			}
+			return respond(req, 200, "[]")
+		})
+		s.ScanProjectCommits(context.Background(), GitLabProject{ID: 1, Path: "acme/cli"}, tt.oldestFirst)
+		if got := authorOrder(c.Findings); got != tt.want {
+			t.Errorf("oldest first %v: order %s, want %s", tt.oldestFirst, got, tt.want)
+		}
+		if s.Stats.CommitsProcessed != 3 {
+			t.Errorf("oldest first %v: processed %d commits, want 3", tt.oldestFirst, s.Stats.CommitsProcessed)
+		}
+	}
+}
+
+func TestPrefetchProcessesEachPageOnce(t *testing.T) {
+	cfg, err := dossier.DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 7 commits, two per page, newest first: c7 c6 | c5 c4 | c3 c2 | c1
+	var mu sync.Mutex
+	requests := map[int]int{}
+	s := NewScanner("", cfg, nil)
+	c := &dossier.Collector{}
+	s.Reporter = c
+	s.Options.PerPage = 2
+	s.Options.Prefetch = 3
+	s.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+		page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+		mu.Lock()
+		requests[page]++
+		mu.Unlock()
+		var items []string
+		for n := 7 - 2*(page-1); n > 7-2*page && n > 0; n-- {
+			items = append(items, fmt.Sprintf(`{"id":"c%d","author_name":"C%d","author_email":"c%d@acme.io","authored_date":"2024-05-01T10:00:00Z","message":"Change"}`, n, n, n))
+		}
+		return respond(req, 200, "["+strings.Join(items, ",")+"]")
+	})
+	s.ScanProjectCommits(context.Background(), GitLabProject{ID: 1, Path: "acme/cli"}, false)
+
+	if got, want := authorOrder(c.Findings), "c7@acme.io c6@acme.io c5@acme.io c4@acme.io c3@acme.io c2@acme.io c1@acme.io"; got != want {
+		t.Errorf("order %s, want %s", got, want)
+	}
+	if s.Stats.CommitsProcessed != 7 {
+		t.Errorf("processed %d commits, want 7", s.Stats.CommitsProcessed)
+	}
+	for page, n := range requests {
+		if n != 1 {
+			t.Errorf("page %d requested %d times", page, n)
+		}
+	}
+}
+
+func TestPageSizeIsClamped(t *testing.T) {
+	for _, tt := range []struct{ perPage, want int }{
+		{-5, 1}, {0, 1}, {1, 1}, {50, 50}, {100, 100}, {101, 100}, {1000, 100},
+	} {
+		s := NewScanner("", nil, nil)
+		s.Options.PerPage = tt.perPage
+		if got := s.pageSize(); got != tt.want {
+			t.Errorf("--per-page %d: page size %d, want %d", tt.perPage, got, tt.want)
+		}
+	}
+}
@@ -1,7 +1,11 @@
-module dossier
+module github.com/0x4f53/dossier
 
 go 1.24.4
 
-require gopkg.in/yaml.v3 v3.0.1
+require (
+	golang.org/x/crypto v0.41.0
+	golang.org/x/net v0.43.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
-require golang.org/x/net v0.43.0 // indirect
+require golang.org/x/sys v0.35.0 // indirect
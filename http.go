@@ -0,0 +1,350 @@
+package dossier
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ========================== Retries ==========================
+
+type RetryPolicy struct {
+	MaxAttempts int // total tries, including the first
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Jitter      float64 // fraction of each delay that is randomised, 0-1
+}
+
+// Delay returns the wait before retry number attempt (1 for the first
+// retry): exponential from BaseDelay, capped at MaxDelay, then jittered.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	d := p.MaxDelay
+	if attempt < 1 {
+		attempt = 1
+	}
+	if attempt <= 30 {
+		if exp := p.BaseDelay << (attempt - 1); exp > 0 && exp < p.MaxDelay {
+			d = exp
+		}
+	}
+	if p.Jitter > 0 {
+		spread := float64(d) * p.Jitter
+		d = time.Duration(float64(d) - spread + rand.Float64()*2*spread)
+	}
+	return d
+}
+
+// ShouldRetry retries 5xx responses and transient network errors; other
+// statuses such as 401, 404 and 422 won't change on a second try
+func (p RetryPolicy) ShouldRetry(attempt, status int, err error) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+	if err != nil {
+		return transient(err)
+	}
+	return status >= 500
+}
+
+// transient reports whether a request error may go away on its own:
+// timeouts, dropped or refused connections and temporary DNS failures, but
+// not bad certificates, refused redirects or cancellation
+func transient(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	for _, target := range []error{context.DeadlineExceeded, io.EOF, io.ErrUnexpectedEOF,
+		syscall.ECONNRESET, syscall.ECONNREFUSED, syscall.ECONNABORTED, syscall.EPIPE} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary || dnsErr.IsTimeout
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// Sleep waits for d, returning early with the context's error if it is
+// cancelled first
+func Sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// ========================== HTTP Helpers ==========================
+
+// NewHTTPClient returns a client that won't hang on a dead connection or a
+// stalled handshake. The overall per-request deadline comes from the
+// request's context.
+func NewHTTPClient(checkRedirect func(*http.Request, []*http.Request) error) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = 10 * time.Second
+	transport.MaxIdleConnsPerHost = 16 // --prefetch requests several pages of one host at once
+	return &http.Client{Transport: transport, CheckRedirect: checkRedirect}
+}
+
+// DecodeJSON wraps decode failures with the URL, status and the start of
+// the body, so an HTML rate-limit or maintenance page is recognisable.
+func DecodeJSON(url string, status int, body []byte, v any) error {
+	if err := json.Unmarshal(body, v); err != nil {
+		snippet := strings.TrimSpace(string(body))
+		if len(snippet) > 200 {
+			snippet = snippet[:200] + "..."
+		}
+		return fmt.Errorf("decoding %s (HTTP %d): %v\nResponse starts with: %s", url, status, err, snippet)
+	}
+	return nil
+}
+
+// ========================== Fetching ==========================
+
+// Doer sends HTTP requests; *http.Client satisfies it, tests can swap in a fake
+type Doer interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// checkRedirect keeps redirects on the original host unless the target is
+// explicitly trusted, and never forwards credentials off the original
+// host or onto plain http.
+func (e *Engine) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	orig := via[0].URL
+	if req.URL.Host == orig.Host && req.URL.Scheme == orig.Scheme {
+		return nil
+	}
+	if req.URL.Host != orig.Host && !e.TrustedRedirectHosts[strings.ToLower(req.URL.Hostname())] {
+		return fmt.Errorf("refusing redirect from %s to untrusted host %s", orig.Host, req.URL.Host)
+	}
+	req.Header.Del("Authorization")
+	req.Header.Del("PRIVATE-TOKEN")
+	return nil
+}
+
+// Get fetches url; see Fetch
+func (e *Engine) Get(ctx context.Context, url string) ([]byte, int, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return e.Fetch(req)
+}
+
+// Fetch sends req with the provider's credentials and returns the response,
+// answering from the in-memory or disk cache while they hold a fresh copy
+// and revalidating stale copies with their ETags. Rate limits are waited
+// out up to --max-rate-limit-wait and transient failures retried per
+// Options.Retry; only 200s are cached.
+func (e *Engine) Fetch(req *http.Request) ([]byte, int, http.Header, error) {
+	ctx, url := req.Context(), req.URL.String()
+	key, err := cacheKey(req)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	mem, fresh := e.Responses.Get(key)
+	if fresh {
+		Log.Debugf("Cached: %s\n", url)
+		e.count(func(s *ScanStats) { s.ResponsesReused++ })
+		return mem.body, mem.status, mem.header, nil
+	}
+	diskKey := e.diskKey(req, key)
+	cached, fresh := e.Disk.Get(diskKey)
+	if fresh {
+		Log.Debugf("Cached on disk: %s\n", url)
+		e.count(func(s *ScanStats) { s.ResponsesFromDisk++ })
+		e.Responses.Put(key, cached.Body, cached.Status, cached.Header())
+		return cached.Body, cached.Status, cached.Header(), nil
+	}
+	if v := mem.validator(); v != nil {
+		cached = v // newer than anything on disk
+	}
+	waits := 0
+	for attempt := 1; ; attempt++ {
+		body, status, header, err := e.send(req, cached)
+		if err == nil && status == http.StatusNotModified && cached != nil {
+			Log.Debugf("Not modified: %s\n", url)
+			e.count(func(s *ScanStats) { s.NotModified++ })
+			body, status = cached.Body, cached.Status
+		}
+		if wait, limited := rateLimitWait(status, header, time.Now()); limited && waits < maxRateLimitWaits {
+			if wait > e.Options.MaxRateLimitWait {
+				Log.Warnf("⚠️  Rate limited on %s; the limit resets in %s, beyond --max-rate-limit-wait\n", url, wait.Round(time.Second))
+				return body, status, header, err
+			}
+			Log.Warnf("⚠️  Rate limited on %s, waiting %s for the limit to reset\n", url, wait.Round(time.Second))
+			if err := Sleep(ctx, wait); err != nil {
+				return nil, 0, nil, err
+			}
+			waits++
+			attempt-- // waiting out a rate limit doesn't use up a retry
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil, 0, nil, ctx.Err() // cancelled, not worth retrying
+		}
+		if !e.Options.Retry.ShouldRetry(attempt, status, err) {
+			if err == nil && status == 200 {
+				e.Responses.Put(key, body, status, header)
+				e.Disk.Put(diskKey, body, status, header)
+			}
+			return body, status, header, err
+		}
+		delay := e.Options.Retry.Delay(attempt)
+		if err != nil {
+			Log.Warnf("⚠️  Request to %s failed (%v), retrying in %s\n", url, err, delay.Round(time.Millisecond))
+		} else {
+			Log.Warnf("⚠️  %s returned HTTP %d, retrying in %s\n", url, status, delay.Round(time.Millisecond))
+		}
+		if err := Sleep(ctx, delay); err != nil {
+			return nil, 0, nil, err
+		}
+	}
+}
+
+// cacheKey names a request in the caches: its URL, plus the body for POSTs
+// such as GraphQL queries, which all go to one endpoint
+func cacheKey(req *http.Request) (string, error) {
+	if req.GetBody == nil {
+		return req.URL.String(), nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	return req.URL.String() + "\n" + string(data), err
+}
+
+// diskKey adds a hash of the credentials Authorize sends to key. The disk
+// cache outlives the run, and a response fetched with one token must not be
+// served to a later run with another token or with none.
+func (e *Engine) diskKey(req *http.Request, key string) string {
+	if e.Authorize == nil {
+		return key
+	}
+	r := &http.Request{Method: req.Method, URL: req.URL, Header: http.Header{}}
+	e.Authorize(r)
+	creds := r.Header.Get("Authorization") + "\n" + r.Header.Get("PRIVATE-TOKEN")
+	if creds == "\n" {
+		return key
+	}
+	sum := sha256.Sum256([]byte(creds))
+	return key + "\ncredentials " + hex.EncodeToString(sum[:])
+}
+
+// send makes one attempt at req, conditional on cached when it is set
+func (e *Engine) send(req *http.Request, cached *DiskEntry) ([]byte, int, http.Header, error) {
+	ctx := req.Context()
+	if e.Options.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Options.RequestTimeout)
+		defer cancel() // after the body has been read
+	}
+	r := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		r.Body = body
+	}
+	if e.Authorize != nil {
+		e.Authorize(r)
+	}
+	cached.Validate(r)
+	Log.Debugf("%s %s\n", r.Method, r.URL)
+	resp, err := e.Doer.Do(r)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	e.count(func(s *ScanStats) {
+		s.PagesFetched++
+		for _, h := range []string{"X-RateLimit-Remaining", "RateLimit-Remaining"} {
+			if v := resp.Header.Get(h); v != "" {
+				s.RateLimitRemaining = v
+			}
+		}
+	})
+	return body, resp.StatusCode, resp.Header, nil
+}
+
+// A request keeps waiting out rate limits at most this many times, in case
+// the reset headers are wrong
+const maxRateLimitWaits = 3
+
+// rateLimitWait reports whether a response is a rate limit and how long to
+// wait before retrying: Retry-After for secondary limits, otherwise until
+// X-RateLimit-Reset once X-RateLimit-Remaining has hit zero.
+func rateLimitWait(status int, h http.Header, now time.Time) (time.Duration, bool) {
+	if status != http.StatusForbidden && status != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(h.Get("Retry-After")); err == nil {
+		return max(time.Duration(secs)*time.Second, time.Second), true
+	}
+	if h.Get("X-RateLimit-Remaining") != "0" {
+		return 0, false // an ordinary 403, e.g. a blocked or private resource
+	}
+	reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return max(time.Unix(reset, 0).Sub(now)+time.Second, time.Second), true
+}
+
+// Page is one response of a concurrently fetched batch
+type Page struct {
+	URL    string
+	Body   []byte
+	Status int
+	Err    error
+}
+
+// FetchPages requests a batch of consecutive pages at once, 1+--prefetch of
+// them within --max-pages, and returns them in page order. Each request
+// still goes through Fetch's backoff.
+func (e *Engine) FetchPages(ctx context.Context, urlFor func(page int) string, first int) []Page {
+	n := 1 + e.Options.Prefetch
+	if e.Options.MaxPages > 0 && first+n-1 > e.Options.MaxPages {
+		n = e.Options.MaxPages - first + 1
+	}
+	pages := make([]Page, n)
+	var wg sync.WaitGroup
+	for i := range pages {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			url := urlFor(first + i)
+			body, status, _, err := e.Get(ctx, url)
+			pages[i] = Page{url, body, status, err}
+		}(i)
+	}
+	wg.Wait()
+	return pages
+}
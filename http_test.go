@@ -0,0 +1,228 @@
+package dossier
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestDecodeJSONReportsHTMLBody(t *testing.T) {
+	page := "<!DOCTYPE html>\n<html><head><title>Unicorn! · GitHub</title></head><body>" + strings.Repeat("x", 300) + "</body></html>"
+	var v struct{}
+	err := DecodeJSON("https://api.github.com/users/alice", 503, []byte(page), &v)
+	if err == nil {
+		t.Fatal("no error decoding HTML")
+	}
+	msg := err.Error()
+	for _, want := range []string{
+		"decoding https://api.github.com/users/alice (HTTP 503): invalid character '<'",
+		"\nResponse starts with: <!DOCTYPE html>\n<html><head><title>Unicorn! · GitHub</title>",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q does not contain %q", msg, want)
+		}
+	}
+	if !strings.HasSuffix(msg, "...") || strings.Contains(msg, "</html>") {
+		t.Errorf("error %q should cut the body off after 200 bytes", msg)
+	}
+}
+
+func TestCheckRedirectDropsCredentialsOffHost(t *testing.T) {
+	e := NewEngine("gitlab", nil, nil)
+	e.TrustedRedirectHosts["objects.example.com"] = true
+	request := func(url string) *http.Request {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("PRIVATE-TOKEN", "glpat-secret")
+		return req
+	}
+	via := []*http.Request{request("https://gitlab.example.com/api/v4/projects")}
+
+	tests := []struct {
+		name, to  string
+		wantErr   bool
+		wantCreds bool
+	}{
+		{"same host", "https://gitlab.example.com/api/v4/projects?page=2", false, true},
+		{"trusted host", "https://objects.example.com/blob", false, false},
+		{"untrusted host", "https://evil.example.net/steal", true, false},
+		{"downgrade to http", "http://gitlab.example.com/api/v4/projects", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := request(tt.to)
+			err := e.checkRedirect(req, via)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			auth, token := req.Header.Get("Authorization"), req.Header.Get("PRIVATE-TOKEN")
+			if tt.wantCreds && (auth == "" || token == "") {
+				t.Error("credentials dropped on the same host")
+			}
+			if !tt.wantCreds && (auth != "" || token != "") {
+				t.Errorf("credentials forwarded: Authorization %q, PRIVATE-TOKEN %q", auth, token)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: 30 * time.Second}
+	for attempt, want := range map[int]time.Duration{
+		0:  time.Second, // treated as the first retry
+		1:  time.Second,
+		2:  2 * time.Second,
+		3:  4 * time.Second,
+		5:  16 * time.Second,
+		6:  30 * time.Second, // 32s capped
+		40: 30 * time.Second, // shift overflow capped too
+	} {
+		if got := p.Delay(attempt); got != want {
+			t.Errorf("Delay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+
+	p.Jitter = 0.2
+	for i := 0; i < 100; i++ {
+		if d := p.Delay(3); d < 3200*time.Millisecond || d > 4800*time.Millisecond {
+			t.Fatalf("jittered Delay(3) = %v, want within 20%% of 4s", d)
+		}
+	}
+}
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 3}
+	timeout := &net.DNSError{Err: "i/o timeout", IsTimeout: true}
+	tests := []struct {
+		name    string
+		attempt int
+		status  int
+		err     error
+		want    bool
+	}{
+		{"503", 1, 503, nil, true},
+		{"500 on the second try", 2, 500, nil, true},
+		{"attempts used up", 3, 503, nil, false},
+		{"404", 1, 404, nil, false},
+		{"401", 1, 401, nil, false},
+		{"422", 1, 422, nil, false},
+		{"200", 1, 200, nil, false},
+		{"connection reset", 1, 0, &net.OpError{Op: "read", Err: syscall.ECONNRESET}, true},
+		{"unexpected EOF", 1, 0, io.ErrUnexpectedEOF, true},
+		{"deadline", 1, 0, context.DeadlineExceeded, true},
+		{"dns timeout", 1, 0, timeout, true},
+		{"no such host", 1, 0, &net.DNSError{Err: "no such host", IsNotFound: true}, false},
+		{"cancelled", 1, 0, context.Canceled, false},
+		{"bad certificate", 1, 0, x509.UnknownAuthorityError{}, false},
+		{"refused redirect", 1, 0, errors.New("refusing redirect from a to b"), false},
+		{"network error after the last attempt", 3, 0, io.EOF, false},
+	}
+	for _, tt := range tests {
+		if got := p.ShouldRetry(tt.attempt, tt.status, tt.err); got != tt.want {
+			t.Errorf("%s: ShouldRetry = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	now := time.Unix(1714557600, 0)
+	tests := []struct {
+		name        string
+		status      int
+		header      http.Header
+		wantWait    time.Duration
+		wantLimited bool
+	}{
+		{"limit used up", 403, http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"1714557660"}}, 61 * time.Second, true},
+		{"reset already passed", 403, http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"1714557000"}}, time.Second, true},
+		{"secondary limit", 403, http.Header{"Retry-After": {"30"}}, 30 * time.Second, true},
+		{"429 with Retry-After 0", 429, http.Header{"Retry-After": {"0"}}, time.Second, true},
+		{"requests left", 403, http.Header{"X-Ratelimit-Remaining": {"12"}, "X-Ratelimit-Reset": {"1714557660"}}, 0, false},
+		{"plain 403", 403, http.Header{}, 0, false},
+		{"no reset", 403, http.Header{"X-Ratelimit-Remaining": {"0"}}, 0, false},
+		{"200 at zero", 200, http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"1714557660"}}, 0, false},
+	}
+	for _, tt := range tests {
+		wait, limited := rateLimitWait(tt.status, tt.header, now)
+		if wait != tt.wantWait || limited != tt.wantLimited {
+			t.Errorf("%s: rateLimitWait = %v, %v; want %v, %v", tt.name, wait, limited, tt.wantWait, tt.wantLimited)
+		}
+	}
+}
+
+func TestRateLimitBeyondMaxWaitGivesUp(t *testing.T) {
+	e := NewEngine("github", nil, nil)
+	e.Options.MaxRateLimitWait = time.Minute
+	requests := 0
+	e.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		reset := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+		return respond(req, 403, http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {reset}}, `{"message":"API rate limit exceeded"}`)
+	})
+	start := time.Now()
+	_, status, _, err := e.Get(context.Background(), "https://api.github.com/users/alice")
+	if err != nil || status != 403 || requests != 1 {
+		t.Errorf("status %d, err %v after %d requests; want the 403 back at once", status, err, requests)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Error("slept on a reset beyond --max-rate-limit-wait")
+	}
+}
+
+func TestFetchRetriesTransientFailures(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int // per attempt; the last one repeats
+		wantStatus   int
+		wantRequests int
+	}{
+		{"503 twice then 200", []int{503, 503, 200}, 200, 3},
+		{"503 past the last attempt", []int{503}, 503, 3},
+		{"404 fails fast", []int{404, 200}, 404, 1},
+	}
+	for _, tt := range tests {
+		e := NewEngine("github", nil, nil)
+		e.Options.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+		requests := 0
+		e.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+			status := tt.statuses[min(requests, len(tt.statuses)-1)]
+			requests++
+			return respond(req, status, nil, `{"attempt":`+strconv.Itoa(requests)+`}`)
+		})
+		body, status, _, err := e.Get(context.Background(), "https://api.github.com/users/alice")
+		if err != nil || status != tt.wantStatus || requests != tt.wantRequests {
+			t.Errorf("%s: status %d, err %v after %d requests; want %d after %d", tt.name, status, err, requests, tt.wantStatus, tt.wantRequests)
+		}
+		if want := `{"attempt":` + strconv.Itoa(requests) + `}`; string(body) != want {
+			t.Errorf("%s: body = %s, want the last response %s", tt.name, body, want)
+		}
+	}
+
+	// A dropped connection is retried like a 5xx
+	e := NewEngine("github", nil, nil)
+	e.Options.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	requests := 0
+	e.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+		if requests++; requests == 1 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return respond(req, 200, nil, `{}`)
+	})
+	if _, status, _, err := e.Get(context.Background(), "https://api.github.com/users/alice"); err != nil || status != 200 || requests != 2 {
+		t.Errorf("after a dropped connection: status %d, err %v after %d requests; want 200 after 2", status, err, requests)
+	}
+}
@@ -0,0 +1,347 @@
+package dossier
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// ========================== Identities ==========================
+
+// Identity aggregates every commit seen under one email address
+type Identity struct {
+	Email     string
+	Names     map[string]int  // display name -> number of commits using it
+	Languages map[string]int  // commit message language -> count (--detect-language)
+	Sources   map[string]bool // repos the email was seen in
+	Commits   int
+	FirstSeen time.Time
+	LastSeen  time.Time
+
+	Timed         int            // commits with a known time
+	BusinessHours int            // of those, weekdays 09:00-18:00 in the commit's own offset
+	Offsets       map[string]int // UTC offset ("+01:00") -> commits
+
+	Signed      int            // commits carrying a GPG or SSH signature
+	SigningKeys map[string]int // key ("GPG key ABCD...") -> signed commits
+}
+
+// IdentityStore groups commits by email, case-insensitively. With
+// NormalizeNames (--normalize-names) display names are cleaned before they
+// are counted.
+type IdentityStore struct {
+	NormalizeNames bool
+
+	byEmail map[string]*Identity
+}
+
+func NewIdentityStore() *IdentityStore {
+	return &IdentityStore{byEmail: map[string]*Identity{}}
+}
+
+func (s *IdentityStore) Add(email, name, source string, when time.Time) {
+	key := strings.ToLower(email)
+	id, ok := s.byEmail[key]
+	if !ok {
+		id = &Identity{Email: email, Names: map[string]int{}, Languages: map[string]int{}, Sources: map[string]bool{}, Offsets: map[string]int{}, SigningKeys: map[string]int{}}
+		s.byEmail[key] = id
+	}
+	if s.NormalizeNames {
+		name = NormalizeName(name)
+	}
+	if name = strings.TrimSpace(name); name != "" {
+		id.Names[name]++
+	}
+	if source != "" {
+		id.Sources[source] = true
+	}
+	id.Commits++
+	if !when.IsZero() {
+		if id.FirstSeen.IsZero() || when.Before(id.FirstSeen) {
+			id.FirstSeen = when
+		}
+		if when.After(id.LastSeen) {
+			id.LastSeen = when
+		}
+		id.Timed++
+		id.Offsets[when.Format("-07:00")]++
+		if wd := when.Weekday(); wd != time.Saturday && wd != time.Sunday && when.Hour() >= 9 && when.Hour() < 18 {
+			id.BusinessHours++
+		}
+	}
+}
+
+// Reset forgets every identity, e.g. between --watch cycles, while reporters
+// holding the store keep seeing the current one
+func (s *IdentityStore) Reset() {
+	s.byEmail = map[string]*Identity{}
+}
+
+// AddSignature records a signed commit; key may be empty when the
+// signature could not be attributed.
+func (s *IdentityStore) AddSignature(email, key string) {
+	if id, ok := s.byEmail[strings.ToLower(email)]; ok {
+		id.Signed++
+		if key != "" {
+			id.SigningKeys[key]++
+		}
+	}
+}
+
+// SigningKey is the key behind most of the identity's signed commits
+func (id *Identity) SigningKey() string {
+	best, n := "", 0
+	for k, c := range id.SigningKeys {
+		if c > n || (c == n && k < best) {
+			best, n = k, c
+		}
+	}
+	return best
+}
+
+// AddLanguage only counts towards identities that were already reported
+func (s *IdentityStore) AddLanguage(email, lang string) {
+	if id, ok := s.byEmail[strings.ToLower(email)]; ok && lang != "" {
+		id.Languages[lang]++
+	}
+}
+
+func (s *IdentityStore) Identities() []*Identity {
+	ids := make([]*Identity, 0, len(s.byEmail))
+	for _, id := range s.byEmail {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return strings.ToLower(ids[i].Email) < strings.ToLower(ids[j].Email)
+	})
+	return ids
+}
+
+// names returns the display names most frequent first; ties go to the
+// longer (usually fuller) name. Names differing only in case count as one,
+// shown in their most common spelling.
+func (id *Identity) names() []string {
+	counts := map[string]int{}      // lowercased name -> commits
+	spelling := map[string]string{} // lowercased name -> most used casing
+	for n, c := range id.Names {
+		key := strings.ToLower(n)
+		counts[key] += c
+		if best, ok := spelling[key]; !ok || c > id.Names[best] || (c == id.Names[best] && n < best) {
+			spelling[key] = n
+		}
+	}
+	names := make([]string, 0, len(spelling))
+	for _, n := range spelling {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := names[i], names[j]
+		if ca, cb := counts[strings.ToLower(a)], counts[strings.ToLower(b)]; ca != cb {
+			return ca > cb
+		}
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return a < b
+	})
+	return names
+}
+
+func (id *Identity) SourceList() []string {
+	sources := make([]string, 0, len(id.Sources))
+	for src := range id.Sources {
+		sources = append(sources, src)
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+func (id *Identity) CanonicalName() string {
+	if names := id.names(); len(names) > 0 {
+		return names[0]
+	}
+	return ""
+}
+
+func (id *Identity) Aliases() []string {
+	if names := id.names(); len(names) > 1 {
+		return names[1:]
+	}
+	return nil
+}
+
+// LanguageBreakdown renders --detect-language counts as "en 80%, de 20%"
+func LanguageBreakdown(counts map[string]int) string {
+	total := 0
+	langs := make([]string, 0, len(counts))
+	for lang, n := range counts {
+		total += n
+		langs = append(langs, lang)
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if counts[langs[i]] != counts[langs[j]] {
+			return counts[langs[i]] > counts[langs[j]]
+		}
+		return langs[i] < langs[j]
+	})
+	parts := make([]string, len(langs))
+	for i, lang := range langs {
+		parts[i] = fmt.Sprintf("%s %.0f%%", lang, percent(counts[lang], total))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
+
+// ========================== Name Similarity ==========================
+
+type NameMatch struct {
+	A, B  *Identity
+	Score float64
+}
+
+// SimilarNames suggests identities with different emails whose display
+// names look like the same person. Nothing is merged.
+func (s *IdentityStore) SimilarNames(threshold float64) []NameMatch {
+	ids := s.Identities()
+	var matches []NameMatch
+	for i := range ids {
+		a := strings.ToLower(ids[i].CanonicalName())
+		if a == "" {
+			continue
+		}
+		for j := i + 1; j < len(ids); j++ {
+			b := strings.ToLower(ids[j].CanonicalName())
+			if b == "" {
+				continue
+			}
+			if score := JaroWinkler(a, b); score >= threshold {
+				matches = append(matches, NameMatch{A: ids[i], B: ids[j], Score: score})
+			}
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches
+}
+
+// ========================== Profile Inference ==========================
+
+// Addresses at these domains say nothing about an employer
+var freemailDomains = map[string]bool{
+	"gmail.com": true, "googlemail.com": true, "outlook.com": true, "hotmail.com": true,
+	"live.com": true, "msn.com": true, "yahoo.com": true, "ymail.com": true,
+	"icloud.com": true, "me.com": true, "mac.com": true, "aol.com": true,
+	"protonmail.com": true, "proton.me": true, "pm.me": true, "gmx.com": true,
+	"gmx.de": true, "gmx.net": true, "web.de": true, "mail.com": true,
+	"yandex.ru": true, "yandex.com": true, "mail.ru": true, "qq.com": true,
+	"163.com": true, "126.com": true, "fastmail.com": true, "zoho.com": true,
+	"tutanota.com": true, "hey.com": true,
+	"users.noreply.github.com": true, "users.noreply.gitlab.com": true,
+}
+
+type ProfileInference struct {
+	Email    string
+	Employer string
+	Region   string
+	Evidence []string
+}
+
+// InferProfile suggests an employer and work region when an identity uses
+// a corporate domain and mostly commits during weekday business hours.
+func InferProfile(id *Identity) (ProfileInference, bool) {
+	domain := EmailDomain(id.Email)
+	if domain == "" || freemailDomains[domain] || id.Timed < 5 {
+		return ProfileInference{}, false
+	}
+	share := float64(id.BusinessHours) / float64(id.Timed)
+	if share < 0.6 {
+		return ProfileInference{}, false
+	}
+	registered, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		return ProfileInference{}, false
+	}
+	employer := strings.SplitN(registered, ".", 2)[0]
+	employer = strings.ToUpper(employer[:1]) + employer[1:]
+
+	offset, n := "", 0
+	for o, c := range id.Offsets {
+		if c > n || (c == n && o < offset) {
+			offset, n = o, c
+		}
+	}
+	return ProfileInference{
+		Email:    id.Email,
+		Employer: employer,
+		Region:   "UTC" + offset,
+		Evidence: []string{
+			"corporate domain @" + domain,
+			fmt.Sprintf("%d of %d commits on weekdays 09:00-18:00 local time", id.BusinessHours, id.Timed),
+			fmt.Sprintf("%d of %d commits at UTC%s", n, id.Timed, offset),
+		},
+	}, true
+}
+
+// ========================== Repo Spans ==========================
+
+type RepoSpan struct {
+	Repo    string
+	First   time.Time
+	Last    time.Time
+	commits map[string]bool // by SHA, so overlapping scans count a commit once
+}
+
+type RepoSpans map[string]*RepoSpan
+
+func (s RepoSpans) Add(repo, sha string, when time.Time) {
+	if repo == "" {
+		return
+	}
+	span, ok := s[repo]
+	if !ok {
+		span = &RepoSpan{Repo: repo, commits: map[string]bool{}}
+		s[repo] = span
+	}
+	span.commits[sha] = true
+	if when.IsZero() {
+		return
+	}
+	if span.First.IsZero() || when.Before(span.First) {
+		span.First = when
+	}
+	if when.After(span.Last) {
+		span.Last = when
+	}
+}
+
+func (r *RepoSpan) Commits() int {
+	return len(r.commits)
+}
+
+func (r *RepoSpan) String() string {
+	return fmt.Sprintf("%s: %s .. %s, %d commits", r.Repo, formatDay(r.First), formatDay(r.Last), r.Commits())
+}
+
+func (s RepoSpans) Sorted() []*RepoSpan {
+	spans := make([]*RepoSpan, 0, len(s))
+	for _, span := range s {
+		spans = append(spans, span)
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Repo < spans[j].Repo })
+	return spans
+}
+
+func formatDay(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,168 @@
+package dossier
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCanonicalNameAndAliases(t *testing.T) {
+	tests := []struct {
+		name        string
+		names       []string // one per commit
+		wantName    string
+		wantAliases []string
+	}{
+		{"most frequent wins", []string{"alice", "Alice Smith", "Alice Smith", "A. Smith", "Alice Smith"}, "Alice Smith", []string{"A. Smith", "alice"}},
+		{"tie goes to the longer name", []string{"asmith", "Alice Smith"}, "Alice Smith", []string{"asmith"}},
+		{"case variants count as one", []string{"alice smith", "Alice Smith", "Alice Smith", "asmith", "asmith"}, "Alice Smith", []string{"asmith"}},
+		{"single name", []string{"Alice", "Alice"}, "Alice", nil},
+		{"blank names ignored", []string{"", "  ", "Alice"}, "Alice", nil},
+		{"no names", []string{""}, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewIdentityStore()
+			for _, n := range tt.names {
+				s.Add("alice@acme.io", n, "repo", time.Time{})
+			}
+			id := s.Identities()[0]
+			if got := id.CanonicalName(); got != tt.wantName {
+				t.Errorf("CanonicalName() = %q, want %q", got, tt.wantName)
+			}
+			if got := id.Aliases(); !reflect.DeepEqual(got, tt.wantAliases) {
+				t.Errorf("Aliases() = %q, want %q", got, tt.wantAliases)
+			}
+		})
+	}
+}
+
+func TestSimilarNames(t *testing.T) {
+	s := NewIdentityStore()
+	s.Add("alice@acme.io", "Alice Smith", "repo", time.Time{})
+	s.Add("asmith@gmail.com", "alice smith", "repo", time.Time{}) // same name, other case
+	s.Add("alice.s@home.io", "Alice Smyth", "repo", time.Time{})
+	s.Add("bob@acme.io", "Bob Jones", "repo", time.Time{})
+	s.Add("ci@acme.io", "", "repo", time.Time{})
+
+	var got []string
+	for _, m := range s.SimilarNames(0.9) {
+		got = append(got, m.A.Email+" ~ "+m.B.Email)
+		if m.Score < 0.9 || m.Score > 1 {
+			t.Errorf("%s ~ %s scored %.3f", m.A.Email, m.B.Email, m.Score)
+		}
+	}
+	want := []string{
+		"alice@acme.io ~ asmith@gmail.com", // 1.0 first
+		"alice.s@home.io ~ alice@acme.io",
+		"alice.s@home.io ~ asmith@gmail.com",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SimilarNames(0.9) = %q, want %q", got, want)
+	}
+}
+
+func TestRepoSpans(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 12, 0, 0, 0, time.UTC) }
+	spans := RepoSpans{}
+	spans.Add("alice/tool", "c3", day(20))
+	spans.Add("alice/tool", "c1", day(3)) // out of order
+	spans.Add("alice/tool", "c2", day(9))
+	spans.Add("alice/tool", "c1", day(3)) // seen again by another scan
+	spans.Add("alice/tool", "c4", time.Time{})
+	spans.Add("alice/site", "s1", day(7))
+	spans.Add("", "x", day(1)) // no repo, not tracked
+
+	var got []string
+	for _, s := range spans.Sorted() {
+		got = append(got, s.String())
+	}
+	want := []string{
+		"alice/site: 2024-05-07 .. 2024-05-07, 1 commits",
+		"alice/tool: 2024-05-03 .. 2024-05-20, 4 commits",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("spans = %q, want %q", got, want)
+	}
+}
+
+func TestInferProfile(t *testing.T) {
+	berlin := time.FixedZone("", 2*3600)
+	weekday := func(day, hour int) time.Time { return time.Date(2024, 5, day, hour, 0, 0, 0, berlin) } // 6-10 May 2024 is Mon-Fri
+
+	s := NewIdentityStore()
+	for day := 6; day <= 10; day++ {
+		s.Add("alice@mail.acme.co.uk", "Alice", "cli", weekday(day, 10))
+	}
+	s.Add("alice@mail.acme.co.uk", "Alice", "cli", weekday(11, 23)) // a Saturday night
+	p, ok := InferProfile(s.Identities()[0])
+	if !ok {
+		t.Fatal("no profile for a corporate address committing in office hours")
+	}
+	if p.Employer != "Acme" || p.Region != "UTC+02:00" {
+		t.Errorf("employer/region = %s/%s, want Acme/UTC+02:00", p.Employer, p.Region)
+	}
+	want := []string{"corporate domain @mail.acme.co.uk", "5 of 6 commits on weekdays 09:00-18:00 local time", "6 of 6 commits at UTC+02:00"}
+	if !reflect.DeepEqual(p.Evidence, want) {
+		t.Errorf("evidence = %q, want %q", p.Evidence, want)
+	}
+
+	for _, tt := range []struct {
+		name  string
+		email string
+		hours []int // one weekday commit per entry
+	}{
+		{"freemail", "alice@gmail.com", []int{10, 10, 10, 10, 10}},
+		{"too few commits", "alice@acme.io", []int{10, 10, 10, 10}},
+		{"mostly out of hours", "alice@acme.io", []int{10, 10, 20, 21, 22}},
+	} {
+		s := NewIdentityStore()
+		for i, h := range tt.hours {
+			s.Add(tt.email, "Alice", "cli", weekday(6+i%5, h))
+		}
+		if p, ok := InferProfile(s.Identities()[0]); ok {
+			t.Errorf("%s: inferred %+v", tt.name, p)
+		}
+	}
+}
+
+func TestSigningAggregation(t *testing.T) {
+	cfg, err := DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine("github", cfg, nil)
+	e.Reporter = &Collector{}
+	alice := Person{Name: "Alice", Email: "alice@acme.io"}
+	day := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	e.ProcessCommits([]Commit{
+		{ID: "1", Time: day, Author: alice, Signed: true, SigningKey: "GPG key AAAA1111"},
+		{ID: "2", Time: day, Author: alice, Signed: true, SigningKey: "GPG key AAAA1111"},
+		{ID: "3", Time: day, Author: alice, Signed: true, SigningKey: "SSH key SHA256:bbbb"},
+		{ID: "4", Time: day, Author: alice, Signed: true}, // signature present, key unknown
+		{ID: "5", Time: day, Author: alice},
+		{ID: "6", Time: day, Author: Person{Name: "Bob", Email: "bob@acme.io"}},
+	})
+
+	ids := e.Identities.Identities()
+	if a := ids[0]; a.Commits != 5 || a.Signed != 4 || a.SigningKey() != "GPG key AAAA1111" {
+		t.Errorf("alice: %d commits, %d signed, key %q; want 5, 4, GPG key AAAA1111", a.Commits, a.Signed, a.SigningKey())
+	}
+	if b := ids[1]; b.Signed != 0 || b.SigningKey() != "" {
+		t.Errorf("bob: %d signed, key %q; want none", b.Signed, b.SigningKey())
+	}
+
+	var log strings.Builder
+	w := Log.W
+	Log.W = &log
+	defer func() { Log.W = w }()
+	e.PrintSummary()
+	out := log.String()
+	if !strings.Contains(out, "alice@acme.io: 5 commits, 80% signed (GPG key AAAA1111)\n") {
+		t.Errorf("summary does not show alice's signing ratio:\n%s", out)
+	}
+	if i := strings.Index(out, "=== Commit signing ==="); i < 0 || strings.Contains(out[i:], "bob@acme.io: ") {
+		t.Errorf("summary lists an identity with no signed commits:\n%s", out)
+	}
+}
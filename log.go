@@ -0,0 +1,93 @@
+package dossier
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// ========================== Logging ==========================
+
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota // --verbose: every request and skipped item
+	LevelInfo                  // progress such as "Scanning repo: X"
+	LevelWarn                  // retries, caps reached, data left out
+	LevelError                 // failed repos and bad arguments; always shown
+)
+
+// Logger writes diagnostics and the summary to stderr, so stdout carries
+// only findings. Messages are written as given, emoji and all.
+type Logger struct {
+	mu     sync.Mutex
+	W      io.Writer
+	Level  LogLevel
+	status string // progress line kept below the messages
+}
+
+// Log is the process-wide logger every provider writes through
+var Log = &Logger{W: os.Stderr, Level: LevelInfo}
+
+// SetVerbosity applies --verbose and --quiet (warnings and errors only)
+func (l *Logger) SetVerbosity(verbose, quiet bool) error {
+	switch {
+	case verbose && quiet:
+		return errors.New("--verbose and --quiet are mutually exclusive")
+	case verbose:
+		l.Level = LevelDebug
+	case quiet:
+		l.Level = LevelWarn
+	default:
+		l.Level = LevelInfo
+	}
+	return nil
+}
+
+func (l *Logger) logf(level LogLevel, format string, args ...any) {
+	if level < l.Level {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clearStatus()
+	fmt.Fprintf(l.W, format, args...)
+	io.WriteString(l.W, l.status)
+}
+
+func (l *Logger) logln(level LogLevel, args ...any) {
+	if level < l.Level {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clearStatus()
+	fmt.Fprintln(l.W, args...)
+	io.WriteString(l.W, l.status)
+}
+
+// SetStatus replaces the line kept below the log messages; "" removes it
+func (l *Logger) SetStatus(line string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clearStatus()
+	l.status = line
+	io.WriteString(l.W, line)
+}
+
+func (l *Logger) clearStatus() {
+	if l.status != "" {
+		io.WriteString(l.W, "\r\033[K")
+	}
+}
+
+func (l *Logger) Debugf(format string, args ...any) { l.logf(LevelDebug, format, args...) }
+func (l *Logger) Infof(format string, args ...any)  { l.logf(LevelInfo, format, args...) }
+func (l *Logger) Warnf(format string, args ...any)  { l.logf(LevelWarn, format, args...) }
+func (l *Logger) Errorf(format string, args ...any) { l.logf(LevelError, format, args...) }
+
+func (l *Logger) Infoln(args ...any)  { l.logln(LevelInfo, args...) }
+func (l *Logger) Warnln(args ...any)  { l.logln(LevelWarn, args...) }
+func (l *Logger) Errorln(args ...any) { l.logln(LevelError, args...) }
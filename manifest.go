@@ -0,0 +1,81 @@
+package dossier
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"time"
+)
+
+// ========================== Manifest ==========================
+
+// Version is the dossier release, recorded in manifests and SARIF logs
+const Version = "0.1.0"
+
+// ScanStats counts what a scan covered, for the summary and the manifest
+type ScanStats struct {
+	EmailsSeen         int           `json:"emails_seen"`
+	EmailsValid        int           `json:"emails_valid"`
+	EmailsBlacklisted  int           `json:"emails_blacklisted"`
+	EmailsNoMX         int           `json:"emails_no_mx"`
+	EmailsUnlisted     int           `json:"emails_not_whitelisted,omitempty"`
+	ReposScanned       int           `json:"repos_scanned"`
+	PagesFetched       int           `json:"pages_fetched"`
+	ResponsesReused    int           `json:"responses_reused"`
+	ResponsesFromDisk  int           `json:"responses_from_disk,omitempty"`
+	NotModified        int           `json:"not_modified,omitempty"`
+	CommitsProcessed   int           `json:"commits_processed"`
+	BinaryLikeSkipped  int           `json:"binary_like_skipped,omitempty"`
+	CommitsSampled     int           `json:"commits_sampled,omitempty"`
+	RateLimitRemaining string        `json:"rate_limit_remaining,omitempty"`
+	FailedRepos        []RepoFailure `json:"failed_repos,omitempty"`
+}
+
+// RepoFailure is a repo whose commits could not all be fetched
+type RepoFailure struct {
+	Repo  string `json:"repo"`
+	Error string `json:"error"`
+}
+
+type Manifest struct {
+	Tool       string            `json:"tool"`
+	Version    string            `json:"version"`
+	Provider   string            `json:"provider"`
+	Usernames  []string          `json:"usernames"`
+	Args       []string          `json:"args"`
+	Flags      map[string]string `json:"flags"`
+	Config     *Config           `json:"config"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+	Coverage   ScanStats         `json:"coverage"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// WriteManifest records how this dossier was produced: arguments, the
+// effective value of every flag, the loaded signatures and what the scan
+// covered.
+func WriteManifest(path, provider, username string, cfg *Config, coverage ScanStats, started time.Time, scanErr error) error {
+	m := Manifest{
+		Tool:       "dossier",
+		Version:    Version,
+		Provider:   provider,
+		Usernames:  []string{username},
+		Args:       os.Args[1:],
+		Flags:      map[string]string{},
+		Config:     cfg,
+		StartedAt:  started,
+		FinishedAt: time.Now(),
+		Coverage:   coverage,
+	}
+	flag.VisitAll(func(f *flag.Flag) {
+		m.Flags[f.Name] = f.Value.String()
+	})
+	if scanErr != nil {
+		m.Error = scanErr.Error()
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,50 @@
+package dossier
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	cfg := &Config{OperatingSystems: []Pattern{{ID: "Fedora Linux", Regex: "fedora"}}}
+	started := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	stats := ScanStats{EmailsSeen: 4, ReposScanned: 2, CommitsProcessed: 17, FailedRepos: []RepoFailure{{Repo: "alice/big", Error: "HTTP 502"}}}
+	if err := WriteManifest(path, "github", "alice", cfg, stats, started, errors.New("interrupted")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Tool != "dossier" || m.Version != Version || m.Provider != "github" || len(m.Usernames) != 1 || m.Usernames[0] != "alice" {
+		t.Errorf("header fields = %+v", m)
+	}
+	if !m.StartedAt.Equal(started) || m.FinishedAt.Before(started) {
+		t.Errorf("started %v, finished %v", m.StartedAt, m.FinishedAt)
+	}
+	if len(m.Config.OperatingSystems) != 1 || m.Config.OperatingSystems[0].ID != "Fedora Linux" {
+		t.Errorf("config = %+v", m.Config)
+	}
+	if m.Coverage.CommitsProcessed != 17 || m.Coverage.ReposScanned != 2 || len(m.Coverage.FailedRepos) != 1 || m.Coverage.FailedRepos[0].Repo != "alice/big" {
+		t.Errorf("coverage = %+v", m.Coverage)
+	}
+	if m.Error != "interrupted" {
+		t.Errorf("error = %q", m.Error)
+	}
+	if _, ok := m.Flags["test.run"]; !ok {
+		t.Errorf("flags = %v, want every registered flag", m.Flags)
+	}
+	if m.Args == nil {
+		t.Error("args missing")
+	}
+}
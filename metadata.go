@@ -0,0 +1,71 @@
+package dossier
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ========================== Metadata Files ==========================
+
+// Well-known files that list contributors. CODEOWNERS may live in any of
+// three places; only the first one found is read.
+var MetadataFiles = [][]string{
+	{"CODEOWNERS", ".github/CODEOWNERS", "docs/CODEOWNERS"},
+	{"AUTHORS"},
+	{"MAINTAINERS"},
+	{".mailmap"},
+}
+
+type MetadataContact struct {
+	Name     string
+	Email    string
+	Username string
+}
+
+var (
+	nameEmailRe     = regexp.MustCompile(`([^<>,]*)<([^<>\s]+@[^<>\s]+)>`)
+	bareEmailRe     = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	maintainerTagRe = regexp.MustCompile(`^[A-Z]:\s*`) // "M: Name <email>" in kernel-style MAINTAINERS
+)
+
+// ParseMetadataFile extracts contacts from CODEOWNERS, AUTHORS,
+// MAINTAINERS or .mailmap content.
+func ParseMetadataFile(path, content string) []MetadataContact {
+	var contacts []MetadataContact
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// CODEOWNERS: a path pattern followed by @users, @org/teams or emails
+		if strings.HasSuffix(path, "CODEOWNERS") {
+			for _, owner := range strings.Fields(line)[1:] {
+				if strings.HasPrefix(owner, "@") {
+					if !strings.Contains(owner, "/") { // teams are not people
+						contacts = append(contacts, MetadataContact{Username: owner[1:]})
+					}
+				} else if strings.Contains(owner, "@") {
+					contacts = append(contacts, MetadataContact{Email: owner})
+				}
+			}
+			continue
+		}
+
+		// AUTHORS, MAINTAINERS, .mailmap: "Name <email>" pairs, else bare emails
+		line = maintainerTagRe.ReplaceAllString(line, "")
+		if pairs := nameEmailRe.FindAllStringSubmatch(line, -1); len(pairs) > 0 {
+			for _, p := range pairs {
+				contacts = append(contacts, MetadataContact{Name: strings.TrimSpace(p[1]), Email: p[2]})
+			}
+			continue
+		}
+		for _, e := range bareEmailRe.FindAllString(line, -1) {
+			contacts = append(contacts, MetadataContact{Email: e})
+		}
+	}
+	return contacts
+}
@@ -0,0 +1,53 @@
+package dossier
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMetadataFile(t *testing.T) {
+	tests := []struct {
+		name, path, content string
+		want                []MetadataContact
+	}{
+		{
+			name: "codeowners",
+			path: ".github/CODEOWNERS",
+			content: "# Default owners\n" +
+				"*       @alice @acme/core\n" +
+				"/docs/  bob@acme.io   # docs team lead\n" +
+				"\n" +
+				"*.go    @carol docs@acme.io\n",
+			want: []MetadataContact{{Username: "alice"}, {Email: "bob@acme.io"}, {Username: "carol"}, {Email: "docs@acme.io"}},
+		},
+		{
+			name: "authors",
+			path: "AUTHORS",
+			content: "# Names should be added to this file as\n" +
+				"#     Name <email address>\n" +
+				"Alice Smith <alice@acme.io>\n" +
+				"Bob Jones <bob@acme.io>, Carol <carol@acme.io>\n" +
+				"dave@acme.io\n" +
+				"Erin (no email)\n",
+			want: []MetadataContact{
+				{Name: "Alice Smith", Email: "alice@acme.io"},
+				{Name: "Bob Jones", Email: "bob@acme.io"},
+				{Name: "Carol", Email: "carol@acme.io"},
+				{Email: "dave@acme.io"},
+			},
+		},
+		{
+			name:    "maintainers tags",
+			path:    "MAINTAINERS",
+			content: "PARSER\nM: Alice Smith <alice@acme.io>\nS: Maintained\n",
+			want:    []MetadataContact{{Name: "Alice Smith", Email: "alice@acme.io"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseMetadataFile(tt.path, tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+// Package dossier holds what the provider packages (github, gitlab,
+// bitbucket, azure and sourcehut) share: signature loading, secret and
+// address detection, the email blacklist and validation, commit-message
+// parsing, identity aggregation and the output formats.
+package dossier
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ========================== Structs ==========================
+
+type Pattern struct {
+	ID     string `yaml:"id" json:"id"`
+	Regex  string `yaml:"regex" json:"regex"`
+	Source string `yaml:"-" json:"source,omitempty"` // file the pattern was loaded from
+}
+
+type Config struct {
+	OperatingSystems []Pattern `yaml:"operating_systems" json:"operating_systems"`
+	Utilities        []Pattern `yaml:"utilities" json:"utilities"`
+	EmailDomains     []Pattern `yaml:"email_domains" json:"email_domains"`
+	RepoNames        []Pattern `yaml:"repo_names" json:"repo_names"`
+}
+
+// ========================== YAML / Config ==========================
+
+// The signatures and blacklist shipped with the source, so an installed
+// binary works without data files next to it
+var (
+	//go:embed signatures.yaml
+	defaultSignatures []byte
+	//go:embed blacklist.txt
+	defaultBlacklist []byte
+)
+
+// LoadPatterns reads one signature file. Every regex is compiled up front
+// and all bad patterns are reported together, each with its file, category
+// and id.
+func LoadPatterns(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return parsePatterns(data, filename)
+}
+
+// DefaultPatterns returns the built-in signatures.yaml
+func DefaultPatterns() (*Config, error) {
+	return parsePatterns(defaultSignatures, "signatures.yaml (built in)")
+}
+
+func parsePatterns(data []byte, filename string) (*Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	var errs []error
+	for _, cat := range cfg.categories() {
+		for i := range *cat.patterns {
+			p := &(*cat.patterns)[i]
+			p.Source = filename
+			if _, err := regexp.Compile(p.Regex); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s pattern %q: %w", filename, cat.name, p.ID, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return &cfg, nil
+}
+
+// LoadPatternDir merges every *.yaml signature pack in dir, in name order
+func LoadPatternDir(dir string) (*Config, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no *.yaml signature files in %s", dir)
+	}
+	sort.Strings(files)
+	merged := &Config{}
+	var errs []error
+	for _, file := range files {
+		cfg, err := LoadPatterns(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		dst, src := merged.categories(), cfg.categories()
+		for i := range dst {
+			*dst[i].patterns = append(*dst[i].patterns, *src[i].patterns...)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return merged, nil
+}
+
+// ConfigPath resolves a config file such as signatures.yaml: an explicit
+// path wins, then the env var, then name in the working directory, then in
+// the user config dir (~/.config/dossier on Linux). It returns "" when none
+// exists, meaning the built-in default should be used.
+func ConfigPath(path, envVar, name string) string {
+	if path != "" {
+		return path
+	}
+	if v := os.Getenv(envVar); v != "" {
+		return v
+	}
+	if fileExists(name) {
+		return name
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		if p := filepath.Join(dir, "dossier", name); fileExists(p) {
+			return p
+		}
+	}
+	return ""
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+type patternCategory struct {
+	name     string
+	patterns *[]Pattern
+}
+
+// categories lists every pattern list in Config with its yaml key, so new
+// categories are picked up by the loaders without further changes.
+func (c *Config) categories() []patternCategory {
+	v := reflect.ValueOf(c).Elem()
+	var cats []patternCategory
+	for i := 0; i < v.NumField(); i++ {
+		if list, ok := v.Field(i).Addr().Interface().(*[]Pattern); ok {
+			cats = append(cats, patternCategory{name: strings.Split(v.Type().Field(i).Tag.Get("yaml"), ",")[0], patterns: list})
+		}
+	}
+	return cats
+}
+
+// ConfigSchema describes signatures.yaml as a JSON Schema. It is built from
+// the Config and Pattern struct tags so it cannot drift from the loader.
+func ConfigSchema() map[string]any {
+	yamlName := func(f reflect.StructField) string {
+		return strings.Split(f.Tag.Get("yaml"), ",")[0]
+	}
+
+	patternType := reflect.TypeOf(Pattern{})
+	patternProps := map[string]any{}
+	var required []string
+	for i := 0; i < patternType.NumField(); i++ {
+		name := yamlName(patternType.Field(i))
+		if name == "-" {
+			continue
+		}
+		patternProps[name] = map[string]any{"type": "string", "minLength": 1}
+		required = append(required, name)
+	}
+	patternProps["regex"].(map[string]any)["format"] = "regex"
+
+	configType := reflect.TypeOf(Config{})
+	props := map[string]any{}
+	for i := 0; i < configType.NumField(); i++ {
+		props[yamlName(configType.Field(i))] = map[string]any{
+			"type": []string{"array", "null"}, // a key left empty, like email_domains in the default file
+			"items": map[string]any{
+				"type":                 "object",
+				"properties":           patternProps,
+				"required":             required,
+				"additionalProperties": false,
+			},
+		}
+	}
+
+	return map[string]any{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"title":                "dossier signatures.yaml",
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+}
+
+// compiledPatterns caches regexes by source, since the same signatures run
+// over every commit. Loaded patterns were already checked, so one that still
+// fails to compile (a hand-built Pattern) is skipped instead of panicking.
+var compiledPatterns sync.Map
+
+func compilePattern(expr string) *regexp.Regexp {
+	if re, ok := compiledPatterns.Load(expr); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil
+	}
+	compiledPatterns.Store(expr, re)
+	return re
+}
+
+func SearchPatterns(text string, patterns []Pattern) []string {
+	var matches []string
+	for _, pat := range patterns {
+		re := compilePattern(pat.Regex)
+		if re != nil && re.MatchString(text) {
+			matches = append(matches, pat.ID)
+		}
+	}
+	return matches
+}
+
+// ========================== Custom Redaction ==========================
+
+// LoadRedactions reads a --redact-config file: a "patterns" list in the
+// same id/regex form as signatures.yaml.
+func LoadRedactions(filename string) ([]*regexp.Regexp, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Patterns []Pattern `yaml:"patterns"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	var res []*regexp.Regexp
+	var errs []error
+	for _, p := range file.Patterns {
+		re, err := regexp.Compile(p.Regex)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: redaction pattern %q: %w", filename, p.ID, err))
+			continue
+		}
+		res = append(res, re)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return res, nil
+}
@@ -0,0 +1,284 @@
+package dossier
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		wantOS   []string // ids
+		wantErrs []string // substrings of the error
+	}{
+		{
+			name:   "valid",
+			yaml:   "operating_systems:\n  - id: Fedora Linux\n    regex: \"fedora\"\n  - id: macOS\n    regex: \"macos|darwin\"\nutilities:\n  - id: OpenPGP\n    regex: \"openpgp\"\n",
+			wantOS: []string{"Fedora Linux", "macOS"},
+		},
+		{
+			name:   "empty",
+			yaml:   "",
+			wantOS: nil,
+		},
+		{
+			name:     "every bad regex reported",
+			yaml:     "operating_systems:\n  - id: Broken\n    regex: \"(fedora\"\nutilities:\n  - id: AlsoBroken\n    regex: \"[gpg\"\n",
+			wantErrs: []string{`operating_systems pattern "Broken"`, `utilities pattern "AlsoBroken"`},
+		},
+		{
+			name:     "not yaml",
+			yaml:     "operating_systems: [",
+			wantErrs: []string{"sig.yaml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, "sig.yaml", tt.yaml)
+			cfg, err := LoadPatterns(path)
+			if tt.wantErrs != nil {
+				if err == nil {
+					t.Fatal("no error")
+				}
+				for _, want := range append(tt.wantErrs, path) {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("error %q does not mention %q", err, want)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, p := range cfg.OperatingSystems {
+				ids = append(ids, p.ID)
+				if p.Source != path {
+					t.Errorf("%s: Source = %q, want %q", p.ID, p.Source, path)
+				}
+			}
+			if !reflect.DeepEqual(ids, tt.wantOS) {
+				t.Errorf("operating systems = %v, want %v", ids, tt.wantOS)
+			}
+		})
+	}
+
+	if _, err := LoadPatterns(filepath.Join(t.TempDir(), "missing.yaml")); !os.IsNotExist(err) {
+		t.Errorf("missing file: err = %v, want not-exist", err)
+	}
+}
+
+func TestSearchPatterns(t *testing.T) {
+	cfg, err := DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		text     string
+		patterns []Pattern
+		want     []string
+	}{
+		{"built-in os", "Signed from my ubuntu laptop", cfg.OperatingSystems, []string{"Ubuntu Linux"}},
+		{"built-in utility", "signed with gpg", cfg.Utilities, []string{"GNU Privacy Guard (GPG)"}},
+		{"no match", "Fix typo in README", cfg.OperatingSystems, nil},
+		{"case sensitive", "UBUNTU", cfg.OperatingSystems, nil},
+		{"all matches in pattern order", "darwin build, then fedora", []Pattern{{ID: "Fedora", Regex: "fedora"}, {ID: "macOS", Regex: "darwin"}}, []string{"Fedora", "macOS"}},
+		{"bad regex skipped", "fedora", []Pattern{{ID: "Broken", Regex: "(fedora"}, {ID: "Fedora", Regex: "fedora"}}, []string{"Fedora"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SearchPatterns(tt.text, tt.patterns); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SearchPatterns(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPatternDir(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"20-corp.yaml": "email_domains:\n  - id: Acme\n    regex: acme\\.io$\noperating_systems:\n  - id: AcmeOS\n    regex: acmeos\n",
+		"10-base.yaml": "operating_systems:\n  - id: Fedora Linux\n    regex: fedora\nutilities:\n  - id: OpenPGP\n    regex: openpgp\n",
+		"notes.txt":    "not a signature pack",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg, err := LoadPatternDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := func(patterns []Pattern) string {
+		var out []string
+		for _, p := range patterns {
+			out = append(out, p.ID+"@"+filepath.Base(p.Source))
+		}
+		return strings.Join(out, " ")
+	}
+	if got := ids(cfg.OperatingSystems); got != "Fedora Linux@10-base.yaml AcmeOS@20-corp.yaml" {
+		t.Errorf("operating systems = %s, want both packs in name order", got)
+	}
+	if got := ids(cfg.Utilities); got != "OpenPGP@10-base.yaml" {
+		t.Errorf("utilities = %s", got)
+	}
+	if got := ids(cfg.EmailDomains); got != "Acme@20-corp.yaml" {
+		t.Errorf("email domains = %s", got)
+	}
+
+	bad := filepath.Join(dir, "30-bad.yaml")
+	if err := os.WriteFile(bad, []byte("utilities:\n  - id: Broken\n    regex: \"(gpg\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPatternDir(dir); err == nil || !strings.Contains(err.Error(), bad) {
+		t.Errorf("err = %v, want the bad pack named", err)
+	}
+
+	if _, err := LoadPatternDir(t.TempDir()); err == nil || !strings.Contains(err.Error(), "no *.yaml") {
+		t.Errorf("empty dir: err = %v", err)
+	}
+}
+
+// validate checks doc against the subset of JSON Schema that ConfigSchema
+// and the SARIF schema use and returns one message per violation
+func validate(schema map[string]any, doc any, path string) []string {
+	var errs []string
+	typ := schema["type"]
+	if types, ok := typ.([]string); ok {
+		if doc == nil && slices.Contains(types, "null") {
+			return nil
+		}
+		typ = types[0]
+	}
+	switch typ {
+	case "object":
+		obj, ok := doc.(map[string]any)
+		if !ok {
+			return []string{path + ": not an object"}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		required, _ := schema["required"].([]string)
+		for _, name := range required {
+			if _, ok := obj[name]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: missing %q", path, name))
+			}
+		}
+		for name, v := range obj {
+			sub, ok := props[name].(map[string]any)
+			if !ok {
+				if schema["additionalProperties"] == false {
+					errs = append(errs, fmt.Sprintf("%s: unknown property %q", path, name))
+				}
+				continue
+			}
+			errs = append(errs, validate(sub, v, path+"."+name)...)
+		}
+	case "array":
+		list, ok := doc.([]any)
+		if !ok {
+			return []string{path + ": not an array"}
+		}
+		for i, v := range list {
+			errs = append(errs, validate(schema["items"].(map[string]any), v, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "string":
+		str, ok := doc.(string)
+		if !ok {
+			return []string{path + ": not a string"}
+		}
+		if min, ok := schema["minLength"].(int); ok && len(str) < min {
+			errs = append(errs, path+": too short")
+		}
+		if enum, ok := schema["enum"].([]string); ok && !slices.Contains(enum, str) {
+			errs = append(errs, fmt.Sprintf("%s: %q not one of %v", path, str, enum))
+		}
+		switch schema["format"] {
+		case "regex":
+			if _, err := regexp.Compile(str); err != nil {
+				errs = append(errs, path+": not a regex")
+			}
+		case "uri":
+			if u, err := url.Parse(str); err != nil || !u.IsAbs() {
+				errs = append(errs, path+": not an absolute URI")
+			}
+		}
+	case "integer":
+		n, ok := doc.(float64)
+		if !ok || n != float64(int64(n)) {
+			return []string{path + ": not an integer"}
+		}
+		if min, ok := schema["minimum"].(int); ok && n < float64(min) {
+			errs = append(errs, path+": below the minimum")
+		}
+	}
+	return errs
+}
+
+func TestConfigSchema(t *testing.T) {
+	schema := ConfigSchema()
+	if _, err := json.Marshal(schema); err != nil {
+		t.Fatalf("schema does not marshal: %v", err)
+	}
+	var cats []string
+	for name := range schema["properties"].(map[string]any) {
+		cats = append(cats, name)
+	}
+	slices.Sort(cats)
+	if want := []string{"email_domains", "operating_systems", "repo_names", "utilities"}; !slices.Equal(cats, want) {
+		t.Errorf("categories = %v, want %v", cats, want)
+	}
+
+	builtin, err := os.ReadFile("signatures.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		yaml string
+		want []string // substrings, one per expected violation
+	}{
+		{"built-in signatures", string(builtin), nil},
+		{"good", "operating_systems:\n  - id: Fedora Linux\n    regex: fedora\nemail_domains: []\nrepo_names:\n", nil},
+		{"bad", "operating_systems:\n  - id: Fedora Linux\n    regex: \"(fedora\"\n  - regex: macos\n  - id: \"\"\n    regex: x\n    source: here\nshells:\n  - id: bash\n    regex: bash\nutilities: gpg\n",
+			[]string{`operating_systems[0].regex: not a regex`, `operating_systems[1]: missing "id"`, `operating_systems[2].id: too short`, `operating_systems[2]: unknown property "source"`, `unknown property "shells"`, `utilities: not an array`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc map[string]any
+			if err := yaml.Unmarshal([]byte(tt.yaml), &doc); err != nil {
+				t.Fatal(err)
+			}
+			errs := validate(schema, doc, "$")
+			if len(errs) != len(tt.want) {
+				t.Fatalf("violations = %q, want %d", errs, len(tt.want))
+			}
+			joined := strings.Join(errs, "\n")
+			for _, want := range tt.want {
+				if !strings.Contains(joined, want) {
+					t.Errorf("violations %q do not mention %q", errs, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package dossier
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ========================== Progress ==========================
+
+var spinnerFrames = []rune("⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏")
+
+// ProgressReporter keeps a status line on stderr with the repo being
+// scanned out of the total, or just a spinner while the total is unknown,
+// and the commits processed so far. Findings pass through to Next with the
+// line cleared around them. A nil *ProgressReporter does nothing, so scanners
+// can call it whether or not --progress is on.
+type ProgressReporter struct {
+	Next Reporter
+
+	mu      sync.Mutex
+	repo    int
+	total   int
+	commits int
+	frame   int
+	drawn   time.Time
+}
+
+func NewProgressReporter(next Reporter) *ProgressReporter {
+	return &ProgressReporter{Next: next}
+}
+
+// IsTerminal reports whether f is a terminal rather than a file or pipe
+func IsTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// SetRepo records that repo n of total is being scanned; total 0 means unknown
+func (p *ProgressReporter) SetRepo(n, total int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.repo, p.total = n, total
+	p.draw(true)
+}
+
+// SetCommits records the commits processed so far
+func (p *ProgressReporter) SetCommits(n int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.commits = n
+	p.draw(false)
+}
+
+// draw redraws the line, at most ten times a second unless forced
+func (p *ProgressReporter) draw(force bool) {
+	if !force && time.Since(p.drawn) < 100*time.Millisecond {
+		return
+	}
+	p.drawn = time.Now()
+	p.frame = (p.frame + 1) % len(spinnerFrames)
+	line := fmt.Sprintf("%c %d commits processed", spinnerFrames[p.frame], p.commits)
+	if p.total > 0 {
+		line = fmt.Sprintf("%c repo %d/%d, %d commits processed", spinnerFrames[p.frame], p.repo, p.total, p.commits)
+	}
+	Log.SetStatus(line)
+}
+
+func (p *ProgressReporter) Report(f Finding) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	Log.SetStatus("")
+	p.Next.Report(f)
+	p.draw(true)
+}
+
+// Flush clears the line for good, since the summary follows
+func (p *ProgressReporter) Flush() {
+	p.mu.Lock()
+	Log.SetStatus("")
+	p.mu.Unlock()
+	if f, ok := p.Next.(Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (p *ProgressReporter) Summarize(s Summary) {
+	if next, ok := p.Next.(Summarizer); ok {
+		next.Summarize(s)
+	}
+}
+
+func (p *ProgressReporter) Close() error {
+	p.mu.Lock()
+	Log.SetStatus("")
+	p.mu.Unlock()
+	if c, ok := p.Next.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,361 @@
+package dossier
+
+import (
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/syslog"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+)
+
+// ========================== Output Formats ==========================
+
+// FormatEmail renders an address per --email-format: plain, mailto or angle
+func FormatEmail(addr, format string) string {
+	if addr == "" {
+		return ""
+	}
+	switch format {
+	case "mailto":
+		return "mailto:" + addr
+	case "angle":
+		return "<" + addr + ">"
+	}
+	return addr
+}
+
+// TextReporter prints findings for people, with emails rendered per
+// --email-format
+type TextReporter struct {
+	EmailFormat string
+}
+
+func (r TextReporter) Report(f Finding) {
+	email := FormatEmail(f.Email, r.EmailFormat)
+	switch f.Type {
+	case "email":
+		fmt.Printf("Email: %s\n", email)
+		fmt.Printf("Name: %s\n", f.Name)
+		if f.Seen > 1 {
+			fmt.Printf("Seen: %d times\n", f.Seen)
+		}
+		if f.Class != "" && f.Class != "real" {
+			fmt.Printf("Class: %s\n", f.Class)
+		}
+		if f.Signature != "" {
+			fmt.Printf("Found in: %s\n", f.Signature) // trailer, metadata file or gist
+		}
+	case "domain_match":
+		fmt.Printf("Domain Match: %s\n", f.Signature)
+		fmt.Printf("Email: %s\n", email)
+	case "os":
+		fmt.Printf("Operating System: %s\n", f.Signature)
+		fmt.Printf("Email: %s\n", email)
+	case "google_credential":
+		fmt.Printf("Google Credential: %s (%s)\n", f.Signature, f.Secret)
+		fmt.Printf("Email: %s\n", email)
+	case "registry_credential":
+		fmt.Printf("Registry Credential: %s %s (%s)\n", f.Signature, f.Value, f.Secret)
+		fmt.Printf("Email: %s\n", email)
+	case "saas_credential":
+		fmt.Printf("SaaS Credential: %s (%s)\n", f.Signature, f.Secret)
+		fmt.Printf("Email: %s\n", email)
+	case "encoded_secret":
+		fmt.Printf("Encoded Secret: %s (%s, %s)\n", f.Signature, f.Encoding, f.Secret)
+		fmt.Printf("Email: %s\n", email)
+	case "crypto_address":
+		fmt.Printf("Crypto Address: %s %s\n", f.Signature, f.Value)
+		fmt.Printf("Email: %s\n", email)
+	case "account":
+		fmt.Printf("Account: %s\n", f.Value)
+		fmt.Printf("Email: %s\n", email)
+	case "suspicious_date":
+		fmt.Printf("Suspicious Date: %s\n", f.Signature)
+		fmt.Printf("Email: %s\n", email)
+	case "username":
+		fmt.Printf("Username: %s (from %s)\n", f.Value, f.Signature)
+	case "repo_match":
+		fmt.Printf("Repo matched: %s (%s)\n", f.Signature, f.Value)
+	case "utility":
+		fmt.Printf("Utility: %s\n", f.Signature)
+		fmt.Printf("Email: %s\n", email)
+	}
+	if f.Date != "" {
+		fmt.Printf("Date: %s\n", f.Date)
+	}
+	if f.Repo != "" {
+		fmt.Printf("Repo: %s\n", f.Repo)
+	}
+	fmt.Printf("Location: %s\n\n", f.Location)
+}
+
+// SyslogReporter sends each finding as a JSON message, for SIEM ingestion
+type SyslogReporter struct {
+	w      *syslog.Writer
+	failed bool
+}
+
+// NewSyslogReporter logs to the local syslog daemon, or to addr
+// ("host:port", UDP) when one is given.
+func NewSyslogReporter(addr string) (*SyslogReporter, error) {
+	prio := syslog.LOG_NOTICE | syslog.LOG_USER
+	var w *syslog.Writer
+	var err error
+	if addr != "" {
+		w, err = syslog.Dial("udp", addr, prio, "dossier")
+	} else {
+		w, err = syslog.New(prio, "dossier")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogReporter{w: w}, nil
+}
+
+func (r *SyslogReporter) Report(f Finding) {
+	msg, _ := json.Marshal(f)
+	if err := r.w.Notice(string(msg)); err != nil && !r.failed {
+		r.failed = true // warn once, then keep trying quietly
+		Log.Warnf("Warning: writing to syslog failed: %v\n", err)
+	}
+}
+
+// ESBulkReporter writes Elasticsearch _bulk action/source line pairs. The
+// document _id is a hash of the finding, so re-ingesting is idempotent.
+type ESBulkReporter struct {
+	w     io.Writer
+	index string
+}
+
+func NewESBulkReporter(w io.Writer, index string) *ESBulkReporter {
+	return &ESBulkReporter{w: w, index: index}
+}
+
+func (r *ESBulkReporter) Report(f Finding) {
+	doc, _ := json.Marshal(f)
+	sum := sha1.Sum(doc)
+	action, _ := json.Marshal(map[string]map[string]string{
+		"index": {"_index": r.index, "_id": hex.EncodeToString(sum[:])},
+	})
+	fmt.Fprintf(r.w, "%s\n%s\n", action, doc)
+}
+
+// EmailListReporter prints each distinct email address once, one per line,
+// and drops every other finding type.
+type EmailListReporter struct {
+	w      io.Writer
+	format string // --email-format
+	seen   map[string]bool
+}
+
+func NewEmailListReporter(w io.Writer, format string) *EmailListReporter {
+	return &EmailListReporter{w: w, format: format, seen: map[string]bool{}}
+}
+
+func (r *EmailListReporter) Report(f Finding) {
+	key := strings.ToLower(f.Email)
+	if f.Type != "email" || r.seen[key] {
+		return
+	}
+	r.seen[key] = true
+	fmt.Fprintln(r.w, FormatEmail(f.Email, r.format))
+}
+
+// KVReporter writes one logfmt line per finding, keyed by the JSON field
+// names, for Loki/Vector style pipelines. The provider is written last, as
+// source=.
+type KVReporter struct {
+	W io.Writer
+}
+
+func (r KVReporter) Report(f Finding) {
+	var parts []string
+	v := reflect.ValueOf(f)
+	for i := 0; i < v.NumField(); i++ {
+		key := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+		if field := v.Field(i); key != "" && key != "provider" && !field.IsZero() {
+			parts = append(parts, key+"="+logfmtValue(fmt.Sprint(field.Interface())))
+		}
+	}
+	parts = append(parts, "source="+f.Provider)
+	fmt.Fprintln(r.W, strings.Join(parts, " "))
+}
+
+// logfmtValue quotes values with spaces, quotes, = or control characters
+func logfmtValue(s string) string {
+	if strings.ContainsAny(s, " =\"\\") || strings.IndexFunc(s, unicode.IsControl) >= 0 {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// JSONLReporter writes one JSON object per finding per line (NDJSON), to
+// stdout or to a file, optionally gzip-compressed.
+type JSONLReporter struct {
+	enc     *json.Encoder
+	gz      *gzip.Writer
+	closers []io.Closer // closed in order: gzip stream first, then the file
+}
+
+func NewJSONLReporter(path string, compress bool) (*JSONLReporter, error) {
+	r := &JSONLReporter{}
+	var w io.Writer = os.Stdout
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		w = file
+		r.closers = append(r.closers, file)
+	}
+	if compress {
+		r.gz = gzip.NewWriter(w)
+		w = r.gz
+		r.closers = append([]io.Closer{r.gz}, r.closers...)
+	}
+	r.enc = json.NewEncoder(w)
+	return r, nil
+}
+
+func (r *JSONLReporter) Report(f Finding) {
+	r.enc.Encode(f)
+}
+
+func (r *JSONLReporter) Summarize(s Summary) {
+	r.enc.Encode(s)
+}
+
+func (r *JSONLReporter) Flush() {
+	if r.gz != nil {
+		r.gz.Flush()
+	}
+}
+
+func (r *JSONLReporter) Close() error {
+	var first error
+	for _, c := range r.closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// ========================== Identity Output ==========================
+
+// TableReporter prints nothing per finding; once the scan finishes it
+// prints one aligned row per identity in Identities. A Flush mid-scan
+// (--flush-interval, --watch) prints only the rows that are new or changed
+// since the last one, so earlier rows are never repeated.
+type TableReporter struct {
+	W          io.Writer
+	Identities *IdentityStore
+
+	printed map[string]string // email -> last row printed
+}
+
+func (*TableReporter) Report(f Finding) {}
+
+func (r *TableReporter) Flush() {
+	first := r.printed == nil
+	if first {
+		r.printed = map[string]string{}
+	}
+	var rows []string
+	for _, id := range r.Identities.Identities() {
+		row := fmt.Sprintf("%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			Truncate(id.Email, 40),
+			Truncate(id.CanonicalName(), 30),
+			Truncate(strings.Join(id.Aliases(), ", "), 40),
+			Truncate(strings.Join(id.SourceList(), ", "), 50),
+			id.Commits,
+			formatDay(id.FirstSeen),
+			formatDay(id.LastSeen),
+		)
+		if r.printed[id.Email] != row {
+			r.printed[id.Email] = row
+			rows = append(rows, row)
+		}
+	}
+	if !first && len(rows) == 0 {
+		return
+	}
+	tw := tabwriter.NewWriter(r.W, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "EMAIL\tNAME\tALIASES\tSOURCES\tCOMMITS\tFIRST SEEN\tLAST SEEN")
+	for _, row := range rows {
+		fmt.Fprint(tw, row)
+	}
+	tw.Flush()
+}
+
+// Truncate shortens s to n runes, ending in an ellipsis when it was cut
+func Truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// FindingsAndIdentitiesReporter streams every finding as a "finding"
+// record, then appends an "identity" record per aggregated identity when
+// the output is closed, all in one NDJSON stream.
+type FindingsAndIdentitiesReporter struct {
+	*JSONLReporter
+	Identities *IdentityStore
+}
+
+type IdentityRecord struct {
+	Record    string   `json:"record"`
+	Email     string   `json:"email"`
+	Name      string   `json:"name,omitempty"`
+	Aliases   []string `json:"aliases,omitempty"`
+	Class     string   `json:"class,omitempty"`
+	Sources   []string `json:"sources,omitempty"`
+	Commits   int      `json:"commits"`
+	FirstSeen string   `json:"first_seen,omitempty"`
+	LastSeen  string   `json:"last_seen,omitempty"`
+}
+
+func (r FindingsAndIdentitiesReporter) Report(f Finding) {
+	r.enc.Encode(struct {
+		Record string `json:"record"`
+		Finding
+	}{"finding", f})
+}
+
+func (r FindingsAndIdentitiesReporter) Summarize(s Summary) {
+	r.enc.Encode(struct {
+		Record string `json:"record"`
+		Summary
+	}{"summary", s})
+}
+
+func (r FindingsAndIdentitiesReporter) Close() error {
+	for _, id := range r.Identities.Identities() {
+		rec := IdentityRecord{
+			Record:  "identity",
+			Email:   id.Email,
+			Name:    id.CanonicalName(),
+			Aliases: id.Aliases(),
+			Class:   ClassifyEmail(id.Email),
+			Sources: id.SourceList(),
+			Commits: id.Commits,
+		}
+		if !id.FirstSeen.IsZero() {
+			rec.FirstSeen = id.FirstSeen.Format(time.RFC3339)
+			rec.LastSeen = id.LastSeen.Format(time.RFC3339)
+		}
+		r.enc.Encode(rec)
+	}
+	return r.JSONLReporter.Close()
+}
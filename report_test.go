@@ -0,0 +1,371 @@
+package dossier
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestTableReporterGolden(t *testing.T) {
+	ids := NewIdentityStore()
+	day := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	for i, name := range []string{"Alice Smith", "Alice Smith", "asmith"} {
+		ids.Add("alice@acme.io", name, "alice/tool", day.AddDate(0, 0, i))
+	}
+	ids.Add("alice@acme.io", "Alice Smith", "alice/site", day.AddDate(0, 1, 0))
+	ids.Add("bob@acme.io", "Bob", "alice/tool", day)
+	ids.Add("a.very.long.address.for.truncation@subdomain.example-corp.io", "Bartholomew Fitzgerald-Montgomery III", "org/"+strings.Repeat("x", 60), time.Time{})
+
+	var buf strings.Builder
+	(&TableReporter{W: &buf, Identities: ids}).Flush()
+
+	golden := filepath.Join("testdata", "table.golden")
+	if *update {
+		if err := os.WriteFile(golden, []byte(buf.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != string(want) {
+		t.Errorf("table differs from %s (run with -update to accept):\n%s", golden, buf.String())
+	}
+}
+
+func TestTableReporterFlushPrintsOnlyNewRows(t *testing.T) {
+	var buf strings.Builder
+	ids := NewIdentityStore()
+	r := &TableReporter{W: &buf, Identities: ids}
+	day := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	// First window: two identities
+	ids.Add("alice@acme.io", "Alice", "commit", day)
+	ids.Add("bob@acme.io", "Bob", "commit", day)
+	r.Flush()
+	if out := buf.String(); !strings.Contains(out, "alice@acme.io") || !strings.Contains(out, "bob@acme.io") {
+		t.Fatalf("first flush = %q, want both rows", out)
+	}
+
+	// Second window: bob commits again, carol appears, alice is unchanged
+	buf.Reset()
+	ids.Add("bob@acme.io", "Bob", "commit", day.AddDate(0, 0, 1))
+	ids.Add("carol@acme.io", "Carol", "commit", day)
+	r.Flush()
+	out := buf.String()
+	if strings.Contains(out, "alice@acme.io") {
+		t.Errorf("second flush repeated an unchanged row:\n%s", out)
+	}
+	if !strings.Contains(out, "bob@acme.io") || !strings.Contains(out, "carol@acme.io") {
+		t.Errorf("second flush = %q, want the changed and new rows", out)
+	}
+	if !strings.Contains(out, "2024-05-02") {
+		t.Errorf("second flush = %q, want bob's new last-seen day", out)
+	}
+
+	// Third window: nothing changed, nothing printed
+	buf.Reset()
+	r.Flush()
+	if buf.Len() != 0 {
+		t.Errorf("idle flush printed %q", buf.String())
+	}
+}
+
+func TestSyslogReporterSendsJSONOverUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r, err := NewSyslogReporter(conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Report(Finding{Type: "email", Email: "alice@acme.io", Location: "https://example.com/c/1"})
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 4096)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<13>") || !strings.Contains(msg, " dossier[") {
+		t.Errorf("message %q lacks the user.notice priority or the dossier tag", msg)
+	}
+	var f Finding
+	if err := json.Unmarshal([]byte(msg[strings.Index(msg, "{"):]), &f); err != nil {
+		t.Fatalf("payload of %q is not a finding: %v", msg, err)
+	}
+	if f.Email != "alice@acme.io" || f.Location != "https://example.com/c/1" {
+		t.Errorf("payload = %+v", f)
+	}
+}
+
+func TestESBulkReporterWritesActionSourcePairs(t *testing.T) {
+	var buf strings.Builder
+	r := NewESBulkReporter(&buf, "dossier-2024.05")
+	findings := []Finding{
+		{Type: "email", Email: "alice@acme.io", Name: "Alice \"Al\" Smith", Location: "https://example.com/c/1"},
+		{Type: "os", Signature: "Fedora Linux", Email: "alice@acme.io", Location: "https://example.com/c/1"},
+		{Type: "email", Email: "alice@acme.io", Name: "Alice \"Al\" Smith", Location: "https://example.com/c/1"}, // re-ingested
+	}
+	for _, f := range findings {
+		r.Report(f)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2*len(findings) {
+		t.Fatalf("got %d lines, want an action and a source per finding:\n%s", len(lines), buf.String())
+	}
+	var ids []string
+	for i := 0; i < len(lines); i += 2 {
+		var action map[string]map[string]string
+		if err := json.Unmarshal([]byte(lines[i]), &action); err != nil {
+			t.Fatalf("action line %q: %v", lines[i], err)
+		}
+		index, ok := action["index"]
+		if !ok || len(action) != 1 || index["_index"] != "dossier-2024.05" || len(index["_id"]) != 40 {
+			t.Errorf("action line = %s", lines[i])
+		}
+		ids = append(ids, index["_id"])
+
+		var f Finding
+		if err := json.Unmarshal([]byte(lines[i+1]), &f); err != nil {
+			t.Fatalf("source line %q: %v", lines[i+1], err)
+		}
+		if want := findings[i/2]; f != want {
+			t.Errorf("source = %+v, want %+v", f, want)
+		}
+	}
+	if ids[0] != ids[2] || ids[0] == ids[1] {
+		t.Errorf("ids = %v, want the same finding to keep its id and others to differ", ids)
+	}
+}
+
+func TestJSONLReporterGzipReadBack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.jsonl.gz")
+	r, err := NewJSONLReporter(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readBack := func() []Finding {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var findings []Finding
+		lines := bufio.NewScanner(gz)
+		for lines.Scan() {
+			var f Finding
+			if err := json.Unmarshal(lines.Bytes(), &f); err != nil {
+				t.Fatalf("line %q: %v", lines.Text(), err)
+			}
+			findings = append(findings, f)
+		}
+		return findings // a flushed but unclosed stream ends in an unexpected EOF
+	}
+
+	r.Report(Finding{Type: "email", Email: "alice@acme.io"})
+	r.Flush() // --flush-interval: what was written so far is readable
+	if got := readBack(); len(got) != 1 || got[0].Email != "alice@acme.io" {
+		t.Errorf("after flush read %+v, want alice", got)
+	}
+
+	r.Report(Finding{Type: "os", Signature: "Fedora Linux", Email: "bob@acme.io"})
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got := readBack()
+	if len(got) != 2 || got[1].Signature != "Fedora Linux" || got[1].Email != "bob@acme.io" {
+		t.Errorf("after close read %+v, want both findings", got)
+	}
+}
+
+func TestFormatEmail(t *testing.T) {
+	tests := []struct {
+		addr, format, want string
+	}{
+		{"alice@acme.io", "plain", "alice@acme.io"},
+		{"alice@acme.io", "", "alice@acme.io"},
+		{"alice@acme.io", "mailto", "mailto:alice@acme.io"},
+		{"alice@acme.io", "angle", "<alice@acme.io>"},
+		{"", "mailto", ""},
+		{"", "angle", ""},
+	}
+	for _, tt := range tests {
+		if got := FormatEmail(tt.addr, tt.format); got != tt.want {
+			t.Errorf("FormatEmail(%q, %q) = %q, want %q", tt.addr, tt.format, got, tt.want)
+		}
+	}
+}
+
+// capture returns what fn wrote to *file, os.Stdout or os.Stderr
+func capture(t *testing.T, file **os.File, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := *file
+	*file = w
+	done := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		buf.ReadFrom(r)
+		done <- buf.String()
+	}()
+	defer func() { *file = orig }()
+	fn()
+	w.Close()
+	return <-done
+}
+
+func TestRedactConfigAppliesToTextAndJSON(t *testing.T) {
+	redactions, err := LoadRedactions(writeFile(t, "redact.yaml", "patterns:\n  - id: codename\n    regex: \"Project[- ]Nightjar\"\n  - id: customer\n    regex: \"CUST-[0-9]{6}\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := []Finding{
+		{Type: "email", Email: "alice@acme.io", Name: "Alice (Project Nightjar)", Repo: "nightjar", Location: "https://example.com/acme/tool/commit/1?ref=CUST-123456"},
+		{Type: "repo_match", Signature: "Project-Nightjar", Value: "acme/project-nightjar", Location: "https://example.com/acme/project-nightjar"},
+	}
+	leaked := func(out string) bool {
+		return strings.Contains(out, "Nightjar") || strings.Contains(out, "CUST-123456")
+	}
+	run := func(r Reporter) {
+		e := NewEngine("github", nil, nil)
+		e.Redactions = redactions
+		e.Reporter = r
+		for _, f := range findings {
+			e.Report(f)
+		}
+		e.CloseReporter()
+	}
+
+	text := capture(t, &os.Stdout, func() { run(TextReporter{}) })
+	if leaked(text) || !strings.Contains(text, "Name: Alice (****)") || !strings.Contains(text, "ref=****") {
+		t.Errorf("text output not redacted:\n%s", text)
+	}
+
+	path := filepath.Join(t.TempDir(), "out.jsonl")
+	jr, err := NewJSONLReporter(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run(jr)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaked(string(data)) || !strings.Contains(string(data), `"name":"Alice (****)"`) || !strings.Contains(string(data), `"signature":"****"`) {
+		t.Errorf("JSON output not redacted:\n%s", data)
+	}
+	// Case differs from the pattern, so it stays: redaction is exactly what the patterns say
+	if !strings.Contains(string(data), "acme/project-nightjar") {
+		t.Errorf("JSON output redacted a value no pattern matches:\n%s", data)
+	}
+}
+
+func TestLogfmtValue(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"alice@acme.io", "alice@acme.io"},
+		{"https://example.com/c/1?a=b", `"https://example.com/c/1?a=b"`},
+		{"Alice Smith", `"Alice Smith"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`C:\path`, `"C:\\path"`},
+		{"line\nbreak", `"line\nbreak"`},
+		{"tab\there", `"tab\there"`},
+		{"Zoë", "Zoë"},
+	}
+	for _, tt := range tests {
+		if got := logfmtValue(tt.in); got != tt.want {
+			t.Errorf("logfmtValue(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestKVReporter(t *testing.T) {
+	var buf strings.Builder
+	r := KVReporter{W: &buf}
+	r.Report(Finding{Type: "email", Email: "alice@acme.io", Name: "Alice Smith", Seen: 2, Provider: "github"})
+	r.Report(Finding{Type: "saas_credential", Signature: "stripe_secret_key", Location: "https://example.com/c/1?x=1", Provider: "gitlab"}.WithSecret(strings.Repeat("9xQ", 8)))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want one per finding:\n%s", len(lines), buf.String())
+	}
+	if want := `type=email email=alice@acme.io name="Alice Smith" seen=2 source=github`; lines[0] != want {
+		t.Errorf("line 1 = %s\nwant     %s", lines[0], want)
+	}
+	if !strings.HasPrefix(lines[1], "type=saas_credential ") || !strings.HasSuffix(lines[1], " source=gitlab") ||
+		!strings.Contains(lines[1], ` location="https://example.com/c/1?x=1"`) || strings.Contains(lines[1], strings.Repeat("9xQ", 8)) {
+		t.Errorf("line 2 = %s, want a quoted location and the secret redacted", lines[1])
+	}
+}
+
+func TestFindingsAndIdentitiesRecordOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.ndjson")
+	jr, err := NewJSONLReporter(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine("github", cfg, nil)
+	e.Reporter = FindingsAndIdentitiesReporter{JSONLReporter: jr, Identities: e.Identities}
+	day := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	e.ProcessCommits([]Commit{
+		{ID: "1", Repo: "alice/tool", Time: day, Author: Person{Name: "Bob", Email: "bob@acme.io"}, Message: "Fix\n\nCo-authored-by: Alice <alice@acme.io>"},
+		{ID: "2", Repo: "alice/tool", Time: day.AddDate(0, 0, 1), Author: Person{Name: "Alice", Email: "alice@acme.io"}, Message: "More"},
+	})
+	e.SummarizeReporter()
+	e.CloseReporter()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	var identities []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("bad line %q: %v", line, err)
+		}
+		kind, _ := rec["record"].(string)
+		if n := len(order); n == 0 || order[n-1] != kind {
+			order = append(order, kind)
+		}
+		if kind == "identity" {
+			identities = append(identities, rec)
+		}
+	}
+	if got := strings.Join(order, " "); got != "finding summary identity" {
+		t.Errorf("record runs = %s, want findings, then the summary, then identities", got)
+	}
+	if len(identities) != 2 || identities[0]["email"] != "alice@acme.io" || identities[0]["commits"] != 2.0 ||
+		identities[0]["first_seen"] != "2024-05-01T10:00:00Z" || identities[1]["email"] != "bob@acme.io" {
+		t.Errorf("identity records = %v, want alice (2 commits) then bob", identities)
+	}
+}
@@ -0,0 +1,188 @@
+package dossier
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+)
+
+// ========================== SARIF ==========================
+
+// SARIF 2.1.0, trimmed to the parts a findings list needs
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string       `json:"id"`
+	ShortDescription     sarifMessage `json:"shortDescription"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          map[string]any    `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+	} `json:"physicalLocation"`
+}
+
+// SARIFReporter writes every finding as a result in one SARIF 2.1.0 log
+// once the scan ends, for code-scanning dashboards. Each email is a result
+// of the "email" rule; other findings get a rule per type and signature.
+type SARIFReporter struct {
+	w       io.Writer
+	file    *os.File
+	version string
+	rules   []sarifRule
+	ruleIdx map[string]int
+	results []sarifResult
+}
+
+// NewSARIFReporter writes to path, or to stdout when path is empty.
+// version is the tool version recorded in the log.
+func NewSARIFReporter(path, version string) (*SARIFReporter, error) {
+	r := &SARIFReporter{w: os.Stdout, version: version, rules: []sarifRule{}, ruleIdx: map[string]int{}, results: []sarifResult{}}
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		r.w, r.file = file, file
+	}
+	return r, nil
+}
+
+// sarifLevel maps a finding type to a SARIF level: leaked secrets are
+// errors, identities warnings and fingerprints notes
+func sarifLevel(f Finding) string {
+	switch f.Type {
+	case "os", "utility", "repo_match", "suspicious_date":
+		return "note"
+	}
+	if f.Secret != "" {
+		return "error"
+	}
+	return "warning"
+}
+
+func sarifRuleID(f Finding) string {
+	if f.Type == "email" || f.Signature == "" {
+		return f.Type
+	}
+	return f.Type + "/" + f.Signature
+}
+
+func (r *SARIFReporter) Report(f Finding) {
+	id := sarifRuleID(f)
+	idx, ok := r.ruleIdx[id]
+	if !ok {
+		rule := sarifRule{ID: id, ShortDescription: sarifMessage{Text: id}}
+		rule.DefaultConfiguration.Level = sarifLevel(f)
+		idx = len(r.rules)
+		r.rules = append(r.rules, rule)
+		r.ruleIdx[id] = idx
+	}
+
+	// The rule already names the signature, except for emails
+	text := id
+	var details []string
+	if f.Email != "" {
+		details = append(details, f.Email)
+	}
+	for _, field := range []struct{ label, value string }{
+		{"name", f.Name}, {"value", f.Value}, {"secret", f.Secret},
+	} {
+		if field.value != "" {
+			details = append(details, field.label+" "+field.value)
+		}
+	}
+	if f.Type == "email" && f.Signature != "" {
+		details = append(details, "found in "+f.Signature)
+	}
+	if len(details) > 0 {
+		text += ": " + strings.Join(details, ", ")
+	}
+	res := sarifResult{
+		RuleID:              id,
+		RuleIndex:           idx,
+		Level:               sarifLevel(f),
+		Message:             sarifMessage{Text: text},
+		PartialFingerprints: map[string]string{"dossierFinding/v1": f.Key()},
+		Properties:          map[string]any{},
+	}
+	if f.Location != "" {
+		var loc sarifLocation
+		loc.PhysicalLocation.ArtifactLocation.URI = f.Location
+		res.Locations = []sarifLocation{loc}
+	}
+	for k, v := range map[string]string{"date": ISODate(f.Date), "repo": f.Repo, "provider": f.Provider, "class": f.Class, "encoding": f.Encoding} {
+		if v != "" {
+			res.Properties[k] = v
+		}
+	}
+	if f.Seen > 0 {
+		res.Properties["seen"] = f.Seen
+	}
+	r.results = append(r.results, res)
+}
+
+func (r *SARIFReporter) Close() error {
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "dossier",
+				Version:        r.version,
+				InformationURI: "https://github.com/0x4f53/dossier",
+				Rules:          r.rules,
+			}},
+			Results: r.results,
+		}},
+	}
+	enc := json.NewEncoder(r.w)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(log)
+	if r.file != nil {
+		if cerr := r.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
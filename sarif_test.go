@@ -0,0 +1,144 @@
+package dossier
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sarifSchema is the part of the SARIF 2.1.0 schema
+// (https://json.schemastore.org/sarif-2.1.0.json) covering the objects
+// SARIFReporter writes, with the same required fields, enums and closed
+// property sets
+func sarifSchema() map[string]any {
+	object := func(required []string, props map[string]any) map[string]any {
+		return map[string]any{"type": "object", "required": required, "properties": props, "additionalProperties": false}
+	}
+	array := func(items map[string]any) map[string]any { return map[string]any{"type": "array", "items": items} }
+	str := map[string]any{"type": "string"}
+	uri := map[string]any{"type": "string", "format": "uri"}
+	level := map[string]any{"type": "string", "enum": []string{"none", "note", "warning", "error"}}
+	message := object([]string{"text"}, map[string]any{"text": str})
+
+	rule := object([]string{"id"}, map[string]any{
+		"id":                   str,
+		"shortDescription":     message,
+		"defaultConfiguration": object(nil, map[string]any{"level": level}),
+	})
+	location := object(nil, map[string]any{
+		"physicalLocation": object([]string{"artifactLocation"}, map[string]any{
+			"artifactLocation": object(nil, map[string]any{"uri": uri}),
+		}),
+	})
+	result := object([]string{"message"}, map[string]any{
+		"ruleId":              str,
+		"ruleIndex":           map[string]any{"type": "integer", "minimum": -1},
+		"level":               level,
+		"message":             message,
+		"locations":           array(location),
+		"partialFingerprints": map[string]any{"type": "object"},
+		"properties":          map[string]any{"type": "object"},
+	})
+	driver := object([]string{"name"}, map[string]any{
+		"name":           str,
+		"version":        str,
+		"informationUri": uri,
+		"rules":          array(rule),
+	})
+	run := object([]string{"tool"}, map[string]any{
+		"tool":    object([]string{"driver"}, map[string]any{"driver": driver}),
+		"results": array(result),
+	})
+	return object([]string{"version", "runs"}, map[string]any{
+		"$schema": uri,
+		"version": map[string]any{"type": "string", "enum": []string{"2.1.0"}},
+		"runs":    array(run),
+	})
+}
+
+func TestSARIFReporterMatchesSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.sarif")
+	r, err := NewSARIFReporter(path, "1.4.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := "https://github.com/alice/tool/commit/abc"
+	r.Report(Finding{Type: "email", Email: "alice@acme.io", Name: "Alice", Repo: "alice/tool", Location: commit, Date: "2024-05-01 10:00:00 UTC"})
+	r.Report(Finding{Type: "email", Email: "bob@acme.io", Signature: "Co-authored-by", Location: commit, Seen: 3})
+	r.Report(Finding{Type: "os", Signature: "Fedora Linux", Value: "fedora", Location: commit})
+	r.Report(Finding{Type: "saas_credential", Signature: "stripe_secret_key", Location: commit}.WithSecret(strings.Repeat("9xQ", 8)))
+	r.Report(Finding{Type: "account", Value: "alice"}) // no location
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), strings.Repeat("9xQ", 8)) {
+		t.Error("log holds the unredacted secret")
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range validate(sarifSchema(), doc, "$") {
+		t.Error(e)
+	}
+	bad := `{"version":"2.0.0","runs":[{"tool":{"driver":{"name":"dossier","rules":[{"id":"email","help":"x"}]}},
+		"results":[{"ruleIndex":0.5,"level":"fatal","message":{"text":"t"},"locations":[{"physicalLocation":{"artifactLocation":{"uri":"tool/commit/abc"}}}]},{"ruleId":"email"}]}]}`
+	var badDoc map[string]any
+	if err := json.Unmarshal([]byte(bad), &badDoc); err != nil {
+		t.Fatal(err)
+	}
+	if errs := validate(sarifSchema(), badDoc, "$"); len(errs) != 6 {
+		t.Errorf("violations in a broken log = %q, want 6", errs)
+	}
+
+	// What the schema can't say: results point at their rules, one rule per ID
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatal(err)
+	}
+	if len(log.Runs) != 1 {
+		t.Fatalf("%d runs, want 1", len(log.Runs))
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "dossier" || run.Tool.Driver.Version != "1.4.0" {
+		t.Errorf("driver = %+v", run.Tool.Driver)
+	}
+	var ids []string
+	for _, rule := range run.Tool.Driver.Rules {
+		ids = append(ids, rule.ID)
+	}
+	if got, want := strings.Join(ids, " "), "email os/Fedora Linux saas_credential/stripe_secret_key account"; got != want {
+		t.Errorf("rules = %s, want %s", got, want)
+	}
+	if len(run.Results) != 5 {
+		t.Fatalf("%d results, want 5", len(run.Results))
+	}
+	wantLevels := []string{"warning", "warning", "note", "error", "warning"}
+	for i, res := range run.Results {
+		if rule := run.Tool.Driver.Rules[res.RuleIndex]; rule.ID != res.RuleID {
+			t.Errorf("result %d: ruleIndex %d names %s, not %s", i, res.RuleIndex, rule.ID, res.RuleID)
+		}
+		if res.Level != wantLevels[i] {
+			t.Errorf("result %d: level %s, want %s", i, res.Level, wantLevels[i])
+		}
+		if res.PartialFingerprints["dossierFinding/v1"] == "" {
+			t.Errorf("result %d has no fingerprint", i)
+		}
+		if i < 4 && (len(res.Locations) != 1 || res.Locations[0].PhysicalLocation.ArtifactLocation.URI != commit) {
+			t.Errorf("result %d: locations = %+v, want the commit URL", i, res.Locations)
+		}
+	}
+	if res := run.Results[4]; res.Locations != nil {
+		t.Errorf("result without a location has locations %+v", res.Locations)
+	}
+	if got := run.Results[1].Message.Text; got != "email: bob@acme.io, found in Co-authored-by" {
+		t.Errorf("trailer message = %q", got)
+	}
+}
@@ -0,0 +1,208 @@
+package dossier
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ========================== Secrets ==========================
+
+type SecretMatch struct {
+	ID     string
+	Value  string
+	Offset int // where Value starts in the scanned text
+}
+
+// Patterns may wrap the secret in a capture group to keep the boundary
+// checks around it out of the reported value.
+var GoogleCredentialPatterns = []Pattern{
+	{ID: "api_key", Regex: `(?:^|[^0-9A-Za-z_-])(AIza[0-9A-Za-z_-]{35})(?:$|[^0-9A-Za-z_-])`},
+	{ID: "oauth_client_id", Regex: `[0-9]+-[0-9a-z]+\.apps\.googleusercontent\.com`},
+	{ID: "oauth_client_secret", Regex: `(?:^|[^0-9A-Za-z_-])(GOCSPX-[0-9A-Za-z_-]{28})(?:$|[^0-9A-Za-z_-])`},
+	{ID: "oauth_client_secret", Regex: `"client_secret"\s*:\s*"([0-9A-Za-z_-]{24})"`},
+}
+
+// Common SaaS API keys. These prefixes are distinctive enough that false
+// positives are rare.
+var SaaSCredentialPatterns = []Pattern{
+	{ID: "stripe_secret_key", Regex: `(?:^|[^0-9A-Za-z_])(sk_live_[0-9A-Za-z]{24,})(?:$|[^0-9A-Za-z_])`},
+	{ID: "stripe_restricted_key", Regex: `(?:^|[^0-9A-Za-z_])(rk_live_[0-9A-Za-z]{24,})(?:$|[^0-9A-Za-z_])`},
+	{ID: "twilio_api_key", Regex: `(?:^|[^0-9A-Za-z_])(SK[0-9a-f]{32})(?:$|[^0-9A-Za-z_])`},
+	{ID: "twilio_account_sid", Regex: `(?:^|[^0-9A-Za-z_])(AC[0-9a-f]{32})(?:$|[^0-9A-Za-z_])`},
+	{ID: "sendgrid_api_key", Regex: `(?:^|[^0-9A-Za-z_-])(SG\.[0-9A-Za-z_-]{22}\.[0-9A-Za-z_-]{43})(?:$|[^0-9A-Za-z_-])`},
+}
+
+// Everything DecodeAndRescan looks for inside encoded blobs
+var EncodedSecretPatterns = append(append([]Pattern{}, GoogleCredentialPatterns...), SaaSCredentialPatterns...)
+
+func FindSecrets(text string, patterns []Pattern) []SecretMatch {
+	var matches []SecretMatch
+	for _, pat := range patterns {
+		re := compilePattern(pat.Regex)
+		if re == nil {
+			continue
+		}
+		for _, m := range re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[0], m[1]
+			if len(m) > 2 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			matches = append(matches, SecretMatch{ID: pat.ID, Value: text[start:end], Offset: start})
+		}
+	}
+	return matches
+}
+
+// Redact keeps just enough of a secret to recognise its kind.
+func Redact(secret string) string {
+	if len(secret) <= 8 {
+		return "****"
+	}
+	return secret[:4] + "****"
+}
+
+// ========================== Registry Credentials ==========================
+
+type RegistryCredential struct {
+	Kind     string // "npm", "pypi" or "docker"
+	Registry string // host, or the .pypirc section when it names no URL
+	Value    string
+	Offset   int
+}
+
+var (
+	npmAuthRe    = regexp.MustCompile(`(?m)^[ \t]*(?://([^\s:/]+)[^\s:]*:)?(_authToken|_auth|_password)[ \t]*=[ \t]*"?([^\s"]+)`)
+	dockerAuthRe = regexp.MustCompile(`"([^"]+)"\s*:\s*\{\s*"auth"\s*:\s*"([A-Za-z0-9+/=]{8,})"`)
+	iniSectionRe = regexp.MustCompile(`^\[([^\]]+)\]$`)
+	iniKeyRe     = regexp.MustCompile(`^(repository|password)[ \t]*[=:][ \t]*(\S+)`)
+)
+
+// FindRegistryCredentials finds package and image registry logins pasted
+// from .npmrc, .pypirc and Docker config.json.
+func FindRegistryCredentials(text string) []RegistryCredential {
+	var found []RegistryCredential
+	for _, m := range npmAuthRe.FindAllStringSubmatchIndex(text, -1) {
+		value := text[m[6]:m[7]]
+		if strings.HasPrefix(value, "${") {
+			continue // read from the environment, nothing leaked
+		}
+		registry := "registry.npmjs.org"
+		if m[2] >= 0 {
+			registry = text[m[2]:m[3]]
+		}
+		found = append(found, RegistryCredential{Kind: "npm", Registry: registry, Value: value, Offset: m[6]})
+	}
+
+	// .pypirc: a password inside a [pypi]/[testpypi] section or one that
+	// names its repository
+	section, repository, offset := "", "", 0
+	for _, line := range strings.SplitAfter(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if m := iniSectionRe.FindStringSubmatch(trimmed); m != nil {
+			section, repository = m[1], ""
+		} else if m := iniKeyRe.FindStringSubmatch(trimmed); m != nil && section != "" {
+			if m[1] == "repository" {
+				if u, err := url.Parse(m[2]); err == nil && u.Host != "" {
+					repository = u.Host
+				}
+			} else if repository != "" || section == "pypi" || section == "testpypi" {
+				registry := repository
+				if registry == "" {
+					registry = section
+				}
+				found = append(found, RegistryCredential{Kind: "pypi", Registry: registry, Value: m[2], Offset: offset + strings.Index(line, m[2])})
+			}
+		}
+		offset += len(line)
+	}
+
+	// Docker "auths": the auth field must decode to user:password
+	for _, m := range dockerAuthRe.FindAllStringSubmatchIndex(text, -1) {
+		decoded, err := base64.StdEncoding.DecodeString(text[m[4]:m[5]])
+		if err != nil || !strings.Contains(string(decoded), ":") || !isPrintable(decoded) {
+			continue
+		}
+		registry := text[m[2]:m[3]]
+		if u, err := url.Parse(registry); err == nil && u.Host != "" {
+			registry = u.Host
+		}
+		found = append(found, RegistryCredential{Kind: "docker", Registry: registry, Value: string(decoded), Offset: m[4]})
+	}
+	return found
+}
+
+// ========================== Encoded Secrets ==========================
+
+type EncodedMatch struct {
+	SecretMatch
+	Encoding string // "base64" or "hex"
+}
+
+// Only tokens long enough to hide a credential are worth decoding
+var (
+	hexToken    = regexp.MustCompile(`[0-9A-Fa-f]{32,}`)
+	base64Token = regexp.MustCompile(`[A-Za-z0-9+/_-]{24,}={0,2}`)
+)
+
+func isHexString(s string) bool {
+	return hexToken.FindString(s) == s
+}
+
+// Decoded SHAs and other binary noise are almost never printable, so
+// requiring printable output is what keeps commit hashes out.
+func isPrintable(b []byte) bool {
+	if len(b) == 0 {
+		return false
+	}
+	for _, c := range b {
+		if (c < 0x20 || c > 0x7e) && c != '\n' && c != '\r' && c != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+func decodeBase64(tok string) ([]byte, bool) {
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if b, err := enc.DecodeString(tok); err == nil {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+func DecodeAndRescan(text string, patterns []Pattern) []EncodedMatch {
+	var matches []EncodedMatch
+	for _, loc := range hexToken.FindAllStringIndex(text, -1) {
+		tok := text[loc[0]:loc[1]]
+		if len(tok)%2 != 0 {
+			continue
+		}
+		b, err := hex.DecodeString(tok)
+		if err != nil || !isPrintable(b) {
+			continue
+		}
+		for _, m := range FindSecrets(string(b), patterns) {
+			m.Offset = loc[0] // the encoded token's position, not the decoded one
+			matches = append(matches, EncodedMatch{SecretMatch: m, Encoding: "hex"})
+		}
+	}
+	for _, loc := range base64Token.FindAllStringIndex(text, -1) {
+		tok := text[loc[0]:loc[1]]
+		if isHexString(tok) {
+			continue // already tried as hex
+		}
+		b, ok := decodeBase64(tok)
+		if !ok || !isPrintable(b) {
+			continue
+		}
+		for _, m := range FindSecrets(string(b), patterns) {
+			m.Offset = loc[0]
+			matches = append(matches, EncodedMatch{SecretMatch: m, Encoding: "base64"})
+		}
+	}
+	return matches
+}
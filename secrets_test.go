@@ -0,0 +1,156 @@
+package dossier
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Fixtures are built from repeated characters so they can't be mistaken
+// for leaked credentials
+var (
+	fakeGoogleKey    = "AIza" + strings.Repeat("Sy0_-x", 5) + "abcde" // 35 after the prefix
+	fakeGoogleSecret = "GOCSPX-" + strings.Repeat("aB3_", 7)          // 28 after the prefix
+	fakeJSONSecret   = strings.Repeat("xY9-", 6)                      // 24
+	fakeClientID     = "123456789012-" + strings.Repeat("a1", 16) + ".apps.googleusercontent.com"
+)
+
+// secretIDs lists "id=value" for each match, for compact comparisons
+func secretIDs(matches []SecretMatch) []string {
+	var out []string
+	for _, m := range matches {
+		out = append(out, m.ID+"="+m.Value)
+	}
+	return out
+}
+
+func TestGoogleCredentialPatterns(t *testing.T) {
+	tests := []struct {
+		name, text string
+		want       []string
+	}{
+		{"api key", "maps key " + fakeGoogleKey + " in config", []string{"api_key=" + fakeGoogleKey}},
+		{"oauth client id", "client " + fakeClientID, []string{"oauth_client_id=" + fakeClientID}},
+		{"oauth client secret", "secret=" + fakeGoogleSecret, []string{"oauth_client_secret=" + fakeGoogleSecret}},
+		{"client_secret.json", `{"installed":{"client_secret": "` + fakeJSONSecret + `"}}`, []string{"oauth_client_secret=" + fakeJSONSecret}},
+
+		{"api key one short", fakeGoogleKey[:len(fakeGoogleKey)-1], nil},
+		{"api key one long", fakeGoogleKey + "z", nil},
+		{"api key inside a word", "x" + fakeGoogleKey, nil},
+		{"lowercase prefix", "aiza" + fakeGoogleKey[4:], nil},
+		{"secret one short", fakeGoogleSecret[:len(fakeGoogleSecret)-1], nil},
+		{"client id without number", "my-app.apps.googleusercontent.com", nil},
+		{"client_secret too short", `"client_secret": "` + fakeJSONSecret[1:] + `"`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := secretIDs(FindSecrets(tt.text, GoogleCredentialPatterns))
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeAndRescan(t *testing.T) {
+	stripe := "sk_live_" + strings.Repeat("0aZ", 8)
+	b64 := base64.StdEncoding.EncodeToString([]byte("STRIPE_KEY=" + stripe))
+	tests := []struct {
+		name, text string
+		want       []string // encoding id=value@offset
+	}{
+		{"hex", "payload 4141 " + hex.EncodeToString([]byte("key: "+fakeGoogleKey)), []string{"hex api_key=" + fakeGoogleKey + "@13"}},
+		{"base64", "config: " + b64, []string{"base64 stripe_secret_key=" + stripe + "@8"}},
+		{"base64url", base64.RawURLEncoding.EncodeToString([]byte(fakeGoogleSecret + "?")), []string{"base64 oauth_client_secret=" + fakeGoogleSecret + "@0"}},
+		{"commit sha", "Reverts da39a3ee5e6b4b0d3255bfef95601890afd80709 and 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", nil},
+		{"base64 of binary", base64.StdEncoding.EncodeToString([]byte{0, 1, 2, 0xff, 0xfe, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17}), nil},
+		{"printable but no secret", base64.StdEncoding.EncodeToString([]byte("just an ordinary sentence here")), nil},
+		{"plain secret is not encoded", fakeGoogleKey, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, m := range DecodeAndRescan(tt.text, EncodedSecretPatterns) {
+				got = append(got, fmt.Sprintf("%s %s=%s@%d", m.Encoding, m.ID, m.Value, m.Offset))
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaaSCredentialPatterns(t *testing.T) {
+	stripe := "sk_live_" + strings.Repeat("9xQ", 8)
+	restricted := "rk_live_" + strings.Repeat("0aZ", 9)
+	twilioKey := "SK" + strings.Repeat("0123456789abcdef", 2)
+	twilioSID := "AC" + strings.Repeat("fedcba9876543210", 2)
+	sendgrid := "SG." + strings.Repeat("aB3_-", 4) + "xy" + "." + strings.Repeat("Zz9-_", 8) + "abc"
+	tests := []struct {
+		name, text string
+		want       []string
+	}{
+		{"stripe secret", "STRIPE_KEY=" + stripe, []string{"stripe_secret_key=" + stripe}},
+		{"stripe restricted", "key: " + restricted, []string{"stripe_restricted_key=" + restricted}},
+		{"twilio api key", "twilio " + twilioKey, []string{"twilio_api_key=" + twilioKey}},
+		{"twilio account sid", "sid=" + twilioSID, []string{"twilio_account_sid=" + twilioSID}},
+		{"sendgrid", "SENDGRID_API_KEY=" + sendgrid, []string{"sendgrid_api_key=" + sendgrid}},
+
+		{"stripe test key", "sk_test_" + stripe[8:], nil},
+		{"stripe key too short", "sk_live_" + strings.Repeat("a", 23), nil},
+		{"twilio uppercase hex", "SK" + strings.ToUpper(twilioKey[2:]), nil},
+		{"twilio one short", twilioKey[:len(twilioKey)-1], nil},
+		{"twilio inside a word", "ASK" + twilioKey[2:], nil},
+		{"sendgrid wrong segment", "SG." + strings.Repeat("a", 21) + "." + strings.Repeat("b", 43), nil},
+		{"prose", "Ask the SK team about AC power and sk_live demos", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := secretIDs(FindSecrets(tt.text, SaaSCredentialPatterns))
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindRegistryCredentials(t *testing.T) {
+	npmToken := "npm_" + strings.Repeat("aB3", 12)
+	pypiToken := "pypi-" + strings.Repeat("Ag0x", 10)
+	dockerAuth := base64.StdEncoding.EncodeToString([]byte("alice:" + strings.Repeat("pw9", 6)))
+	tests := []struct {
+		name, text string
+		want       []string // kind registry value
+	}{
+		{"npmrc default registry", "_authToken=" + npmToken + "\n", []string{"npm registry.npmjs.org " + npmToken}},
+		{"npmrc scoped registry", "@acme:registry=https://npm.acme.io/\n//npm.acme.io/:_authToken=\"" + npmToken + "\"\n", []string{"npm npm.acme.io " + npmToken}},
+		{"npmrc from the environment", "//registry.npmjs.org/:_authToken=${NPM_TOKEN}\n", nil},
+		{"pypirc pypi section", "[distutils]\nindex-servers = pypi\n\n[pypi]\nusername = __token__\npassword = " + pypiToken + "\n", []string{"pypi pypi " + pypiToken}},
+		{"pypirc private index", "[internal]\nrepository = https://pypi.acme.io/simple/\npassword: hunter2hunter2\n", []string{"pypi pypi.acme.io hunter2hunter2"}},
+		{"pypirc unknown section", "[tool.black]\npassword = notacredential\n", nil},
+		{"docker config", `{"auths": {"https://index.docker.io/v1/": {"auth": "` + dockerAuth + `"}, "ghcr.io": {"auth": "` + dockerAuth + `"}}}`,
+			[]string{"docker index.docker.io alice:" + strings.Repeat("pw9", 6), "docker ghcr.io alice:" + strings.Repeat("pw9", 6)}},
+		{"docker auth without a password", `{"auths": {"ghcr.io": {"auth": "` + base64.StdEncoding.EncodeToString([]byte("alicealice")) + `"}}}`, nil},
+		{"docker auth not base64", `{"auths": {"ghcr.io": {"auth": "notbase64!!"}}}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range FindRegistryCredentials(tt.text) {
+				got = append(got, c.Kind+" "+c.Registry+" "+c.Value)
+				at := c.Value
+				if c.Kind == "docker" {
+					at = dockerAuth // the match is the encoded field
+				}
+				if !strings.HasPrefix(tt.text[c.Offset:], at) {
+					t.Errorf("offset %d does not point at %s", c.Offset, at)
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
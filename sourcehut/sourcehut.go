@@ -0,0 +1,444 @@
+package sourcehut
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/0x4f53/dossier"
+)
+
+// ========================== Structs ==========================
+
+// Signature is a commit author or committer as git.sr.ht reports it
+type Signature struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Time  time.Time `json:"time"`
+}
+
+type SourceHutCommit struct {
+	ID        string    `json:"id"`
+	Message   string    `json:"message"`
+	Author    Signature `json:"author"`
+	Committer Signature `json:"committer"`
+}
+
+type CommitCursor struct {
+	Results []SourceHutCommit `json:"results"`
+	Cursor  *string           `json:"cursor"` // null on the last page
+}
+
+type Repo struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Visibility  string    `json:"visibility"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+	Owner       struct {
+		CanonicalName string `json:"canonicalName"` // "~username"
+	} `json:"owner"`
+}
+
+type RepoCursor struct {
+	Results []Repo  `json:"results"`
+	Cursor  *string `json:"cursor"`
+}
+
+// graphQLResponse is the envelope of every git.sr.ht reply. Errors come
+// back with HTTP 200, so they must be checked separately.
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// ========================== Constants ==========================
+
+const providerName = "sourcehut"
+
+// defaultBaseURL is the public git.sr.ht instance
+const defaultBaseURL = "https://git.sr.ht"
+
+// ========================== Scanner ==========================
+
+// SourceHutScanner holds everything one scan needs, so several can run in a process
+// and tests can supply their own Doer and Reporter
+type SourceHutScanner struct {
+	*dossier.Engine
+	Token   string
+	BaseURL string // git.sr.ht instance, e.g. https://git.example.org for a self-hosted one
+}
+
+var _ dossier.Scanner = (*SourceHutScanner)(nil)
+
+func NewScanner(token string, cfg *dossier.Config, blacklist []*regexp.Regexp) *SourceHutScanner {
+	s := &SourceHutScanner{Engine: dossier.NewEngine(providerName, cfg, blacklist), Token: token, BaseURL: defaultBaseURL}
+	s.Reporter = dossier.TextReporter{}
+	s.Authorize = s.authorize
+	return s
+}
+
+// ========================== HTTP Helpers ==========================
+
+func (s *SourceHutScanner) authorize(req *http.Request) {
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token) // personal access token from meta.sr.ht
+	}
+}
+
+func (s *SourceHutScanner) queryURL() string {
+	return strings.TrimSuffix(s.BaseURL, "/") + "/query"
+}
+
+// query runs a GraphQL query and decodes its data into v. Queries are
+// POSTs, cached by their payload; with nothing to revalidate, disk cache
+// entries are used until the TTL runs out.
+func (s *SourceHutScanner) query(ctx context.Context, query string, vars map[string]any, v any) error {
+	payload, err := json.Marshal(map[string]any{"query": query, "variables": vars})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", s.queryURL(), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	body, status, _, err := s.Fetch(req)
+	if err != nil {
+		return err
+	}
+	if status != 200 {
+		return fmt.Errorf("SourceHut API error %d\n%s", status, string(body))
+	}
+	var resp graphQLResponse
+	if err := dossier.DecodeJSON(s.queryURL(), status, body, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		msgs := make([]string, len(resp.Errors))
+		for i, e := range resp.Errors {
+			msgs[i] = e.Message
+		}
+		return fmt.Errorf("SourceHut API error: %s", strings.Join(msgs, "; "))
+	}
+	return dossier.DecodeJSON(s.queryURL(), status, resp.Data, v)
+}
+
+// ========================== Repos and Commits ==========================
+
+// git.sr.ht has no server-side ordering, so --repo-sort is applied to the
+// fetched list. It has no stars either, and pushes show up as updates.
+var repoSortFields = map[string]func(Repo) time.Time{
+	"updated": func(r Repo) time.Time { return r.Updated },
+	"created": func(r Repo) time.Time { return r.Created },
+	"pushed":  func(r Repo) time.Time { return r.Updated },
+}
+
+func (s *SourceHutScanner) sortRepos(repos []Repo) {
+	if field, ok := repoSortFields[s.Options.RepoSort]; ok {
+		sort.SliceStable(repos, func(i, j int) bool { return field(repos[i]).After(field(repos[j])) })
+	}
+}
+
+// repoFields is selected wherever a repository is fetched
+const repoFields = `id name description visibility created updated owner { canonicalName }`
+
+// repoURL is the web page of a repo, also the prefix of its commit links
+func (s *SourceHutScanner) repoURL(r Repo) string {
+	return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(s.BaseURL, "/"), r.Owner.CanonicalName, r.Name)
+}
+
+// trimOwner strips the "~" SourceHut puts in front of usernames
+func trimOwner(username string) string {
+	return strings.TrimPrefix(username, "~")
+}
+
+func (s *SourceHutScanner) GetUserRepos(ctx context.Context, username string) ([]Repo, error) {
+	const q = `query($user: String!, $cursor: Cursor) {
+	user(username: $user) { repositories(cursor: $cursor) { results { ` + repoFields + ` } cursor } }
+}`
+	var repos []Repo
+	var cursor *string
+	for n := 1; n == 1 || cursor != nil; n++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if s.PageLimitReached(n, "repos of "+username) {
+			break
+		}
+		var data struct {
+			User *struct {
+				Repositories RepoCursor `json:"repositories"`
+			} `json:"user"`
+		}
+		if err := s.query(ctx, q, map[string]any{"user": trimOwner(username), "cursor": cursor}, &data); err != nil {
+			return nil, err
+		}
+		if data.User == nil {
+			return nil, fmt.Errorf("no SourceHut user %q", username)
+		}
+		repos = append(repos, data.User.Repositories.Results...)
+		cursor = data.User.Repositories.Cursor
+	}
+	s.sortRepos(repos)
+	return repos, nil
+}
+
+func (s *SourceHutScanner) ScanRepoCommits(ctx context.Context, r Repo, ascending bool) {
+	const q = `query($user: String!, $repo: String!, $cursor: Cursor) {
+	user(username: $user) { repository(name: $repo) { log(cursor: $cursor) {
+		results { id message author { name email time } committer { name email time } }
+		cursor
+	} } }
+}`
+	var allCommits []SourceHutCommit
+	var cursor *string
+	for n := 1; n == 1 || cursor != nil; n++ {
+		if ctx.Err() != nil {
+			return
+		}
+		if s.PageLimitReached(n, "commits of "+r.Name) {
+			break
+		}
+		var data struct {
+			User *struct {
+				Repository *struct {
+					Log CommitCursor `json:"log"`
+				} `json:"repository"`
+			} `json:"user"`
+		}
+		vars := map[string]any{"user": trimOwner(r.Owner.CanonicalName), "repo": r.Name, "cursor": cursor}
+		if err := s.query(ctx, q, vars, &data); err != nil {
+			s.RepoFailed(r.Name, len(allCommits), err)
+			break
+		}
+		if data.User == nil || data.User.Repository == nil {
+			s.RepoFailed(r.Name, len(allCommits), errors.New("repository not found, deleted while scanning?"))
+			break
+		}
+		allCommits = append(allCommits, data.User.Repository.Log.Results...)
+		cursor = data.User.Repository.Log.Cursor
+	}
+
+	// Reverse if ascending
+	if ascending {
+		for i, j := 0, len(allCommits)-1; i < j; i, j = i+1, j-1 {
+			allCommits[i], allCommits[j] = allCommits[j], allCommits[i]
+		}
+	}
+
+	s.ProcessCommits(s.commits(allCommits, r))
+}
+
+// commits converts git.sr.ht commits for ProcessCommits
+func (s *SourceHutScanner) commits(log []SourceHutCommit, r Repo) []dossier.Commit {
+	out := make([]dossier.Commit, len(log))
+	for i, c := range log {
+		out[i] = dossier.Commit{
+			ID:       c.ID,
+			Repo:     r.Name,
+			Location: s.repoURL(r) + "/commit/" + c.ID,
+			Time:     c.Author.Time,
+			Author:   dossier.Person{Name: c.Author.Name, Email: c.Author.Email},
+			Message:  c.Message,
+			Text:     fmt.Sprintf("%s %s <%s> %s", c.Message, c.Author.Name, c.Author.Email, r.Name),
+		}
+	}
+	return out
+}
+
+// ========================== Metadata Files ==========================
+
+// ScanRepoMetadata reads well-known contributor files at HEAD
+func (s *SourceHutScanner) ScanRepoMetadata(ctx context.Context, r Repo) {
+	const q = `query($user: String!, $repo: String!, $path: String!) {
+	user(username: $user) { repository(name: $repo) { path(path: $path) { object { ... on TextBlob { text } } } } }
+}`
+	for _, candidates := range dossier.MetadataFiles {
+		for _, path := range candidates {
+			var data struct {
+				User *struct {
+					Repository *struct {
+						Path *struct {
+							Object struct {
+								Text *string `json:"text"`
+							} `json:"object"`
+						} `json:"path"`
+					} `json:"repository"`
+				} `json:"user"`
+			}
+			vars := map[string]any{"user": trimOwner(r.Owner.CanonicalName), "repo": r.Name, "path": path}
+			if err := s.query(ctx, q, vars, &data); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				continue
+			}
+			if data.User == nil || data.User.Repository == nil || data.User.Repository.Path == nil || data.User.Repository.Path.Object.Text == nil {
+				continue // no such file, or a binary one
+			}
+			location := fmt.Sprintf("%s/tree/HEAD/item/%s", s.repoURL(r), path)
+			s.ReportMetadata(path, *data.User.Repository.Path.Object.Text, location)
+			break
+		}
+	}
+}
+
+// ========================== Profile Mode ==========================
+
+// ScanProfile exists for parity with the other providers: git.sr.ht
+// doesn't expose a user's email address, so there is nothing to report.
+func (s *SourceHutScanner) ScanProfile(ctx context.Context, username string) error {
+	dossier.Log.Infof("SourceHut profiles don't expose email addresses, nothing to report for %s\n", username)
+	return nil
+}
+
+// ========================== Scanning ==========================
+
+// ScanUser scans a user and returns the findings reported along the way
+func (s *SourceHutScanner) ScanUser(ctx context.Context, username string) ([]dossier.Finding, error) {
+	return s.Collect(func() error { return s.scanUser(ctx, username) })
+}
+
+// ScanOrg always fails: SourceHut has no organizations
+func (s *SourceHutScanner) ScanOrg(ctx context.Context, org string) ([]dossier.Finding, error) {
+	return s.Collect(func() error { return s.scanOrg(ctx, org) })
+}
+
+// ScanRepo is ScanUser for a single repo ("~user/repo")
+func (s *SourceHutScanner) ScanRepo(ctx context.Context, fullName string) ([]dossier.Finding, error) {
+	return s.Collect(func() error { return s.scanRepo(ctx, fullName) })
+}
+
+func (s *SourceHutScanner) scanUser(ctx context.Context, username string) error {
+	if s.Options.ProfileOnly {
+		dossier.Log.Infof("Fetching profile emails for user: %s\n\n", username)
+		return s.ScanProfile(ctx, username)
+	}
+
+	dossier.Log.Infof("Scanning SourceHut commits for user: %s\n\n", username)
+
+	repos, err := s.GetUserRepos(ctx, username)
+	if err != nil {
+		return fmt.Errorf("fetching repos: %w", err)
+	}
+	return s.scanRepos(ctx, repos)
+}
+
+func (s *SourceHutScanner) scanOrg(ctx context.Context, org string) error {
+	return fmt.Errorf("SourceHut has no organizations, scan the user %s instead", org)
+}
+
+// scanRepo scans a single repo, given as "~user/repo"
+func (s *SourceHutScanner) scanRepo(ctx context.Context, fullName string) error {
+	owner, name, ok := strings.Cut(fullName, "/")
+	if !ok || owner == "" || name == "" {
+		return fmt.Errorf("invalid repo %q (want ~user/repo)", fullName)
+	}
+	const q = `query($user: String!, $repo: String!) {
+	user(username: $user) { repository(name: $repo) { ` + repoFields + ` } }
+}`
+	var data struct {
+		User *struct {
+			Repository *Repo `json:"repository"`
+		} `json:"user"`
+	}
+	if err := s.query(ctx, q, map[string]any{"user": trimOwner(owner), "repo": name}, &data); err != nil {
+		return err
+	}
+	if data.User == nil || data.User.Repository == nil {
+		return fmt.Errorf("no SourceHut repo %s", fullName)
+	}
+	return s.scanRepos(ctx, []Repo{*data.User.Repository})
+}
+
+// scanRepos scans the given repos, skipping inactive ones, up to
+// --repo-limit
+func (s *SourceHutScanner) scanRepos(ctx context.Context, repos []Repo) error {
+	scanned := 0
+	for i, r := range repos {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.Progress.SetRepo(i+1, len(repos))
+		if !s.Options.ActiveSince.IsZero() && r.Updated.Before(s.Options.ActiveSince) {
+			dossier.Log.Infof("Skipping %s: no activity since %s\n", r.Name, s.Options.ActiveSince.Format("2006-01-02"))
+			continue
+		}
+		if s.Options.RepoLimit > 0 && scanned >= s.Options.RepoLimit {
+			dossier.Log.Warnf("Reached --repo-limit of %d repos\n", s.Options.RepoLimit)
+			break
+		}
+		scanned++
+		s.Stats.ReposScanned++
+		s.WithRepoBuffer(fmt.Sprintf("Scanning repo: %s\n", r.Name), func() {
+			if s.Options.MatchRepos {
+				s.ReportRepoMatches(r.Name, r.Description, nil, s.repoURL(r))
+			}
+			s.ScanRepoCommits(ctx, r, s.Options.OldestFirst)
+			if s.Options.ScanMetadata {
+				s.ScanRepoMetadata(ctx, r)
+			}
+		})
+	}
+	return nil
+}
+
+// ========================== Main ==========================
+
+// Main runs the SourceHut command line tool
+func Main() {
+	s := NewScanner("", nil, nil)
+	cmd := dossier.NewCommand(s.Engine)
+	flag.StringVar(&s.BaseURL, "api-base", s.BaseURL, "git.sr.ht instance to query, for self-hosted SourceHut")
+	flag.IntVar(&s.Options.RepoLimit, "repo-limit", 0, "only scan the first N repos (0 = all), in --repo-sort order")
+	flag.StringVar(&s.Options.RepoSort, "repo-sort", "", "order repos by updated, created or pushed before scanning")
+	repoFlag := flag.String("repo", "", "scan only this repository (~user/repo) instead of all of a user's repos")
+	cmd.Parse()
+	if _, ok := repoSortFields[s.Options.RepoSort]; s.Options.RepoSort != "" && !ok {
+		dossier.Log.Errorf("Invalid --repo-sort %q (SourceHut supports updated, created or pushed)\n", s.Options.RepoSort)
+		os.Exit(1)
+	}
+	if flag.NArg() < 1 && *repoFlag == "" {
+		dossier.Log.Errorln("Usage: go run ./cmd/sourcehut [flags] <sourcehut-username>")
+		dossier.Log.Errorln("       go run ./cmd/sourcehut --repo ~user/repo [flags]")
+		dossier.Log.Errorln("       go run ./cmd/sourcehut schema    (print the signatures.yaml JSON Schema)")
+		os.Exit(1)
+	}
+	username := flag.Arg(0)
+	scan := s.scanUser
+	if *repoFlag != "" {
+		if flag.NArg() > 0 {
+			dossier.Log.Errorln("--repo cannot be combined with a username")
+			os.Exit(1)
+		}
+		username = *repoFlag
+		scan = s.scanRepo
+	}
+
+	tokens, err := dossier.LoadTokens(".env")
+	if err != nil {
+		dossier.Log.Errorln("Error reading .env:", err)
+		os.Exit(1)
+	}
+	s.Token = tokens.SourceHut
+	if s.Token == "" {
+		dossier.Log.Errorln("❌ No SourceHut token found: set SRHT_TOKEN in .env or the environment to a personal access token from meta.sr.ht/oauth2")
+		os.Exit(1)
+	}
+	dossier.Log.Infoln("🔑 Found SourceHut token in .env or the environment!")
+
+	cmd.Run(username, scan)
+}
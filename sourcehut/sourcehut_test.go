@@ -0,0 +1,85 @@
+package sourcehut
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/0x4f53/dossier"
+)
+
+// doerFunc lets a function stand in for the HTTP client
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
+
+func respond(req *http.Request, status int, body string) (*http.Response, error) {
+	return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+}
+
+// authorOrder lists the author email findings in the order they were reported
+func authorOrder(findings []dossier.Finding) string {
+	var emails []string
+	for _, f := range findings {
+		if f.Type == "email" && f.Signature == "" {
+			emails = append(emails, f.Email)
+		}
+	}
+	return strings.Join(emails, " ")
+}
+
+func TestOrderAcrossPages(t *testing.T) {
+	commit := func(n string) string {
+		return `{"id":"c` + n + `","message":"Change ` + n + `","author":{"name":"C` + n + `","email":"c` + n + `@acme.io","time":"2024-05-0` + n + `T10:00:00Z"}}`
+	}
+	pages := map[string]string{ // by cursor
+		"":   `{"data":{"user":{"repository":{"log":{"results":[` + commit("3") + "," + commit("2") + `],"cursor":"p2"}}}}}`,
+		"p2": `{"data":{"user":{"repository":{"log":{"results":[` + commit("1") + `],"cursor":null}}}}}`,
+	}
+	cfg, err := dossier.DefaultPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		oldestFirst bool
+		want        string
+	}{
+		{false, "c3@acme.io c2@acme.io c1@acme.io"},
+		{true, "c1@acme.io c2@acme.io c3@acme.io"},
+	} {
+		s := NewScanner("", cfg, nil)
+		c := &dossier.Collector{}
+		s.Reporter = c
+		s.Doer = doerFunc(func(req *http.Request) (*http.Response, error) {
+			var payload struct {
+				Variables struct {
+					Cursor *string `json:"cursor"`
+				} `json:"variables"`
+			}
+			if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+				t.Fatal(err)
+			}
+			cursor := ""
+			if payload.Variables.Cursor != nil {
+				cursor = *payload.Variables.Cursor
+			}
+			if body, ok := pages[cursor]; ok {
+				return respond(req, 200, body)
+			}
+			t.Errorf("unexpected cursor %q", cursor)
+			return respond(req, 404, `{}`)
+		})
+		var r Repo
+		r.Name, r.Owner.CanonicalName = "tool", "~alice"
+		s.ScanRepoCommits(context.Background(), r, tt.oldestFirst)
+		if got := authorOrder(c.Findings); got != tt.want {
+			t.Errorf("oldest first %v: order %s, want %s", tt.oldestFirst, got, tt.want)
+		}
+		if s.Stats.CommitsProcessed != 3 {
+			t.Errorf("oldest first %v: processed %d commits, want 3", tt.oldestFirst, s.Stats.CommitsProcessed)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package dossier
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ========================== Trailers ==========================
+
+type Trailer struct {
+	Key   string
+	Name  string
+	Email string
+}
+
+var trailerKeys = map[string]string{
+	"co-authored-by": "Co-authored-by",
+	"signed-off-by":  "Signed-off-by",
+	"reviewed-by":    "Reviewed-by",
+	"acked-by":       "Acked-by",
+	"tested-by":      "Tested-by",
+	"reported-by":    "Reported-by",
+	"suggested-by":   "Suggested-by",
+	"helped-by":      "Helped-by",
+}
+
+var trailerRe = regexp.MustCompile(`(?im)^([a-z-]+-by):[ \t]*(.*?)[ \t]*<([^<>\s]+@[^<>\s]+)>[ \t]*$`)
+
+// ParseTrailers returns the identities in a commit message's git trailers
+func ParseTrailers(message string) []Trailer {
+	var trailers []Trailer
+	for _, m := range trailerRe.FindAllStringSubmatch(message, -1) {
+		if key, ok := trailerKeys[strings.ToLower(m[1])]; ok {
+			trailers = append(trailers, Trailer{Key: key, Name: m[2], Email: m[3]})
+		}
+	}
+	return trailers
+}
+
+// ========================== Name Normalization ==========================
+
+var viaSuffix = regexp.MustCompile(`(?i)\s+via\s+\S.*$`) // "Jane Doe via GitHub"
+
+// NormalizeName cleans a display name for --normalize-names: surrounding
+// quotes and whitespace, "via" suffixes and runs of spaces go, and names
+// written entirely in lower or upper case are title-cased.
+func NormalizeName(raw string) string {
+	const quotes = "\"'`“”‘’"
+	name := strings.Trim(strings.TrimSpace(raw), quotes)
+	name = strings.Trim(strings.TrimSpace(viaSuffix.ReplaceAllString(name, "")), quotes)
+	name = strings.Join(strings.Fields(name), " ")
+
+	// Only multi-word names made of letters are obviously cased; a single
+	// lowercase token is more likely a handle than a name
+	if !strings.Contains(name, " ") || (name != strings.ToLower(name) && name != strings.ToUpper(name)) {
+		return name
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !strings.ContainsRune(" -'.", r) {
+			return name
+		}
+	}
+	runes := []rune(strings.ToLower(name))
+	for i := range runes {
+		if i == 0 || strings.ContainsRune(" -'", runes[i-1]) {
+			runes[i] = unicode.ToUpper(runes[i])
+		}
+	}
+	return string(runes)
+}
+
+// ========================== Name Similarity ==========================
+
+// JaroWinkler scores two strings from 0 (nothing alike) to 1 (identical),
+// favouring strings that share a prefix, which suits personal names.
+func JaroWinkler(a, b string) float64 {
+	s1, s2 := []rune(a), []rune(b)
+	if len(s1) == 0 && len(s2) == 0 {
+		return 1
+	}
+	if len(s1) == 0 || len(s2) == 0 {
+		return 0
+	}
+
+	window := max(len(s1), len(s2))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+	matched1 := make([]bool, len(s1))
+	matched2 := make([]bool, len(s2))
+	matches := 0
+	for i := range s1 {
+		lo, hi := max(0, i-window), min(len(s2), i+window+1)
+		for j := lo; j < hi; j++ {
+			if !matched2[j] && s1[i] == s2[j] {
+				matched1[i], matched2[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, k := 0, 0
+	for i := range s1 {
+		if !matched1[i] {
+			continue
+		}
+		for !matched2[k] {
+			k++
+		}
+		if s1[i] != s2[k] {
+			transpositions++
+		}
+		k++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(s1)) + m/float64(len(s2)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(s1), len(s2)) && s1[prefix] == s2[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// ========================== Language Detection ==========================
+
+// Tiny word model: frequent function words and commit verbs per language.
+// Short commit messages carry too little text for character n-grams, but
+// a couple of these words is usually enough to tell languages apart.
+var languageWords = map[string][]string{
+	"English":    {"the", "and", "to", "of", "for", "in", "with", "from", "is", "add", "added", "fix", "fixed", "update", "updated", "remove", "removed", "use", "when", "not", "into", "bump", "readme", "initial", "commit"},
+	"German":     {"der", "die", "das", "und", "nicht", "mit", "für", "von", "ist", "ein", "eine", "auf", "zu", "im", "hinzugefügt", "behoben", "entfernt", "aktualisiert", "fehler", "neue", "jetzt", "wird"},
+	"French":     {"le", "la", "les", "des", "et", "pour", "du", "un", "une", "est", "au", "ajout", "correction", "mise", "jour", "suppression", "ajouté", "corrigé", "avec", "sur", "pas"},
+	"Spanish":    {"el", "los", "las", "del", "y", "para", "con", "se", "por", "es", "una", "agregar", "añadido", "corrección", "actualizar", "arreglado", "cambios", "nuevo", "eliminar"},
+	"Portuguese": {"o", "os", "do", "da", "e", "para", "com", "em", "não", "um", "uma", "adicionado", "correção", "atualização", "ajuste", "ajustes", "novo", "removido", "arquivo"},
+	"Italian":    {"il", "lo", "gli", "di", "della", "per", "con", "che", "non", "un", "aggiunto", "corretto", "aggiornamento", "aggiornato", "rimosso", "modifica", "nuovo"},
+	"Dutch":      {"het", "een", "en", "van", "voor", "met", "niet", "op", "is", "toegevoegd", "opgelost", "bijgewerkt", "verwijderd", "aangepast", "nieuwe"},
+}
+
+var languageIndex = func() map[string][]string {
+	idx := map[string][]string{}
+	for lang, words := range languageWords {
+		for _, w := range words {
+			idx[w] = append(idx[w], lang)
+		}
+	}
+	return idx
+}()
+
+// DetectLanguage returns the most likely language of text, or "" when it
+// can't tell.
+func DetectLanguage(text string) string {
+	scripts := map[string]int{}
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			scripts["Russian"]++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			scripts["Japanese"]++
+		case unicode.Is(unicode.Hangul, r):
+			scripts["Korean"]++
+		case unicode.Is(unicode.Han, r):
+			scripts["Chinese"]++
+		}
+	}
+	if scripts["Japanese"] > 0 {
+		return "Japanese" // kana are a safer signal than shared Han characters
+	}
+	if best := bestScore(scripts); best != "" {
+		return best
+	}
+
+	scores := map[string]int{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		for _, lang := range languageIndex[word] {
+			scores[lang]++
+		}
+	}
+	return bestScore(scores)
+}
+
+// bestScore returns the key with the highest count, or "" on a tie or when
+// nothing scored.
+func bestScore(scores map[string]int) string {
+	best, bestN, tie := "", 0, false
+	for k, n := range scores {
+		switch {
+		case n > bestN:
+			best, bestN, tie = k, n, false
+		case n == bestN && n > 0:
+			tie = true
+		}
+	}
+	if tie {
+		return ""
+	}
+	return best
+}
+
+// ========================== Binary Detection ==========================
+
+// LooksBinary flags pasted blobs and minified bundles: text with many
+// non-printable characters, or a very long run with no whitespace.
+func LooksBinary(text string) bool {
+	if text == "" {
+		return false
+	}
+	nonPrintable, total := 0, 0
+	for _, r := range text {
+		total++
+		if r == utf8.RuneError || (!unicode.IsPrint(r) && !unicode.IsSpace(r)) {
+			nonPrintable++
+		}
+	}
+	if float64(nonPrintable)/float64(total) > 0.1 {
+		return true
+	}
+	for _, token := range strings.Fields(text) {
+		if len(token) > 500 {
+			return true
+		}
+	}
+	return false
+}
+
+// ========================== UTC Offsets ==========================
+
+// ParseOffset normalises "+05:30", "+0530" or "Z" to the "-07:00" form
+func ParseOffset(s string) (string, error) {
+	for _, layout := range []string{"-07:00", "-0700", "Z07:00"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format("-07:00"), nil
+		}
+	}
+	return "", fmt.Errorf("invalid UTC offset %q, expected e.g. +05:30", s)
+}
@@ -0,0 +1,129 @@
+package dossier
+
+import (
+	"encoding/base64"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		message, want string
+	}{
+		{"Fix the crash when the config file is missing", "English"},
+		{"Update README and bump version", "English"},
+		{"Fehler behoben, der Parser ist jetzt schneller", "German"},
+		{"Correction du bug pour la mise à jour", "French"},
+		{"Se agregar soporte para los nuevos archivos", "Spanish"},
+		{"Toegevoegd: een nieuwe optie voor het exporteren", "Dutch"},
+		{"Исправлена ошибка в парсере", "Russian"},
+		{"パーサーのバグを修正", "Japanese"},
+		{"修复解析器错误", "Chinese"},
+		{"파서 버그 수정", "Korean"},
+		{"v1.2.3", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.message); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		// Reference values from Winkler's paper
+		{"MARTHA", "MARHTA", 0.961},
+		{"DWAYNE", "DUANE", 0.840},
+		{"DIXON", "DICKSONX", 0.813},
+		{"alice", "alice", 1},
+		{"", "", 1},
+		{"alice", "", 0},
+		{"abc", "xyz", 0},
+	}
+	for _, tt := range tests {
+		got := JaroWinkler(tt.a, tt.b)
+		if math.Abs(got-tt.want) > 0.001 {
+			t.Errorf("JaroWinkler(%q, %q) = %.3f, want %.3f", tt.a, tt.b, got, tt.want)
+		}
+		if back := JaroWinkler(tt.b, tt.a); math.Abs(back-got) > 1e-9 {
+			t.Errorf("JaroWinkler(%q, %q) = %.3f, but %.3f the other way round", tt.a, tt.b, got, back)
+		}
+	}
+}
+
+func TestLooksBinary(t *testing.T) {
+	blob := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("\x89PNG\r\n\x1a\n", 60)))
+	tests := []struct {
+		name, text string
+		want       bool
+	}{
+		{"commit message", "Fix the parser\n\nThe tokenizer dropped the last line.\n\nSigned-off-by: Alice <alice@acme.io>", false},
+		{"unicode message", "Исправлена ошибка в парсере — 修复解析器错误 ✓", false},
+		{"long url", "See https://example.com/" + strings.Repeat("a", 400), false},
+		{"empty", "", false},
+		{"base64 blob", "Add logo\n\n" + blob, true},
+		{"minified bundle", "Build: " + strings.Repeat("a=f(1);b=g(a);", 40), true},
+		{"raw bytes", "Oops \x00\x01\x02\x03\x04\x05\xff\xfe pasted", true},
+	}
+	for _, tt := range tests {
+		if got := LooksBinary(tt.text); got != tt.want {
+			t.Errorf("%s: LooksBinary = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseOffset(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"+05:30", "+05:30"},
+		{"+0530", "+05:30"},
+		{"-07:00", "-07:00"},
+		{"-0700", "-07:00"},
+		{"Z", "+00:00"},
+		{"+00:00", "+00:00"},
+	}
+	for _, tt := range tests {
+		if got, err := ParseOffset(tt.in); err != nil || got != tt.want {
+			t.Errorf("ParseOffset(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "5:30", "UTC+1", "+25:00", "IST"} {
+		if got, err := ParseOffset(bad); err == nil {
+			t.Errorf("ParseOffset(%q) = %q, want an error", bad, got)
+		}
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		raw, want string
+	}{
+		{"Jane Doe", "Jane Doe"},
+		{"  Jane   Doe  ", "Jane Doe"},
+		{`"Jane Doe"`, "Jane Doe"},
+		{"“Jane Doe”", "Jane Doe"},
+		{"'jane doe'", "Jane Doe"},
+		{"Jane Doe via GitHub", "Jane Doe"},
+		{"\"Jane Doe\" via GitLab", "Jane Doe"},
+		{"jane doe", "Jane Doe"},
+		{"JANE DOE", "Jane Doe"},
+		{"mary-jane o'neil", "Mary-Jane O'Neil"},
+		{"josé álvarez", "José Álvarez"},
+		{"McDonald", "McDonald"},
+		{"Jane de la Cruz", "Jane de la Cruz"}, // mixed case is left alone
+		{"jdoe", "jdoe"},                       // a handle, not a name
+		{"dev bot 2", "dev bot 2"},             // not just letters
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeName(tt.raw); got != tt.want {
+			t.Errorf("NormalizeName(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package dossier
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ========================== XLSX ==========================
+
+// XLSXReporter collects findings and writes an Excel workbook with an
+// Identities sheet and a Findings sheet when the scan is closed. The
+// SpreadsheetML is written directly, so no spreadsheet library is needed.
+type XLSXReporter struct {
+	path       string
+	identities *IdentityStore
+	findings   []Finding
+}
+
+func NewXLSXReporter(path string, identities *IdentityStore) *XLSXReporter {
+	return &XLSXReporter{path: path, identities: identities}
+}
+
+func (r *XLSXReporter) Report(f Finding) {
+	r.findings = append(r.findings, f)
+}
+
+func (r *XLSXReporter) Close() error {
+	idRows := [][]any{{"Email", "Name", "Aliases", "Sources", "Commits", "First seen", "Last seen"}}
+	for _, id := range r.identities.Identities() {
+		idRows = append(idRows, []any{id.Email, id.CanonicalName(), strings.Join(id.Aliases(), ", "),
+			strings.Join(id.SourceList(), ", "), id.Commits, formatDay(id.FirstSeen), formatDay(id.LastSeen)})
+	}
+
+	// One column per Finding field, named after its JSON key
+	t := reflect.TypeOf(Finding{})
+	header := []any{}
+	var columns []int
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			header = append(header, strings.Split(t.Field(i).Tag.Get("json"), ",")[0])
+			columns = append(columns, i)
+		}
+	}
+	findingRows := [][]any{header}
+	for _, f := range r.findings {
+		v := reflect.ValueOf(f)
+		row := make([]any, len(columns))
+		for col, i := range columns {
+			if field := v.Field(i); !field.IsZero() {
+				row[col] = field.Interface()
+			}
+		}
+		findingRows = append(findingRows, row)
+	}
+	return writeXLSX(r.path, []string{"Identities", "Findings"}, [][][]any{idRows, findingRows})
+}
+
+// writeXLSX writes a minimal workbook: one sheet per name, the first row
+// frozen as a header and every column sized to its longest value.
+func writeXLSX(path string, names []string, sheets [][][]any) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(file)
+	add := func(name, content string) {
+		if err != nil {
+			return
+		}
+		var w io.Writer
+		if w, err = zw.Create(name); err == nil {
+			_, err = io.WriteString(w, xml.Header+content)
+		}
+	}
+
+	var overrides, entries, rels strings.Builder
+	for i, name := range names {
+		n := i + 1
+		fmt.Fprintf(&overrides, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&entries, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(name), n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+		add(fmt.Sprintf("xl/worksheets/sheet%d.xml", n), sheetXML(sheets[i]))
+	}
+	add("[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`+
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`+
+		`<Default Extension="xml" ContentType="application/xml"/>`+
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`+
+		overrides.String()+`</Types>`)
+	add("_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`+
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>`+
+		`</Relationships>`)
+	add("xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`+
+		`<sheets>`+entries.String()+`</sheets></workbook>`)
+	add("xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`+
+		rels.String()+`</Relationships>`)
+
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func sheetXML(rows [][]any) string {
+	var widths []int
+	var data strings.Builder
+	for r, row := range rows {
+		fmt.Fprintf(&data, `<row r="%d">`, r+1)
+		for c, val := range row {
+			if c >= len(widths) {
+				widths = append(widths, 0)
+			}
+			ref := fmt.Sprintf("%s%d", columnName(c), r+1)
+			switch v := val.(type) {
+			case nil:
+				continue
+			case int:
+				fmt.Fprintf(&data, `<c r="%s"><v>%d</v></c>`, ref, v)
+				widths[c] = max(widths[c], len(strconv.Itoa(v)))
+			default:
+				s := fmt.Sprint(v)
+				fmt.Fprintf(&data, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlEscape(s))
+				widths[c] = max(widths[c], utf8.RuneCountInString(s))
+			}
+		}
+		data.WriteString(`</row>`)
+	}
+
+	var cols strings.Builder
+	for c, w := range widths {
+		fmt.Fprintf(&cols, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, c+1, c+1, min(max(w, 6), 60)+2)
+	}
+	return `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>` +
+		`<cols>` + cols.String() + `</cols><sheetData>` + data.String() + `</sheetData></worksheet>`
+}
+
+// columnName turns a 0-based index into A, B, ..., Z, AA, ...
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
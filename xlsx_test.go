@@ -0,0 +1,129 @@
+package dossier
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readSheets opens a workbook and returns each sheet's cells by reference
+// ("B2"), keyed by sheet name, plus the raw sheet XML
+func readSheets(t *testing.T, path string) (map[string]map[string]string, map[string]string) {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	parts := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name], err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("workbook has no %s", name)
+		}
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(parts["xl/workbook.xml"], &workbook); err != nil {
+		t.Fatal(err)
+	}
+	cells, raw := map[string]map[string]string{}, map[string]string{}
+	for i, s := range workbook.Sheets {
+		data := parts["xl/worksheets/sheet"+string(rune('1'+i))+".xml"]
+		var sheet struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"sheetData>row>c"`
+		}
+		if err := xml.Unmarshal(data, &sheet); err != nil {
+			t.Fatalf("%s: %v", s.Name, err)
+		}
+		cells[s.Name] = map[string]string{}
+		for _, c := range sheet.Cells {
+			cells[s.Name][c.Ref] = c.Value + c.Inline
+		}
+		raw[s.Name] = string(data)
+	}
+	return cells, raw
+}
+
+func TestXLSXReporterWorkbook(t *testing.T) {
+	ids := NewIdentityStore()
+	day := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	ids.Add("alice@acme.io", "Alice Smith", "alice/tool", day)
+	ids.Add("alice@acme.io", "asmith", "alice/site", day.AddDate(0, 0, 3))
+
+	path := filepath.Join(t.TempDir(), "report.xlsx")
+	r := NewXLSXReporter(path, ids)
+	r.Report(Finding{Type: "email", Email: "alice@acme.io", Name: "Alice <Smith> & Co", Seen: 2})
+	r.Report(Finding{Type: "saas_credential", Signature: "stripe_secret_key", Line: 4}.WithSecret(strings.Repeat("9xQ", 8)))
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	cells, raw := readSheets(t, path)
+	id := cells["Identities"]
+	for ref, want := range map[string]string{
+		"A1": "Email", "B1": "Name", "E1": "Commits", "G1": "Last seen",
+		"A2": "alice@acme.io", "C2": "asmith", "D2": "alice/site, alice/tool", "E2": "2", "F2": "2024-05-01", "G2": "2024-05-04",
+	} {
+		if id[ref] != want {
+			t.Errorf("Identities!%s = %q, want %q", ref, id[ref], want)
+		}
+	}
+
+	found := cells["Findings"]
+	col := map[string]string{} // header -> column letter
+	for i := 0; found[columnName(i)+"1"] != ""; i++ {
+		col[found[columnName(i)+"1"]] = columnName(i)
+	}
+	if found["A1"] != "type" || col["email"] == "" || col["secret"] == "" || col["line"] == "" {
+		t.Fatalf("Findings header = %v", col)
+	}
+	if _, ok := col["secretHash"]; ok || len(col) == 0 {
+		t.Errorf("header has an unexported field: %v", col)
+	}
+	if found["A2"] != "email" || found[col["name"]+"2"] != "Alice <Smith> & Co" || found[col["seen"]+"2"] != "2" {
+		t.Errorf("email row = type %q, name %q, seen %q", found["A2"], found[col["name"]+"2"], found[col["seen"]+"2"])
+	}
+	if found["A3"] != "saas_credential" || found[col["line"]+"3"] != "4" || found[col["secret"]+"3"] == "" || strings.Contains(raw["Findings"], strings.Repeat("9xQ", 8)) {
+		t.Errorf("secret row = line %q, secret %q; want line 4 and only the redacted value", found[col["line"]+"3"], found[col["secret"]+"3"])
+	}
+	if found[col["email"]+"3"] != "" {
+		t.Errorf("empty field written as %q", found[col["email"]+"3"])
+	}
+
+	for name, sheet := range raw {
+		if !strings.Contains(sheet, `state="frozen"`) || !strings.Contains(sheet, `<col min="1" max="1"`) {
+			t.Errorf("%s has no frozen header or column widths", name)
+		}
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %s, want %s", i, got, want)
+		}
+	}
+}